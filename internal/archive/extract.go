@@ -0,0 +1,214 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ExtractOptions configure an Extract call.
+type ExtractOptions struct {
+	Format Format
+	// Verify, when set, is called after each entry has been written to its
+	// temporary file, with the entry name and the number of bytes written. A
+	// non-nil error aborts the extraction before anything is renamed into place.
+	Verify func(name string, size int64) error
+}
+
+// FormatOf infers the archive format from path's extension, defaulting to zip.
+func FormatOf(path string) Format {
+	if strings.HasSuffix(strings.ToLower(path), ".tar.gz") {
+		return FormatTarGz
+	}
+	return FormatZip
+}
+
+// Extract restores the given entries of the archive at archivePath. For
+// extraction an Entry's Name selects the member inside the archive and Source
+// is the on-disk path it is written back to. Every entry is first written to
+// a temp file next to Source; only once all of them were found, written, and
+// verified are the temp files renamed into place, and a Source that exists by
+// then fails the extraction instead of being replaced. On any error no temp
+// file is left behind and no target is created. Modification times recorded
+// in the archive are restored. ctx cancels between entries.
+func Extract(ctx context.Context, archivePath string, entries []Entry, opts ExtractOptions) (retErr error) {
+	want := make(map[string]string, len(entries))
+	for _, e := range entries {
+		if _, dup := want[e.Name]; dup {
+			return fmt.Errorf("entry %q requested twice", e.Name)
+		}
+		want[e.Name] = e.Source
+	}
+	written := make(map[string]string, len(entries)) // entry name -> temp file
+	defer func() {
+		if retErr != nil {
+			for _, tmp := range written {
+				_ = os.Remove(tmp)
+			}
+		}
+	}()
+
+	emit := func(name string, r io.Reader, mode fs.FileMode, modTime time.Time) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		target, ok := want[name]
+		if !ok {
+			return nil
+		}
+		if _, dup := written[name]; dup {
+			return nil // first occurrence wins, matching how it was written
+		}
+		tmp, n, err := writeExtracted(target, r, mode, modTime)
+		if err != nil {
+			return fmt.Errorf("extract %q: %w", name, err)
+		}
+		written[name] = tmp
+		if opts.Verify != nil {
+			if err := opts.Verify(name, n); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var err error
+	switch opts.Format {
+	case FormatZip:
+		err = walkZip(archivePath, emit)
+	case FormatTarGz:
+		err = walkTarGz(archivePath, emit)
+	default:
+		err = fmt.Errorf("unknown archive format %q", opts.Format)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if _, ok := written[e.Name]; !ok {
+			return fmt.Errorf("entry %q not found in %s", e.Name, archivePath)
+		}
+	}
+	return commitExtracted(entries, written)
+}
+
+// commitExtracted renames every temp file onto its target. A target that has
+// appeared since the caller checked it is left alone and fails the call. If a
+// rename fails, targets already renamed are removed again (they did not exist
+// before the extraction) so the call stays all-or-nothing.
+func commitExtracted(entries []Entry, written map[string]string) error {
+	var done []string
+	for _, e := range entries {
+		err := fs.ErrExist
+		if _, statErr := os.Lstat(e.Source); os.IsNotExist(statErr) {
+			err = os.Rename(written[e.Name], e.Source)
+		}
+		if err != nil {
+			for _, p := range done {
+				_ = os.Remove(p)
+			}
+			return fmt.Errorf("restore %q: %w", e.Source, err)
+		}
+		delete(written, e.Name)
+		done = append(done, e.Source)
+	}
+	return nil
+}
+
+// writeExtracted writes r to a new temp file in target's directory and
+// returns its path and size. The temp file is removed again on error.
+func writeExtracted(target string, r io.Reader, mode fs.FileMode, modTime time.Time) (string, int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return "", 0, err
+	}
+	perm := mode.Perm()
+	if perm == 0 {
+		perm = 0o600
+	}
+	f, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return "", 0, err
+	}
+	n, err := io.Copy(f, r) //#nosec G110 -- only members movelooper itself wrote are extracted, and sizes are verified
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", n, err
+	}
+	if !modTime.IsZero() {
+		_ = os.Chtimes(f.Name(), modTime, modTime)
+	}
+	return f.Name(), n, nil
+}
+
+type emitFunc func(name string, r io.Reader, mode fs.FileMode, modTime time.Time) error
+
+func walkZip(path string, emit emitFunc) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if err := emitZipFile(f, emit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func emitZipFile(f *zip.File, emit emitFunc) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return emit(f.Name, rc, f.Mode(), f.Modified)
+}
+
+func walkTarGz(path string, emit emitFunc) error {
+	f, err := os.Open(path) //#nosec G304 -- path comes from the history entry
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := emit(hdr.Name, tr, hdr.FileInfo().Mode(), hdr.ModTime); err != nil {
+			return err
+		}
+	}
+}
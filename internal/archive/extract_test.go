@@ -0,0 +1,106 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatOf(t *testing.T) {
+	assert.Equal(t, FormatTarGz, FormatOf("/x/photos.TAR.GZ"))
+	assert.Equal(t, FormatZip, FormatOf("/x/photos.zip"))
+}
+
+func TestExtract_RoundTrip(t *testing.T) {
+	for _, f := range []Format{FormatZip, FormatTarGz} {
+		t.Run(string(f), func(t *testing.T) {
+			src := t.TempDir()
+			a := writeSource(t, src, "a.txt", "alpha")
+			b := writeSource(t, src, "b.txt", "bravo!")
+			arc := filepath.Join(t.TempDir(), "out"+Extension(f))
+			require.NoError(t, Write(context.Background(), arc, []Entry{{Source: a, Name: "a.txt"}, {Source: b, Name: "sub/b.txt"}}, Options{Format: f}))
+
+			out := t.TempDir()
+			sizes := map[string]int64{}
+			err := Extract(context.Background(), arc, []Entry{
+				{Source: filepath.Join(out, "a.txt"), Name: "a.txt"},
+				{Source: filepath.Join(out, "nested", "b.txt"), Name: "sub/b.txt"},
+			}, ExtractOptions{Format: f, Verify: func(name string, size int64) error {
+				sizes[name] = size
+				return nil
+			}})
+			require.NoError(t, err)
+
+			data, err := os.ReadFile(filepath.Join(out, "nested", "b.txt"))
+			require.NoError(t, err)
+			assert.Equal(t, "bravo!", string(data))
+			assert.Equal(t, map[string]int64{"a.txt": 5, "sub/b.txt": 6}, sizes)
+		})
+	}
+}
+
+func TestExtract_MissingEntryLeavesNothing(t *testing.T) {
+	src := t.TempDir()
+	a := writeSource(t, src, "a.txt", "alpha")
+	arc := filepath.Join(t.TempDir(), "out.zip")
+	require.NoError(t, Write(context.Background(), arc, []Entry{{Source: a, Name: "a.txt"}}, Options{Format: FormatZip}))
+
+	out := t.TempDir()
+	err := Extract(context.Background(), arc, []Entry{
+		{Source: filepath.Join(out, "a.txt"), Name: "a.txt"},
+		{Source: filepath.Join(out, "z.txt"), Name: "z.txt"},
+	}, ExtractOptions{Format: FormatZip})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "z.txt")
+	leftovers, err := os.ReadDir(out)
+	require.NoError(t, err)
+	assert.Empty(t, leftovers, "neither a.txt nor a temp file is left behind")
+}
+
+func TestExtract_VerifyFailureAborts(t *testing.T) {
+	src := t.TempDir()
+	a := writeSource(t, src, "a.txt", "alpha")
+	arc := filepath.Join(t.TempDir(), "out.tar.gz")
+	require.NoError(t, Write(context.Background(), arc, []Entry{{Source: a, Name: "a.txt"}}, Options{Format: FormatTarGz}))
+
+	out := t.TempDir()
+	boom := errors.New("boom")
+	err := Extract(context.Background(), arc, []Entry{{Source: filepath.Join(out, "a.txt"), Name: "a.txt"}},
+		ExtractOptions{Format: FormatTarGz, Verify: func(string, int64) error { return boom }})
+	require.ErrorIs(t, err, boom)
+	leftovers, err := os.ReadDir(out)
+	require.NoError(t, err)
+	assert.Empty(t, leftovers, "neither a.txt nor a temp file is left behind")
+}
+
+func TestExtract_LeavesExistingFilesAlone(t *testing.T) {
+	src := t.TempDir()
+	a := writeSource(t, src, "a.txt", "alpha")
+	b := writeSource(t, src, "b.txt", "bravo")
+	arc := filepath.Join(t.TempDir(), "out.zip")
+	require.NoError(t, Write(context.Background(), arc, []Entry{{Source: a, Name: "a.txt"}, {Source: b, Name: "b.txt"}}, Options{Format: FormatZip}))
+
+	out := t.TempDir()
+	userTmp := writeSource(t, out, "a.txt.tmp", "keep me")
+	taken := writeSource(t, out, "b.txt", "arrived since")
+	err := Extract(context.Background(), arc, []Entry{
+		{Source: filepath.Join(out, "a.txt"), Name: "a.txt"},
+		{Source: taken, Name: "b.txt"},
+	}, ExtractOptions{Format: FormatZip})
+	require.ErrorIs(t, err, fs.ErrExist)
+
+	for path, want := range map[string]string{userTmp: "keep me", taken: "arrived since"} {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(data))
+	}
+	leftovers, err := os.ReadDir(out)
+	require.NoError(t, err)
+	assert.Len(t, leftovers, 2, "a.txt is rolled back and no temp file is left behind")
+}
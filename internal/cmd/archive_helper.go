@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/lucasassuncao/movelooper/internal/archive"
@@ -66,7 +68,7 @@ func archiveCategory(ctx context.Context, m *models.Movelooper, category *models
 	}
 	m.Logger.Info(fmt.Sprintf("%s archived %d %s", label, len(entries), fileNoun("all", len(entries))), m.Logger.Args("archive", destPath))
 
-	recordArchiveHistory(m, category, destPath, batch)
+	recordArchiveHistory(m, category, destPath, entries, batch)
 
 	if !arc.KeepsSource() {
 		deleteArchivedSources(m, files)
@@ -76,12 +78,14 @@ func archiveCategory(ctx context.Context, m *models.Movelooper, category *models
 
 // archiveEntries builds (source, entry-name) pairs. With flatten=false the entry
 // name preserves the file's path relative to the category source directory (so
-// recursive scans keep their structure); otherwise the base name is used. Entry
-// names are always slash-separated.
+// recursive scans keep their structure); otherwise the base name is used, with
+// (n) appended when files from different folders share it, the way the rename
+// conflict strategy does. Entry names are always slash-separated and unique.
 func archiveEntries(category *models.Category, files []scanner.FileEntry) []archive.Entry {
 	flatten := category.Destination.Archive.Flatten
 	root := category.Source.Path
 	entries := make([]archive.Entry, 0, len(files))
+	used := make(map[string]bool, len(files))
 	for _, fe := range files {
 		src := filepath.Join(fe.Dir, fe.Entry.Name())
 		name := fe.Entry.Name()
@@ -90,7 +94,16 @@ func archiveEntries(category *models.Category, files []scanner.FileEntry) []arch
 				name = rel
 			}
 		}
-		entries = append(entries, archive.Entry{Source: src, Name: filepath.ToSlash(name)})
+		name = filepath.ToSlash(name)
+		if used[name] {
+			ext := path.Ext(name)
+			stem := strings.TrimSuffix(name, ext)
+			for n := 1; used[name]; n++ {
+				name = fmt.Sprintf("%s(%d)%s", stem, n, ext)
+			}
+		}
+		used[name] = true
+		entries = append(entries, archive.Entry{Source: src, Name: name})
 	}
 	return entries
 }
@@ -118,19 +131,39 @@ func archiveConflictPath(m *models.Movelooper, cs models.ConflictStrategy, destP
 	}
 }
 
-func recordArchiveHistory(m *models.Movelooper, category *models.Category, destPath string, batch moveBatch) {
+// recordArchiveHistory records one entry for the archive, listing every member
+// with its size so undo can extract the files back and verify them. It runs
+// before keep-source: false deletes the originals, so the sizes are still
+// readable from disk. A member whose size cannot be read leaves the archive
+// unrecorded: undo would fail to verify it, or lose it by skipping it when it
+// deletes the archive.
+func recordArchiveHistory(m *models.Movelooper, category *models.Category, destPath string, entries []archive.Entry, batch moveBatch) {
 	if batch.recorder == nil {
 		return
 	}
-	// One entry marks the archive batch; Action "archive" makes undo skip it.
-	if err := batch.recorder.Add(history.Entry{
+	members := make([]history.ArchiveMember, 0, len(entries))
+	for _, e := range entries {
+		info, err := os.Stat(e.Source)
+		if err != nil {
+			m.Logger.Warn("archive not recorded in history, undo will not restore it",
+				m.Logger.Args("path", destPath, "error", err.Error()))
+			return
+		}
+		members = append(members, history.ArchiveMember{Source: e.Source, Name: e.Name, Size: info.Size()})
+	}
+	entry := history.Entry{
 		Source:      category.Source.Path,
 		Destination: destPath,
 		Timestamp:   time.Now(),
 		BatchID:     batch.batchID,
 		Action:      string(models.ActionArchive),
 		Category:    category.Name,
-	}); err != nil {
+		Members:     members,
+	}
+	if err := entry.Stamp(m.Config.History.Hash); err != nil {
+		m.Logger.Warn("could not fingerprint archive", m.Logger.Args("path", destPath, "error", err.Error()))
+	}
+	if err := batch.recorder.Add(entry); err != nil {
 		m.Logger.Warn("failed to record archive in history", m.Logger.Args("error", err.Error()))
 	}
 }
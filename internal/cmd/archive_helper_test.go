@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/lucasassuncao/movelooper/internal/archive"
+	"github.com/lucasassuncao/movelooper/internal/history"
 	"github.com/lucasassuncao/movelooper/internal/models"
 	"github.com/lucasassuncao/movelooper/internal/scanner"
 	"github.com/stretchr/testify/assert"
@@ -106,3 +108,53 @@ func TestArchiveCategory_WriteFailureReturnsError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Empty(t, path)
 }
+
+// TestArchiveEntries_FlattenDeduplicatesNames verifies that files sharing a
+// base name in different folders get distinct members when flattened, so each
+// one can be extracted back to its own source.
+func TestArchiveEntries_FlattenDeduplicatesNames(t *testing.T) {
+	src := t.TempDir()
+	var files []scanner.FileEntry
+	for _, sub := range []string{"a", "b"} {
+		dir := filepath.Join(src, sub)
+		require.NoError(t, os.Mkdir(dir, 0o755))
+		files = append(files, fileEntriesFrom(t, dir, "x.jpg")...)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "x.jpg"), []byte(sub), 0o644))
+	}
+	cat := archiveTestCategory(src, t.TempDir(), &models.ArchiveConfig{Format: "zip", Flatten: true})
+
+	entries := archiveEntries(cat, files)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "x.jpg", entries[0].Name)
+	assert.Equal(t, "x(1).jpg", entries[1].Name)
+
+	dest := filepath.Join(t.TempDir(), "images.zip")
+	require.NoError(t, archive.Write(context.Background(), dest, entries, archive.Options{Format: archive.FormatZip}))
+	for _, e := range entries {
+		require.NoError(t, os.Remove(e.Source))
+	}
+	require.NoError(t, archive.Extract(context.Background(), dest, entries, archive.ExtractOptions{Format: archive.FormatZip}))
+
+	for _, sub := range []string{"a", "b"} {
+		data, err := os.ReadFile(filepath.Join(src, sub, "x.jpg"))
+		require.NoError(t, err)
+		assert.Equal(t, sub, string(data))
+	}
+}
+
+// TestRecordArchiveHistory_UnreadableMemberSkipsEntry verifies that an archive
+// is left out of history rather than recorded with a member size undo could
+// never verify.
+func TestRecordArchiveHistory_UnreadableMemberSkipsEntry(t *testing.T) {
+	src := t.TempDir()
+	cat := archiveTestCategory(src, t.TempDir(), &models.ArchiveConfig{Format: "zip"})
+	var buf bytes.Buffer
+	m := newBufMovelooper(t, &buf, []*models.Category{cat})
+	rec := &history.Buffer{}
+	batch := moveBatch{moved: make(movedSet), batchID: "batch_test", stats: &runStats{}, recorder: rec}
+
+	entries := []archive.Entry{{Source: filepath.Join(src, "gone.jpg"), Name: "gone.jpg"}}
+	recordArchiveHistory(m, cat, filepath.Join(t.TempDir(), "images.zip"), entries, batch)
+	assert.Zero(t, rec.Len())
+	assert.Contains(t, buf.String(), "archive not recorded in history")
+}
@@ -10,6 +10,10 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// leafFilterFields are the filter fields that test the file itself, as opposed
+// to the any/all/not combinators.
+var leafFilterFields = []string{"match", "path", "age", "date", "size", "mime", "exif", "tags", "video"}
+
 // MovelooperValidators is the rule set enforced by the edit command at
 // validate/save time.
 //
@@ -35,21 +39,28 @@ var MovelooperValidators = []editor.Validator{
 	// hints; NoDuplicates skips unnamed entries).
 	editor.NoDuplicates("categories", "name"),
 
-	// within match blocks, literal/regex/glob are mutually exclusive at any depth.
-	// One validator suffices: MutuallyExclusiveNested walks the full subtree.
+	// within match blocks, literal/regex/glob are mutually exclusive at any depth,
+	// and so are regex/glob within path blocks. One validator per block
+	// suffices: MutuallyExclusiveNested walks the full subtree.
 	editor.MutuallyExclusiveNested("categories.source.filter.match", "literal", "regex", "glob"),
+	editor.MutuallyExclusiveNested("categories.source.filter.path", "regex", "glob"),
+
+	// age and date pick their date with field or from, not both.
+	editor.MutuallyExclusiveNested("categories.source.filter.age", "field", "from"),
+	editor.MutuallyExclusiveNested("categories.source.filter.date", "field", "from"),
 
 	// any and all are mutually exclusive with each other and with leaf fields
-	// (match/age/size). not is a modifier and may coexist with any/all.
+	// (leafFilterFields). not is a modifier and may coexist with any/all.
 	// Four validators cover all nesting depths.
-	editor.MutuallyExclusiveGroupsNested("categories.source.filter", []string{"any"}, []string{"all"}, []string{"match", "age", "size", "mime"}),
-	editor.MutuallyExclusiveGroupsNested("categories.source.filter.any", []string{"any"}, []string{"all"}, []string{"match", "age", "size", "mime"}),
-	editor.MutuallyExclusiveGroupsNested("categories.source.filter.all", []string{"any"}, []string{"all"}, []string{"match", "age", "size", "mime"}),
-	editor.MutuallyExclusiveGroupsNested("categories.source.filter.not", []string{"any"}, []string{"all"}, []string{"match", "age", "size", "mime"}),
+	editor.MutuallyExclusiveGroupsNested("categories.source.filter", []string{"any"}, []string{"all"}, leafFilterFields),
+	editor.MutuallyExclusiveGroupsNested("categories.source.filter.any", []string{"any"}, []string{"all"}, leafFilterFields),
+	editor.MutuallyExclusiveGroupsNested("categories.source.filter.all", []string{"any"}, []string{"all"}, leafFilterFields),
+	editor.MutuallyExclusiveGroupsNested("categories.source.filter.not", []string{"any"}, []string{"all"}, leafFilterFields),
 
-	// age and size min/max pairs must be ordered at any nesting depth.
+	// age, size and video min/max pairs must be ordered at any nesting depth.
 	editor.CrossFieldOrderedNested("categories.source.filter.age", "min", "max"),
 	editor.CrossFieldOrderedNested("categories.source.filter.size", "min", "max"),
+	editor.CrossFieldOrderedNested("categories.source.filter.video", "min-duration", "max-duration"),
 
 	// filter nesting (any/all/not) cannot exceed config.MaxFilterNestingDepth.
 	// Reuses config.FilterDepthOK so the limit is enforced here in the TUI and
@@ -70,3 +70,30 @@ func TestMovelooperValidators_ArchiveRequired(t *testing.T) {
 	}
 	assert.True(t, found, "expected an archive-required violation")
 }
+
+// TestMovelooperValidators_DateChoice verifies that age and date reject
+// field and from together, at any depth.
+func TestMovelooperValidators_DateChoice(t *testing.T) {
+	t.Parallel()
+	raw := []byte(`
+categories:
+  - name: c
+    source:
+      path: a
+      extensions: [pdf]
+      filter:
+        age:
+          min: 24h
+          field: atime
+          from: name
+        not:
+          - date:
+              after: start-of-month
+              field: birth
+              from: exif
+    destination:
+      path: b
+`)
+	errs := editor.RunAll(editor.Wire(MovelooperValidators, editor.Config{}), raw, nil)
+	assert.Len(t, errs, 2)
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/lucasassuncao/movelooper/internal/config"
+	"github.com/lucasassuncao/movelooper/internal/filters"
+	"github.com/lucasassuncao/movelooper/internal/models"
+	"github.com/lucasassuncao/movelooper/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+// ExplainCmd defines the "explain" subcommand.
+func ExplainCmd(m *models.Movelooper) *cobra.Command {
+	var (
+		categoryFilter  string
+		includeDisabled bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "explain <file>...",
+		Short: "Show which category would take a file, or why none does",
+		Long: `Explains what a run of movelooper would do with each file: the category that
+takes it, or for every category whose source holds it, why that category
+passes it by — a .mlignore rule (named with its file and line), an exclusion,
+the recursion or depth limits, the extension list or the filter.`,
+		Example: `  movelooper explain ~/Downloads/report.pdf
+  movelooper explain ~/Downloads/*.part --category videos`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			categories, err := FilterCategories(m.Categories, ParseCategoryNames(categoryFilter), includeDisabled, m.Logger)
+			if err != nil {
+				return err
+			}
+			var disabled []*models.Category
+			if !includeDisabled && categoryFilter == "" {
+				disabled = disabledCategories(m.Categories)
+			}
+			for _, arg := range args {
+				if err := explainFile(m, categories, disabled, config.ExpandTilde(arg)); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&categoryFilter, "category", "", "Comma-separated list of category names to consider (default: all)")
+	cmd.Flags().BoolVar(&includeDisabled, "include-disabled", false, "Include categories with enabled: false")
+	_ = cmd.RegisterFlagCompletionFunc("category", categoryNameCompletion)
+	return cmd
+}
+
+// explainFile logs, for each category whose source holds the file at path,
+// whether that category would take the file and, if not, why. As in a run,
+// the first category that matches takes the file. Disabled categories left
+// out of categories are only named, so a file they would scan is not reported
+// as covered by no category at all.
+func explainFile(m *models.Movelooper, categories, disabled []*models.Category, path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		m.Logger.Warn("not a regular file, movelooper never moves it", m.Logger.Args("path", path))
+		return nil
+	}
+
+	takenBy := ""
+	scanned := false
+	for _, cat := range categories {
+		reason, err := explainCategory(cat, path, info)
+		if errors.Is(err, scanner.ErrOutsideSource) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("category %q: %w", cat.Name, err)
+		}
+		scanned = true
+		switch {
+		case reason != "":
+			m.Logger.Info("category passes the file by",
+				m.Logger.Args("path", path, "category", cat.Name, "reason", reason))
+		case takenBy == "":
+			takenBy = cat.Name
+			pending, _ := actionVerbs(cat.Destination.Action)
+			m.Logger.Info("category takes the file",
+				m.Logger.Args("path", path, "category", cat.Name, "action", pending, "destination", cat.Destination.Path))
+		default:
+			m.Logger.Info("category matches, but an earlier one takes the file",
+				m.Logger.Args("path", path, "category", cat.Name, "taken-by", takenBy))
+		}
+	}
+	for _, cat := range disabled {
+		if _, err := scanner.Explain(cat.Source, []string{cat.Destination.Path}, path); errors.Is(err, scanner.ErrOutsideSource) {
+			continue
+		}
+		scanned = true
+		m.Logger.Info("category scans this file's folder but is disabled - use --include-disabled to explain it",
+			m.Logger.Args("path", path, "category", cat.Name))
+	}
+	if !scanned {
+		m.Logger.Info("no category scans this file's folder", m.Logger.Args("path", path))
+	}
+	return nil
+}
+
+// disabledCategories returns the categories with enabled: false, in config
+// order.
+func disabledCategories(all []*models.Category) []*models.Category {
+	var out []*models.Category
+	for _, cat := range all {
+		if !cat.IsEnabled() {
+			out = append(out, cat)
+		}
+	}
+	return out
+}
+
+// explainCategory returns why cat would not take the regular file at path,
+// or "" when it would. It returns scanner.ErrOutsideSource when the file is
+// not under the category's source.
+func explainCategory(cat *models.Category, path string, info os.FileInfo) (string, error) {
+	reason, err := scanner.Explain(cat.Source, []string{cat.Destination.Path}, path)
+	if err != nil || reason != "" {
+		return reason, err
+	}
+	if !filters.MatchesAnyExtension(info.Name(), cat.Source.Extensions) {
+		return "extension not in source.extensions", nil
+	}
+	if !filters.MatchesFilter(cat.Source.Filter, path, info) {
+		return "does not pass source.filter", nil
+	}
+	return "", nil
+}
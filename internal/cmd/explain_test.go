@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lucasassuncao/movelooper/internal/logger"
+	"github.com/lucasassuncao/movelooper/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExplainFile verifies that explain names the .mlignore rule that
+// protects a file, and otherwise which category takes it and why the others
+// pass it by.
+func TestExplainFile(t *testing.T) {
+	t.Parallel()
+	src := t.TempDir()
+	for name, content := range map[string]string{".mlignore": "# in progress\n*.part\n", "movie.part": "x", "movie.mkv": "x"} {
+		require.NoError(t, os.WriteFile(filepath.Join(src, name), []byte(content), 0o644))
+	}
+	dst := t.TempDir()
+	categories := []*models.Category{
+		{Name: "docs", Source: models.CategorySource{Path: src, Extensions: []string{"pdf"}}, Destination: models.CategoryDestination{Path: dst}},
+		{Name: "videos", Source: models.CategorySource{Path: src, Extensions: []string{"mkv", "part"}}, Destination: models.CategoryDestination{Path: dst}},
+		{Name: "everything", Source: models.CategorySource{Path: src, Extensions: []string{"all"}}, Destination: models.CategoryDestination{Path: dst}},
+		{Name: "elsewhere", Source: models.CategorySource{Path: dst, Extensions: []string{"all"}}, Destination: models.CategoryDestination{Path: src}},
+	}
+	explain := func(name string) string {
+		var buf bytes.Buffer
+		m := &models.Movelooper{Logger: logger.NewSlog(&buf, "info", false)}
+		require.NoError(t, explainFile(m, categories, nil, filepath.Join(src, name)))
+		return buf.String()
+	}
+
+	out := explain("movie.part")
+	assert.Contains(t, out, `"reason":"ignored by `+filepath.Join(src, ".mlignore")+`:2: *.part"`)
+	assert.NotContains(t, out, "takes the file")
+	assert.NotContains(t, out, `"category":"elsewhere"`, "categories whose source does not hold the file are left out")
+
+	out = explain("movie.mkv")
+	assert.Contains(t, out, `"category":"docs","reason":"extension not in source.extensions"`)
+	assert.Contains(t, out, `"msg":"category takes the file","path":"`+filepath.Join(src, "movie.mkv")+`","category":"videos"`)
+	assert.Contains(t, out, `"category":"everything","taken-by":"videos"`)
+}
+
+// TestExplainFile_DisabledCategory verifies that a file only a disabled
+// category scans is reported as such, with a pointer to --include-disabled,
+// rather than as covered by no category.
+func TestExplainFile_DisabledCategory(t *testing.T) {
+	t.Parallel()
+	src := t.TempDir()
+	path := filepath.Join(src, "report.pdf")
+	require.NoError(t, os.WriteFile(path, []byte("x"), 0o644))
+	disabled := []*models.Category{
+		{Name: "docs", Source: models.CategorySource{Path: src, Extensions: []string{"pdf"}}, Destination: models.CategoryDestination{Path: t.TempDir()}},
+		{Name: "elsewhere", Source: models.CategorySource{Path: t.TempDir(), Extensions: []string{"all"}}},
+	}
+
+	var buf bytes.Buffer
+	m := &models.Movelooper{Logger: logger.NewSlog(&buf, "info", false)}
+	require.NoError(t, explainFile(m, nil, disabled, path))
+
+	out := buf.String()
+	assert.Contains(t, out, "--include-disabled")
+	assert.Contains(t, out, `"category":"docs"`)
+	assert.NotContains(t, out, `"category":"elsewhere"`)
+	assert.NotContains(t, out, "no category scans")
+}
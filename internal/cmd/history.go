@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lucasassuncao/movelooper/internal/history"
+	"github.com/lucasassuncao/movelooper/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// historyQueryFlags holds the filter flags shared by "history search" and
+// "history export".
+type historyQueryFlags struct {
+	name        string
+	source      string
+	destination string
+	category    string
+	action      string
+	since       string
+	until       string
+}
+
+func (f *historyQueryFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.name, "name", "", "Filename glob matched against the source and destination names (case-insensitive), e.g. 'tax*.pdf'")
+	cmd.Flags().StringVar(&f.source, "source", "", "Only entries whose source path starts with this prefix")
+	cmd.Flags().StringVar(&f.destination, "dest", "", "Only entries whose destination path starts with this prefix")
+	cmd.Flags().StringVar(&f.category, "category", "", "Comma-separated list of category names")
+	cmd.Flags().StringVar(&f.action, "action", "", "Comma-separated list of actions: move, copy, symlink, archive")
+	cmd.Flags().StringVar(&f.since, "since", "", "Only entries at or after this time: YYYY-MM-DD, 'YYYY-MM-DD HH:MM', RFC 3339, or an age such as 7d or 12h")
+	cmd.Flags().StringVar(&f.until, "until", "", "Only entries before this time (a bare date includes that whole day); same formats as --since")
+	_ = cmd.RegisterFlagCompletionFunc("category", categoryNameCompletion)
+	_ = cmd.RegisterFlagCompletionFunc("action", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{"move", "copy", "symlink", "archive"}, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// HistoryCmd groups the commands that query the undo history
+func HistoryCmd(m *models.Movelooper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Search, inspect and export the undo history",
+		Long: `Queries the undo history: where files came from, where they went, and in which batch.
+
+Use "history search" to find entries by filename, path, category, action or date.
+Use "history show <batch_id>" to list every file of one batch.
+Use "history export" to dump entries as CSV, JSON or NDJSON.
+Use "history verify" to find destinations that went missing or changed since.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(historySearchCmd(m), historyShowCmd(m), historyExportCmd(m), historyVerifyCmd(m))
+	return cmd
+}
+
+func historySearchCmd(m *models.Movelooper) *cobra.Command {
+	var flags historyQueryFlags
+	cmd := &cobra.Command{
+		Use:   "search",
+		Short: "Find history entries by filename, path, category, action or date",
+		Example: `  movelooper history search --name 'tax*.pdf'
+  movelooper history search --dest ~/Documents --since 2025-01-01
+  movelooper history search --category images --action copy --since 7d`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if m.History == nil {
+				return fmt.Errorf("history tracking is not initialized")
+			}
+			q, err := flags.query()
+			if err != nil {
+				return err
+			}
+			entries := m.History.Search(q)
+			if len(entries) == 0 {
+				m.Logger.Info("no history entries match")
+				return nil
+			}
+			return printHistoryEntries(cmd.OutOrStdout(), entries)
+		},
+	}
+	flags.register(cmd)
+	return cmd
+}
+
+func historyShowCmd(m *models.Movelooper) *cobra.Command {
+	return &cobra.Command{
+		Use:     "show <batch_id>",
+		Short:   "List every file of one batch",
+		Example: `  movelooper history show batch_a1b2c3d4e5f6a7b8`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if m.History == nil {
+				return fmt.Errorf("history tracking is not initialized")
+			}
+			return showHistoryBatch(cmd.OutOrStdout(), m.History, args[0])
+		},
+	}
+}
+
+func historyExportCmd(m *models.Movelooper) *cobra.Command {
+	var (
+		flags  historyQueryFlags
+		format string
+		output string
+	)
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export history entries as CSV, JSON or NDJSON",
+		Example: `  movelooper history export --format csv > history.csv
+  movelooper history export --format ndjson --category images --output images.ndjson`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if m.History == nil {
+				return fmt.Errorf("history tracking is not initialized")
+			}
+			if !validExportFormat(format) {
+				return fmt.Errorf("unknown format %q — use one of: %s", format, strings.Join(exportFormats, ", "))
+			}
+			q, err := flags.query()
+			if err != nil {
+				return err
+			}
+			return exportHistory(cmd.OutOrStdout(), m.History.Search(q), format, output)
+		},
+	}
+	flags.register(cmd)
+	cmd.Flags().StringVarP(&format, "format", "f", "json", fmt.Sprintf("Output format: %s", strings.Join(exportFormats, ", ")))
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write to this file instead of stdout")
+	_ = cmd.RegisterFlagCompletionFunc("format", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return exportFormats, cobra.ShellCompDirectiveNoFileComp
+	})
+	return cmd
+}
+
+func historyVerifyCmd(m *models.Movelooper) *cobra.Command {
+	var deep bool
+	cmd := &cobra.Command{
+		Use:   "verify [batch_id]",
+		Short: "Report destinations that went missing or changed since they were recorded",
+		Long: `Compares every destination in history, or in one batch, with the size and
+modification time recorded when the file was placed, and with its content hash
+when history.hash is on. Missing and modified destinations are listed, and the
+command fails when there is any, so it can be used from scripts.
+
+Entries recorded before fingerprints existed, and symlinks, are counted as
+unverified.`,
+		Example: `  movelooper history verify
+  movelooper history verify batch_a1b2c3d4e5f6a7b8
+  movelooper history verify --hash`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if m.History == nil {
+				return fmt.Errorf("history tracking is not initialized")
+			}
+			var batchID string
+			if len(args) == 1 {
+				batchID = args[0]
+			}
+			return verifyHistory(cmd.OutOrStdout(), m.History, batchID, deep)
+		},
+	}
+	cmd.Flags().BoolVar(&deep, "hash", false, "Hash every destination that has a recorded hash, even when size and mtime match")
+	return cmd
+}
+
+// query converts the flags into a history.Query.
+func (f *historyQueryFlags) query() (history.Query, error) {
+	return buildHistoryQuery(*f, time.Now())
+}
@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lucasassuncao/movelooper/internal/config"
+	"github.com/lucasassuncao/movelooper/internal/history"
+)
+
+const (
+	exportCSV    = "csv"
+	exportJSON   = "json"
+	exportNDJSON = "ndjson"
+)
+
+var exportFormats = []string{exportCSV, exportJSON, exportNDJSON}
+
+func validExportFormat(format string) bool {
+	for _, f := range exportFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// historyTimeLayouts are the absolute formats accepted by --since and --until.
+// The bool marks date-only layouts, which --until extends to the end of the day.
+var historyTimeLayouts = []struct {
+	layout   string
+	dateOnly bool
+}{
+	{time.RFC3339, false},
+	{"2006-01-02 15:04:05", false},
+	{"2006-01-02 15:04", false},
+	{"2006-01-02T15:04", false},
+	{"2006-01-02", true},
+}
+
+// buildHistoryQuery validates the filter flags and turns them into a
+// history.Query. now anchors relative ages such as 7d.
+func buildHistoryQuery(f historyQueryFlags, now time.Time) (history.Query, error) {
+	q := history.Query{
+		Name:              f.name,
+		SourcePrefix:      expandPrefix(f.source),
+		DestinationPrefix: expandPrefix(f.destination),
+		Categories:        ParseCategoryNames(f.category),
+		Actions:           ParseCategoryNames(f.action),
+	}
+	if q.Name != "" {
+		if _, err := filepath.Match(q.Name, ""); err != nil {
+			return history.Query{}, fmt.Errorf("invalid --name pattern %q: %w", q.Name, err)
+		}
+	}
+	var err error
+	if f.since != "" {
+		if q.Since, err = parseHistoryTime(f.since, now, false); err != nil {
+			return history.Query{}, fmt.Errorf("invalid --since: %w", err)
+		}
+	}
+	if f.until != "" {
+		if q.Until, err = parseHistoryTime(f.until, now, true); err != nil {
+			return history.Query{}, fmt.Errorf("invalid --until: %w", err)
+		}
+	}
+	if !q.Since.IsZero() && !q.Until.IsZero() && !q.Since.Before(q.Until) {
+		return history.Query{}, fmt.Errorf("--since must be before --until")
+	}
+	return q, nil
+}
+
+// expandPrefix resolves ~ and relative paths so prefixes compare against the
+// absolute paths stored in history.
+func expandPrefix(p string) string {
+	if p == "" {
+		return ""
+	}
+	p = config.ExpandTilde(p)
+	if abs, err := filepath.Abs(p); err == nil {
+		return abs
+	}
+	return p
+}
+
+// parseHistoryTime parses an absolute time in local time, or an age relative
+// to now: a Go duration (12h, 90m) or a number of days (7d). With endOfDay, a
+// bare date means the end of that day, so --until 2025-03-31 includes March 31.
+func parseHistoryTime(s string, now time.Time, endOfDay bool) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	for _, l := range historyTimeLayouts {
+		t, err := time.ParseInLocation(l.layout, s, time.Local)
+		if err != nil {
+			continue
+		}
+		if l.dateOnly && endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date (YYYY-MM-DD, 'YYYY-MM-DD HH:MM', RFC 3339) or an age (7d, 12h)", s)
+}
+
+// printHistoryEntries writes entries as an aligned table, oldest first.
+func printHistoryEntries(w io.Writer, entries []history.Entry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "TIMESTAMP\tBATCH ID\tACTION\tCATEGORY\tSOURCE\tDESTINATION")
+	fmt.Fprintln(tw, "---------\t--------\t------\t--------\t------\t-----------")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.Timestamp.Format("2006-01-02 15:04:05"), e.BatchID, e.EffectiveAction(), dash(e.Category), e.Source, e.Destination)
+	}
+	return tw.Flush()
+}
+
+// showHistoryBatch writes the per-file table of one batch. A batch that was
+// undone is looked up on the redo stack and labelled as such.
+func showHistoryBatch(w io.Writer, h *history.History, batchID string) error {
+	entries := h.GetBatch(batchID)
+	undone := h.GetRedoBatch(batchID)
+	if len(entries) == 0 && len(undone) == 0 {
+		return fmt.Errorf("batch %q not found in history", batchID)
+	}
+
+	fmt.Fprintf(w, "Batch %s: %d file(s)", batchID, len(entries))
+	if len(undone) > 0 {
+		fmt.Fprintf(w, ", %d undone (on the redo stack)", len(undone))
+	}
+	fmt.Fprint(w, "\n\n")
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "#\tSTATUS\tACTION\tCATEGORY\tSOURCE\tDESTINATION\tTIMESTAMP")
+	fmt.Fprintln(tw, "-\t------\t------\t--------\t------\t-----------\t---------")
+	row := func(i int, status string, e history.Entry) {
+		dest := e.Destination
+		if e.Replaced != "" {
+			dest += " (replaced a file)"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			i, status, e.EffectiveAction(), dash(e.Category), e.Source, dest, e.Timestamp.Format("2006-01-02 15:04:05"))
+	}
+	for i, e := range entries {
+		row(i+1, "done", e)
+	}
+	for i, e := range undone {
+		row(len(entries)+i+1, "undone", e)
+	}
+	return tw.Flush()
+}
+
+// exportHistory writes entries in format to w, or to the file at output when
+// it is set.
+func exportHistory(w io.Writer, entries []history.Entry, format, output string) error {
+	if output == "" {
+		return writeHistoryExport(w, entries, format)
+	}
+	f, err := os.Create(filepath.Clean(config.ExpandTilde(output))) //#nosec G304 -- output path is given by the user on the command line
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	if err := writeHistoryExport(bw, entries, format); err != nil {
+		f.Close()
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeHistoryExport(w io.Writer, entries []history.Entry, format string) error {
+	switch format {
+	case exportJSON:
+		if entries == nil {
+			entries = []history.Entry{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	case exportNDJSON:
+		enc := json.NewEncoder(w)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	case exportCSV:
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"timestamp", "batch_id", "action", "category", "source", "destination", "replaced", "members"})
+		for _, e := range entries {
+			_ = cw.Write([]string{
+				e.Timestamp.Format(time.RFC3339), e.BatchID, e.EffectiveAction(), e.Category,
+				e.Source, e.Destination, e.Replaced, strconv.Itoa(len(e.Members)),
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("unknown format %q — use one of: %s", format, strings.Join(exportFormats, ", "))
+	}
+}
+
+// verifyHistory checks the destination of every live entry, or of batchID's
+// entries, writes a row per destination that is missing, modified or could
+// not be read, then a summary. It fails when any row was written.
+func verifyHistory(w io.Writer, h *history.History, batchID string, deep bool) error {
+	var entries []history.Entry
+	if batchID != "" {
+		if entries = h.GetBatch(batchID); len(entries) == 0 {
+			return fmt.Errorf("batch %q not found in history", batchID)
+		}
+	} else {
+		entries = h.Search(history.Query{})
+	}
+
+	counts := make(map[history.Integrity]int)
+	problems := 0
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tBATCH ID\tDESTINATION\tDETAIL")
+	fmt.Fprintln(tw, "------\t--------\t-----------\t------")
+	for _, e := range entries {
+		check, err := e.Verify(deep)
+		if err != nil {
+			check = history.Check{Status: "unreadable", Detail: err.Error()}
+		}
+		counts[check.Status]++
+		if check.Status == history.IntegrityOK || check.Status == history.IntegrityUnverified {
+			continue
+		}
+		problems++
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", check.Status, e.BatchID, e.Destination, check.Detail)
+	}
+	if problems > 0 {
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "%d entries checked: %d ok, %d modified, %d missing, %d unverified\n",
+		len(entries), counts[history.IntegrityOK], counts[history.IntegrityModified],
+		counts[history.IntegrityMissing], counts[history.IntegrityUnverified])
+	if problems > 0 {
+		return fmt.Errorf("%d destination(s) missing, modified or unreadable", problems)
+	}
+	return nil
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lucasassuncao/movelooper/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHistoryTime(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.Local)
+
+	cases := []struct {
+		in       string
+		endOfDay bool
+		want     time.Time
+	}{
+		{"7d", false, now.AddDate(0, 0, -7)},
+		{"12h", false, now.Add(-12 * time.Hour)},
+		{"2025-03-01", false, time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local)},
+		{"2025-03-01", true, time.Date(2025, 3, 2, 0, 0, 0, 0, time.Local)},
+		{"2025-03-01 08:30", true, time.Date(2025, 3, 1, 8, 30, 0, 0, time.Local)},
+	}
+	for _, tt := range cases {
+		got, err := parseHistoryTime(tt.in, now, tt.endOfDay)
+		require.NoError(t, err, tt.in)
+		assert.True(t, tt.want.Equal(got), "%s: got %v, want %v", tt.in, got, tt.want)
+	}
+
+	_, err := parseHistoryTime("last tuesday", now, false)
+	assert.Error(t, err)
+}
+
+func TestBuildHistoryQuery(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+
+	q, err := buildHistoryQuery(historyQueryFlags{name: "*.pdf", category: "docs, images", action: "move"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs", "images"}, q.Categories)
+	assert.Equal(t, []string{"move"}, q.Actions)
+
+	_, err = buildHistoryQuery(historyQueryFlags{name: "[bad"}, now)
+	assert.ErrorContains(t, err, "invalid --name pattern")
+
+	_, err = buildHistoryQuery(historyQueryFlags{since: "2025-03-02", until: "2025-03-01"}, now)
+	assert.ErrorContains(t, err, "--since must be before --until")
+}
+
+func historyTestEntries() []history.Entry {
+	ts := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	return []history.Entry{
+		{Source: "/in/tax.pdf", Destination: "/out/tax.pdf", Timestamp: ts, BatchID: "batch_1", Category: "docs"},
+		{Source: "/in/a.jpg", Destination: "/out/a.jpg", Timestamp: ts, BatchID: "batch_1", Category: "images", Action: "copy"},
+	}
+}
+
+func TestWriteHistoryExport(t *testing.T) {
+	t.Parallel()
+	entries := historyTestEntries()
+
+	var buf bytes.Buffer
+	require.NoError(t, writeHistoryExport(&buf, entries, exportJSON))
+	var decoded []history.Entry
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, entries, decoded)
+
+	buf.Reset()
+	require.NoError(t, writeHistoryExport(&buf, entries, exportNDJSON))
+	assert.Len(t, strings.Split(strings.TrimSpace(buf.String()), "\n"), 2)
+
+	buf.Reset()
+	require.NoError(t, writeHistoryExport(&buf, entries, exportCSV))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, "action", rows[0][2])
+	assert.Equal(t, "move", rows[1][2], "legacy entries export as move")
+	assert.Equal(t, "copy", rows[2][2])
+
+	buf.Reset()
+	require.NoError(t, writeHistoryExport(&buf, nil, exportJSON))
+	assert.Equal(t, "[]", strings.TrimSpace(buf.String()))
+}
+
+func TestExportHistory_ToFile(t *testing.T) {
+	t.Parallel()
+	out := filepath.Join(t.TempDir(), "h.ndjson")
+	require.NoError(t, exportHistory(nil, historyTestEntries(), exportNDJSON, out))
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"batch_id":"batch_1"`)
+}
+
+func TestShowHistoryBatch(t *testing.T) {
+	var buf bytes.Buffer
+	m := newBufMovelooper(t, &buf, nil)
+	entries := historyTestEntries()
+	require.NoError(t, m.History.AddBatch(entries))
+	require.NoError(t, m.History.MoveToRedo(entries[1:]))
+
+	var out bytes.Buffer
+	require.NoError(t, showHistoryBatch(&out, m.History, "batch_1"))
+	text := out.String()
+	assert.Contains(t, text, "1 file(s), 1 undone")
+	assert.Contains(t, text, "/out/tax.pdf")
+	assert.Contains(t, text, "undone")
+
+	assert.ErrorContains(t, showHistoryBatch(&out, m.History, "batch_nope"), "not found")
+}
+
+func TestVerifyHistory(t *testing.T) {
+	var buf bytes.Buffer
+	m := newBufMovelooper(t, &buf, nil)
+	dir := t.TempDir()
+	intact := history.Entry{Source: "/in/a.txt", Destination: filepath.Join(dir, "a.txt"), BatchID: "batch_1"}
+	edited := history.Entry{Source: "/in/b.txt", Destination: filepath.Join(dir, "b.txt"), BatchID: "batch_1"}
+	gone := history.Entry{Source: "/in/c.txt", Destination: filepath.Join(dir, "c.txt"), BatchID: "batch_2"}
+	for _, e := range []*history.Entry{&intact, &edited, &gone} {
+		require.NoError(t, os.WriteFile(e.Destination, []byte("data"), 0o600))
+		require.NoError(t, e.Stamp(false))
+	}
+	require.NoError(t, os.WriteFile(edited.Destination, []byte("new data"), 0o600))
+	require.NoError(t, os.Remove(gone.Destination))
+	require.NoError(t, m.History.AddBatch([]history.Entry{intact, edited, gone}))
+
+	var out bytes.Buffer
+	err := verifyHistory(&out, m.History, "", false)
+	assert.ErrorContains(t, err, "2 destination(s)")
+	text := out.String()
+	assert.Contains(t, text, "modified")
+	assert.Contains(t, text, "missing")
+	assert.NotContains(t, text, "a.txt", "intact destinations are not listed")
+	assert.Contains(t, text, "3 entries checked: 1 ok, 1 modified, 1 missing, 0 unverified")
+
+	out.Reset()
+	require.NoError(t, os.WriteFile(edited.Destination, []byte("data"), 0o600))
+	require.NoError(t, os.Chtimes(edited.Destination, edited.ModTime, edited.ModTime))
+	require.NoError(t, verifyHistory(&out, m.History, "batch_1", false))
+	assert.ErrorContains(t, verifyHistory(&out, m.History, "batch_nope", false), "not found")
+}
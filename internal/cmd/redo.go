@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/lucasassuncao/movelooper/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// RedoCmd replays a batch that was previously undone
+func RedoCmd(m *models.Movelooper) *cobra.Command {
+	var (
+		listBatches bool
+		dryRun      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "redo [batch_id]",
+		Short: "Redo an undone file organization operation",
+		Long: `Replays a batch that was reverted by "movelooper undo", running each file's
+recorded action (move, copy or symlink) again.
+
+Without arguments, redoes the most recently undone batch.
+Pass a batch ID to redo a specific batch.
+Use --list to see the batches on the redo stack.
+Use --dry-run to preview what would be redone without touching any files.
+
+A file is only redone when its source still exists and its original destination
+is free or already holds identical content. Files that fail these checks stay
+on the redo stack.`,
+		Example: `  movelooper redo
+  movelooper redo --list
+  movelooper redo --dry-run
+  movelooper redo batch_a1b2c3d4e5f6a7b8`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if m.History == nil {
+				return fmt.Errorf("history tracking is not initialized")
+			}
+
+			if listBatches {
+				return printRedoList(m)
+			}
+
+			var batchID string
+			if len(args) == 1 {
+				batchID = args[0]
+			} else {
+				batches := m.History.GetRedoBatches()
+				if len(batches) == 0 {
+					m.Logger.Info("no undone batches to redo")
+					return nil
+				}
+				batchID = batches[len(batches)-1].BatchID
+			}
+
+			return redoBatch(cmd.Context(), m, batchID, dryRun)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&listBatches, "list", "l", false, "List the batches that can be redone")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview what would be redone without touching any files")
+	return cmd
+}
@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/huh"
+	"github.com/lucasassuncao/movelooper/internal/fileops"
+	"github.com/lucasassuncao/movelooper/internal/history"
+	"github.com/lucasassuncao/movelooper/internal/models"
+)
+
+// redoStep is what replaying one entry takes, decided by checkRedo.
+type redoStep int
+
+const (
+	// redoFree: the destination is free and the action simply runs again.
+	redoFree redoStep = iota
+	// redoAlreadyDone: the destination already holds the same content, so the
+	// entry counts as replayed without copying anything.
+	redoAlreadyDone
+	// redoSetAside: the destination is occupied and the entry once replaced a
+	// file there (history.keep-replaced); the occupant goes back into the
+	// backup store first, as the original run did.
+	redoSetAside
+)
+
+func printRedoList(m *models.Movelooper) error {
+	batches := m.History.GetRedoBatches()
+	if len(batches) == 0 {
+		m.Logger.Info("no undone batches to redo")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "BATCH ID\tFILES\tUNDONE AT")
+	fmt.Fprintln(w, "--------\t-----\t---------")
+	for _, b := range batches {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", b.BatchID, b.Count, b.Timestamp.Format("2006-01-02 15:04:05"))
+	}
+	return w.Flush()
+}
+
+func redoBatch(ctx context.Context, m *models.Movelooper, batchID string, dryRun bool) error {
+	entries := m.History.GetRedoBatch(batchID)
+	if len(entries) == 0 {
+		return fmt.Errorf("batch %q is not on the redo stack", batchID)
+	}
+
+	if dryRun {
+		dryRunRedoBatch(m, batchID, entries)
+		return nil
+	}
+
+	if cancelled := confirmRedo(m, batchID, entries); cancelled {
+		return nil
+	}
+
+	redone := replayEntries(ctx, m, entries)
+
+	if len(redone) > 0 {
+		if err := m.History.CompleteRedo(redone); err != nil {
+			m.Logger.Error("failed to update history", m.Logger.Args("error", err.Error()))
+		}
+	}
+	return nil
+}
+
+// checkRedo decides how entry can be replayed. The source must still exist,
+// and the destination must be free, already hold identical content, or be
+// reclaimable into the backup store; anything else is a conflict and the
+// entry is left on the redo stack.
+func checkRedo(entry history.Entry) (redoStep, error) {
+	if _, err := os.Lstat(entry.Source); err != nil {
+		if os.IsNotExist(err) {
+			return 0, fmt.Errorf("source no longer exists: %s", entry.Source)
+		}
+		return 0, err
+	}
+
+	info, err := os.Lstat(entry.Destination)
+	if os.IsNotExist(err) {
+		return redoFree, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if same, err := sameAsSource(entry, info); err != nil {
+		return 0, err
+	} else if same {
+		return redoAlreadyDone, nil
+	}
+	if entry.Replaced != "" {
+		if _, err := os.Lstat(entry.Replaced); err == nil {
+			return 0, fmt.Errorf("backup path already occupied: %s", entry.Replaced)
+		}
+		return redoSetAside, nil
+	}
+	return 0, fmt.Errorf("destination already occupied by a different file: %s", entry.Destination)
+}
+
+// sameAsSource reports whether the existing destination is what replaying the
+// entry would produce: a link to the source for symlink, identical content
+// otherwise.
+func sameAsSource(entry history.Entry, dst os.FileInfo) (bool, error) {
+	if entry.Action == string(models.ActionSymlink) {
+		if dst.Mode()&os.ModeSymlink == 0 {
+			return false, nil
+		}
+		target, err := os.Readlink(entry.Destination)
+		if err != nil {
+			return false, err
+		}
+		abs, err := filepath.Abs(entry.Source)
+		if err != nil {
+			return false, err
+		}
+		return target == abs, nil
+	}
+	if !dst.Mode().IsRegular() {
+		return false, nil
+	}
+	return fileops.SameContent(entry.Source, entry.Destination)
+}
+
+// dryRunRedoBatch logs what would be replayed without touching any file.
+func dryRunRedoBatch(m *models.Movelooper, batchID string, entries []history.Entry) {
+	m.Logger.Info("[dry-run] would redo batch", m.Logger.Args("batch_id", batchID, "files", len(entries)))
+	var replayArgs []any
+	for _, entry := range entries {
+		step, err := checkRedo(entry)
+		if err != nil {
+			m.Logger.Warn("[dry-run] cannot redo, would skip", m.Logger.Args("path", entry.Source, "error", err.Error()))
+			continue
+		}
+		switch step {
+		case redoAlreadyDone:
+			m.Logger.Info("[dry-run] destination already holds this file", m.Logger.Args("path", entry.Destination))
+		case redoSetAside:
+			m.Logger.Info("[dry-run] would move the current destination back to the backup store",
+				m.Logger.Args("path", entry.Destination, "backup", entry.Replaced))
+		}
+		replayArgs = append(replayArgs, "path", entry.Destination)
+	}
+	if len(replayArgs) > 0 {
+		m.Logger.Info("[dry-run] would redo file(s)", m.Logger.Args(replayArgs...))
+	}
+}
+
+// confirmRedo shows a confirmation prompt and returns true if the user cancelled.
+func confirmRedo(m *models.Movelooper, batchID string, entries []history.Entry) bool {
+	var sb strings.Builder
+	for i, entry := range entries {
+		if i < 5 {
+			fmt.Fprintf(&sb, "  - %s\n", filepath.Base(entry.Source))
+		} else if i == 5 {
+			fmt.Fprintf(&sb, "  ... and %d more files\n", len(entries)-5)
+			break
+		}
+	}
+	msg := fmt.Sprintf("Redo batch: %s\n\nFiles to process again (%d total):\n%s\nProceed with redo?",
+		batchID, len(entries), sb.String())
+
+	var confirm bool
+	err := huh.NewConfirm().Title(msg).Value(&confirm).Run()
+	if errors.Is(err, huh.ErrUserAborted) || !confirm {
+		m.Logger.Info("redo operation cancelled")
+		return true
+	}
+	return false
+}
+
+// replayEntries runs the recorded action of each entry again, in the order the
+// entries were first recorded. Returns the entries that were replayed so
+// callers can move only those back into history, leaving the rest on the redo
+// stack for a retry.
+func replayEntries(ctx context.Context, m *models.Movelooper, entries []history.Entry) []history.Entry {
+	redone := make([]history.Entry, 0, len(entries))
+	var redoneArgs []any
+	failCount := 0
+
+	m.Logger.Info("redoing batch", m.Logger.Args("files", len(entries)))
+
+	for _, entry := range entries {
+		step, err := checkRedo(entry)
+		if err != nil {
+			m.Logger.Warn("cannot redo, skipping", m.Logger.Args("path", entry.Source, "error", err.Error()))
+			failCount++
+			continue
+		}
+		if err := replayEntry(ctx, m, entry, step); err != nil {
+			failCount++
+			continue
+		}
+		redone = append(redone, entry)
+		redoneArgs = append(redoneArgs, "path", entry.Destination)
+	}
+
+	if len(redoneArgs) > 0 {
+		m.Logger.Info("file(s) redone", m.Logger.Args(redoneArgs...))
+	}
+	m.Logger.Info("redo completed", m.Logger.Args("redone", len(redone), "failed", failCount))
+	return redone
+}
+
+// replayEntry performs one checked entry. When the destination had to be set
+// aside and the action then fails, the set-aside file is put back.
+func replayEntry(ctx context.Context, m *models.Movelooper, entry history.Entry, step redoStep) error {
+	if step == redoAlreadyDone {
+		if entry.Action == "" || entry.Action == string(models.ActionMove) {
+			// The destination already holds the file; finish the move by
+			// consuming the source, as hash_check does for duplicates.
+			if err := os.Remove(entry.Source); err != nil {
+				m.Logger.Error("failed to remove source", m.Logger.Args("path", entry.Source, "error", err.Error()))
+				return err
+			}
+		}
+		return nil
+	}
+
+	if step == redoSetAside {
+		if err := os.MkdirAll(filepath.Dir(entry.Replaced), 0o750); err != nil {
+			m.Logger.Error("failed to create backup directory", m.Logger.Args("path", filepath.Dir(entry.Replaced), "error", err.Error()))
+			return err
+		}
+		if err := fileops.MoveFileCtx(ctx, entry.Destination, entry.Replaced); err != nil {
+			m.Logger.Error("failed to move destination to the backup store", m.Logger.Args("path", entry.Destination, "error", err.Error()))
+			return err
+		}
+	}
+
+	err := os.MkdirAll(filepath.Dir(entry.Destination), 0o750)
+	if err == nil {
+		err = fileops.Replay(ctx, models.Action(entry.Action), entry.Source, entry.Destination)
+		if errors.Is(err, fileops.ErrTimestampPreserve) {
+			m.Logger.Warn("file redone but timestamps could not be preserved", m.Logger.Args("file", entry.Source))
+			err = nil
+		}
+	}
+	if err != nil {
+		m.Logger.Error("failed to redo file", m.Logger.Args("from", entry.Source, "to", entry.Destination, "error", err.Error()))
+		if step == redoSetAside {
+			if rerr := fileops.MoveFileCtx(ctx, entry.Replaced, entry.Destination); rerr != nil {
+				m.Logger.Error("failed to put the set-aside destination back", m.Logger.Args("backup", entry.Replaced, "error", rerr.Error()))
+			}
+		}
+		return err
+	}
+	return nil
+}
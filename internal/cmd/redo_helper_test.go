@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lucasassuncao/movelooper/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func redoTestFiles(t *testing.T) (src, dst string) {
+	t.Helper()
+	dir := t.TempDir()
+	src = filepath.Join(dir, "src", "file.txt")
+	dst = filepath.Join(dir, "dst", "file.txt")
+	require.NoError(t, os.MkdirAll(filepath.Dir(src), 0o750))
+	require.NoError(t, os.WriteFile(src, []byte("content"), 0o600))
+	return src, dst
+}
+
+func TestCheckRedo(t *testing.T) {
+	t.Parallel()
+
+	t.Run("free destination", func(t *testing.T) {
+		t.Parallel()
+		src, dst := redoTestFiles(t)
+		step, err := checkRedo(history.Entry{Source: src, Destination: dst, Action: "move"})
+		require.NoError(t, err)
+		assert.Equal(t, redoFree, step)
+	})
+
+	t.Run("identical destination", func(t *testing.T) {
+		t.Parallel()
+		src, dst := redoTestFiles(t)
+		require.NoError(t, os.MkdirAll(filepath.Dir(dst), 0o750))
+		require.NoError(t, os.WriteFile(dst, []byte("content"), 0o600))
+		step, err := checkRedo(history.Entry{Source: src, Destination: dst, Action: "copy"})
+		require.NoError(t, err)
+		assert.Equal(t, redoAlreadyDone, step)
+	})
+
+	t.Run("different destination", func(t *testing.T) {
+		t.Parallel()
+		src, dst := redoTestFiles(t)
+		require.NoError(t, os.MkdirAll(filepath.Dir(dst), 0o750))
+		require.NoError(t, os.WriteFile(dst, []byte("other"), 0o600))
+		_, err := checkRedo(history.Entry{Source: src, Destination: dst, Action: "move"})
+		assert.ErrorContains(t, err, "already occupied")
+	})
+
+	t.Run("different destination that was replaced", func(t *testing.T) {
+		t.Parallel()
+		src, dst := redoTestFiles(t)
+		require.NoError(t, os.MkdirAll(filepath.Dir(dst), 0o750))
+		require.NoError(t, os.WriteFile(dst, []byte("other"), 0o600))
+		kept := filepath.Join(t.TempDir(), "batch_x", "file.txt")
+		step, err := checkRedo(history.Entry{Source: src, Destination: dst, Action: "move", Replaced: kept})
+		require.NoError(t, err)
+		assert.Equal(t, redoSetAside, step)
+	})
+
+	t.Run("missing source", func(t *testing.T) {
+		t.Parallel()
+		_, err := checkRedo(history.Entry{Source: "/does/not/exist", Destination: "/nowhere", Action: "move"})
+		assert.ErrorContains(t, err, "source no longer exists")
+	})
+}
+
+// TestUndoThenRedo verifies the round trip: undo puts a moved file back and
+// parks the entry on the redo stack, and redo moves it again and records it
+// in history under the same batch.
+func TestUndoThenRedo(t *testing.T) {
+	src, dst := redoTestFiles(t)
+	require.NoError(t, os.MkdirAll(filepath.Dir(dst), 0o750))
+	require.NoError(t, os.Rename(src, dst))
+
+	var buf bytes.Buffer
+	m := newBufMovelooper(t, &buf, nil)
+	entry := history.Entry{Source: src, Destination: dst, Action: "move", BatchID: "batch_x", Category: "docs"}
+	require.NoError(t, m.History.Add(entry))
+
+	restored := restoreEntries(context.Background(), m, []history.Entry{entry}, undoOptions{})
+	require.NoError(t, m.History.MoveToRedo(restored))
+	assert.FileExists(t, src)
+	assert.Empty(t, m.History.GetBatch("batch_x"))
+
+	require.NoError(t, redoBatch(context.Background(), m, "batch_x", true))
+	assert.FileExists(t, src, "dry-run touches nothing")
+	assert.Contains(t, buf.String(), "[dry-run] would redo file(s)")
+
+	redone := replayEntries(context.Background(), m, m.History.GetRedoBatch("batch_x"))
+	require.Len(t, redone, 1)
+	require.NoError(t, m.History.CompleteRedo(redone))
+	assert.NoFileExists(t, src)
+	assert.FileExists(t, dst)
+	assert.Len(t, m.History.GetBatch("batch_x"), 1)
+	assert.Empty(t, m.History.GetRedoBatches())
+}
+
+// TestReplayEntries_SetsReplacedFileAside verifies that redoing an entry that
+// once overwrote a file moves the current occupant back into the backup store.
+func TestReplayEntries_SetsReplacedFileAside(t *testing.T) {
+	src, dst := redoTestFiles(t)
+	require.NoError(t, os.MkdirAll(filepath.Dir(dst), 0o750))
+	require.NoError(t, os.WriteFile(dst, []byte("original"), 0o600))
+	kept := filepath.Join(t.TempDir(), "batch_x", "file.txt")
+
+	var buf bytes.Buffer
+	m := newBufMovelooper(t, &buf, nil)
+	entry := history.Entry{Source: src, Destination: dst, Action: "move", BatchID: "batch_x", Replaced: kept}
+
+	redone := replayEntries(context.Background(), m, []history.Entry{entry})
+	require.Len(t, redone, 1)
+	got, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("content"), got)
+	got, err = os.ReadFile(kept)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("original"), got)
+}
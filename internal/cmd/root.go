@@ -81,6 +81,12 @@ Use --dry-run for a preview without moving files, and --show-files to display fi
 	watchCmd.GroupID = "ops"
 	undoCmd := UndoCmd(m)
 	undoCmd.GroupID = "ops"
+	redoCmd := RedoCmd(m)
+	redoCmd.GroupID = "ops"
+	historyCmd := HistoryCmd(m)
+	historyCmd.GroupID = "ops"
+	explainCmd := ExplainCmd(m)
+	explainCmd.GroupID = "ops"
 
 	editCmd := EditCmd()
 	editCmd.GroupID = "config"
@@ -93,9 +99,11 @@ Use --dry-run for a preview without moving files, and --show-files to display fi
 	selfUpdateCmd.GroupID = "utils"
 	showCmd := ShowCmd()
 	showCmd.GroupID = "utils"
+	serviceCmd := ServiceCmd()
+	serviceCmd.GroupID = "utils"
 
 	GenerateCmd.GroupID = "utils"
-	cmd.AddCommand(watchCmd, undoCmd, editCmd, validateCmd, configCmd, selfUpdateCmd, showCmd, GenerateCmd)
+	cmd.AddCommand(watchCmd, undoCmd, redoCmd, historyCmd, explainCmd, editCmd, validateCmd, configCmd, selfUpdateCmd, showCmd, serviceCmd, GenerateCmd)
 
 	cmd.SetHelpCommand(&cobra.Command{Hidden: true, GroupID: "utils"})
 
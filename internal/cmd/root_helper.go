@@ -150,6 +150,7 @@ func processCategoryMove(ctx context.Context, m *models.Movelooper, category *mo
 	if category.Hooks != nil && category.Hooks.Before != nil {
 		env := hookEnv(category, batch.dryRun, nil)
 		if err := hooks.RunHook(ctx, category.Hooks.Before, hooks.HookContext{Log: m.Logger, Stdout: os.Stdout, Stderr: os.Stderr}, env); err != nil {
+			m.Metrics.HookFailed(category.Name, "before")
 			return fmt.Errorf("before hook: %w", err)
 		}
 	}
@@ -245,6 +246,7 @@ func processCategoryMove(ctx context.Context, m *models.Movelooper, category *mo
 			archivePath: archivePath,
 		})
 		if err := hooks.RunHook(ctx, category.Hooks.After, hooks.HookContext{Log: m.Logger, Stdout: os.Stdout, Stderr: os.Stderr}, env); err != nil {
+			m.Metrics.HookFailed(category.Name, "after")
 			return fmt.Errorf("after hook: %w", err)
 		}
 	}
@@ -347,7 +349,13 @@ func groupByDir(entries []scanner.FileEntry) map[string][]os.DirEntry {
 
 // moveExtensionWithResult moves files described by req and returns the MoveResult.
 func moveExtensionWithResult(ctx context.Context, m *models.Movelooper, req fileops.MoveRequest, batch moveBatch) fileops.MoveResult {
-	mctx := fileops.MoveContext{Logger: m.Logger, History: batch.recorder}
+	mctx := fileops.MoveContext{
+		Logger:           m.Logger,
+		History:          batch.recorder,
+		Metrics:          m.Metrics,
+		BackupDir:        m.History.BackupDir(),
+		HashDestinations: m.Config.History.Hash,
+	}
 	result := fileops.MoveFiles(ctx, mctx, req)
 	for _, name := range result.Moved {
 		batch.moved.mark(req.SourceDir, name)
@@ -468,7 +476,13 @@ func resolvePlannedMove(category *models.Category, fe scanner.FileEntry) (source
 		return "", "", false
 	}
 	sourcePath := filepath.Join(fe.Dir, fe.Entry.Name())
-	tctx := tokens.TokenContext{Info: info, CategoryName: category.Name, Now: time.Now(), SourcePath: sourcePath, DryRun: true}
+	tctx := tokens.TokenContext{
+		Info: info, CategoryName: category.Name, Now: time.Now(), DryRun: true,
+		SourcePath: sourcePath, SourceRoot: category.Source.Path,
+		TagDefaults:      category.Destination.TagDefaults,
+		Captures:         filters.Captures(category.Source.Filter, fe.Entry.Name()),
+		NameDatePatterns: category.Source.CompiledNameDatePatterns,
+	}
 	destDir, destName := fileops.ResolveDestination(category, &tctx)
 
 	return sourcePath, filepath.Join(destDir, destName), true
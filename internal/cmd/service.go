@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/lucasassuncao/movelooper/internal/config"
+	"github.com/lucasassuncao/movelooper/internal/systemd"
+	"github.com/spf13/cobra"
+)
+
+// errSystemUnitUnsupported is returned for --user=false: movelooper organises
+// one user's files, so only user units are generated.
+var errSystemUnitUnsupported = errors.New("only systemd user units are supported; run without --user=false")
+
+// ServiceCmd returns the "service" command, which manages the systemd user
+// unit that runs watch mode in the background.
+func ServiceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "service",
+		Short:             "Install or remove the systemd user service for watch mode",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+		Long: `Manage a systemd user unit that runs "movelooper watch" in the background.
+
+The unit uses Type=notify: watch mode reports READY=1 after its initial scan,
+publishes the queue size as STATUS=, and sends WATCHDOG=1 pings so systemd
+restarts it if it stops responding. Linux only.`,
+	}
+	cmd.AddCommand(serviceInstallCmd(), serviceUninstallCmd())
+	return cmd
+}
+
+func serviceInstallCmd() *cobra.Command {
+	var (
+		user      bool
+		printOnly bool
+		noEnable  bool
+		name      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "install [-- watch flags...]",
+		Short: "Generate and enable a systemd user unit for watch mode",
+		Long: `Writes ~/.config/systemd/user/<name>.service pointing at the resolved
+configuration file, reloads the user manager, and enables and starts the unit.
+
+Arguments after "--" are passed to "movelooper watch" verbatim.`,
+		Example: `  movelooper service install --user
+  movelooper --config ~/movelooper.yaml service install
+  movelooper service install --print
+  movelooper service install -- --category images,docs`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !user {
+				return errSystemUnitUnsupported
+			}
+			configPath, _ := cmd.Root().PersistentFlags().GetString("config")
+			resolved, err := config.ResolveConfigPath(configPath)
+			if err != nil {
+				return err
+			}
+			exe, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("error getting executable: %w", err)
+			}
+			if target, err := filepath.EvalSymlinks(exe); err == nil {
+				exe = target
+			}
+
+			unit := systemd.RenderUnit(systemd.UnitOptions{Executable: exe, ConfigPath: resolved, ExtraArgs: args})
+			if printOnly {
+				fmt.Print(unit)
+				return nil
+			}
+			if runtime.GOOS != "linux" {
+				return fmt.Errorf("systemd services are only supported on Linux; see docs/WATCH.md for launchd and Task Scheduler")
+			}
+			return installService(name, unit, !noEnable)
+		},
+	}
+
+	cmd.Flags().BoolVar(&user, "user", true, "Install a user unit (system units are not supported)")
+	cmd.Flags().BoolVar(&printOnly, "print", false, "Print the unit to stdout instead of installing it")
+	cmd.Flags().BoolVar(&noEnable, "no-enable", false, "Write the unit and reload systemd without enabling or starting it")
+	cmd.Flags().StringVar(&name, "name", systemd.DefaultUnitName, "Unit name")
+	return cmd
+}
+
+func serviceUninstallCmd() *cobra.Command {
+	var (
+		user bool
+		name string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Stop, disable and remove the systemd user unit",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !user {
+				return errSystemUnitUnsupported
+			}
+			if runtime.GOOS != "linux" {
+				return fmt.Errorf("systemd services are only supported on Linux")
+			}
+			return uninstallService(name)
+		},
+	}
+
+	cmd.Flags().BoolVar(&user, "user", true, "Remove a user unit (system units are not supported)")
+	cmd.Flags().StringVar(&name, "name", systemd.DefaultUnitName, "Unit name")
+	return cmd
+}
+
+// installService writes the unit, reloads the user manager and, when enable is
+// set, enables and starts it in one step.
+func installService(name, unit string, enable bool) error {
+	path, err := systemd.UnitPath(name)
+	if err != nil {
+		return err
+	}
+	if err := systemd.WriteUnit(path, unit); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s\n", path)
+
+	if err := systemd.Systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	if !enable {
+		fmt.Printf("Start it with: systemctl --user enable --now %s\n", filepath.Base(path))
+		return nil
+	}
+	if err := systemd.Systemctl("enable", "--now", filepath.Base(path)); err != nil {
+		return err
+	}
+	fmt.Printf("Enabled and started %s\n", filepath.Base(path))
+	fmt.Printf("Follow logs with: journalctl --user -u %s -f\n", filepath.Base(path))
+	return nil
+}
+
+// uninstallService disables and stops the unit (ignoring a unit that is not
+// loaded), removes the file, and reloads the user manager.
+func uninstallService(name string) error {
+	path, err := systemd.UnitPath(name)
+	if err != nil {
+		return err
+	}
+	if _, statErr := os.Stat(path); statErr == nil {
+		if err := systemd.Systemctl("disable", "--now", filepath.Base(path)); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+	if err := systemd.RemoveUnit(path); err != nil {
+		return err
+	}
+	if err := systemd.Systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	fmt.Printf("Removed %s\n", path)
+	return nil
+}
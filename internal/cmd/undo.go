@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/lucasassuncao/movelooper/internal/models"
 	"github.com/spf13/cobra"
@@ -13,6 +14,8 @@ func UndoCmd(m *models.Movelooper) *cobra.Command {
 		listBatches    bool
 		dryRun         bool
 		categoryFilter string
+		force          bool
+		sel            undoSelectors
 	)
 
 	cmd := &cobra.Command{
@@ -24,7 +27,17 @@ Without arguments, reverts the most recent batch.
 Pass a batch ID to revert a specific batch.
 Use --list to see all available batches.
 Use --dry-run to preview what would be restored without moving any files.
-Use --category to undo only files from specific categories within a batch.`,
+Use --category to undo only files from specific categories within a batch.
+
+--since, --until, --path and --file select entries across every batch in
+history instead, for example everything the watcher moved this afternoon.
+They combine with each other and with --category, and are confirmed once for
+the whole selection. With a batch ID they only narrow that batch.
+
+Before restoring a file, undo compares it with the size, modification time and
+(with history.hash) content hash recorded when it was moved. A file changed
+since then is only restored after confirmation, or with --force; without a
+terminal to ask on, it is skipped.`,
 		Example: `  movelooper undo
   movelooper undo --list
   movelooper undo --dry-run
@@ -32,7 +45,12 @@ Use --category to undo only files from specific categories within a batch.`,
   movelooper undo batch_1718000000 --dry-run
   movelooper undo --category images
   movelooper undo batch_1718000000 --category images,docs
-  movelooper undo watch_1718000000000000000`,
+  movelooper undo watch_1718000000000000000
+  movelooper undo batch_1718000000 --force
+  movelooper undo --since 2h
+  movelooper undo --since "2025-03-15 13:00" --until "2025-03-15 18:00" --category images
+  movelooper undo --path "~/Documents/pdf/*"
+  movelooper undo --file ~/Pictures/jpg/IMG_0001.jpg --dry-run`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if m.History == nil {
@@ -43,6 +61,18 @@ Use --category to undo only files from specific categories within a batch.`,
 				return printBatchList(m)
 			}
 
+			if sel.active() {
+				q, err := buildUndoQuery(sel, categoryFilter, time.Now())
+				if err != nil {
+					return err
+				}
+				var batchID string
+				if len(args) == 1 {
+					batchID = args[0]
+				}
+				return undoMatching(cmd.Context(), m, batchID, q, dryRun, force)
+			}
+
 			var batchID string
 			if len(args) == 1 {
 				batchID = args[0]
@@ -64,13 +94,18 @@ Use --category to undo only files from specific categories within a batch.`,
 			}
 
 			names := ParseCategoryNames(categoryFilter)
-			return undoBatch(cmd.Context(), m, batchID, dryRun, names)
+			return undoBatch(cmd.Context(), m, batchID, dryRun, force, names)
 		},
 	}
 
 	cmd.Flags().BoolVarP(&listBatches, "list", "l", false, "List all available batches")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview what would be restored without moving any files")
 	cmd.Flags().StringVar(&categoryFilter, "category", "", "Comma-separated list of category names to undo (default: all)")
+	cmd.Flags().BoolVar(&force, "force", false, "Restore files even if they changed since they were moved")
+	cmd.Flags().StringVar(&sel.since, "since", "", "Undo entries recorded at or after this time or age (2025-03-01, 12h, 7d)")
+	cmd.Flags().StringVar(&sel.until, "until", "", "Undo entries recorded before this time or age (a bare date includes that day)")
+	cmd.Flags().StringVar(&sel.path, "path", "", "Undo entries whose source or destination matches this glob (a bare name matches base names)")
+	cmd.Flags().StringSliceVar(&sel.files, "file", nil, "Undo the entry that moved a file to this destination (repeatable)")
 	_ = cmd.RegisterFlagCompletionFunc("category", categoryNameCompletion)
 	return cmd
 }
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/lucasassuncao/movelooper/internal/archive"
+	"github.com/lucasassuncao/movelooper/internal/history"
+	"github.com/lucasassuncao/movelooper/internal/models"
+)
+
+// errArchiveWithoutMembers marks archive entries recorded before history
+// tracked archive members; there is nothing to extract them from.
+var errArchiveWithoutMembers = errors.New("archive entry has no recorded members")
+
+// archiveRestorePlan is what undoing one archive entry will do: the members to
+// extract back to their source paths, and how many are already in place
+// because the category kept its sources.
+type archiveRestorePlan struct {
+	extract []archive.Entry
+	sizes   map[string]int64 // entry name -> recorded size
+	inPlace int
+}
+
+// planArchiveRestore checks every member's source path. A missing source is
+// extracted; a regular file with the recorded size is the kept original and is
+// left alone; anything else occupies the path and makes the whole entry fail,
+// so undo never overwrites a file it did not create.
+func planArchiveRestore(entry history.Entry) (archiveRestorePlan, error) {
+	if len(entry.Members) == 0 {
+		return archiveRestorePlan{}, errArchiveWithoutMembers
+	}
+	plan := archiveRestorePlan{sizes: make(map[string]int64, len(entry.Members))}
+	for _, mem := range entry.Members {
+		info, err := os.Lstat(mem.Source)
+		switch {
+		case os.IsNotExist(err):
+			plan.extract = append(plan.extract, archive.Entry{Source: mem.Source, Name: mem.Name})
+			plan.sizes[mem.Name] = mem.Size
+		case err != nil:
+			return archiveRestorePlan{}, fmt.Errorf("stat %s: %w", mem.Source, err)
+		case info.Mode().IsRegular() && info.Size() == mem.Size:
+			plan.inPlace++
+		default:
+			return archiveRestorePlan{}, fmt.Errorf("source location already occupied: %s", mem.Source)
+		}
+	}
+	return plan, nil
+}
+
+// restoreArchiveEntry undoes one archive entry: it extracts the members whose
+// sources are gone (keep-source: false), verifies each extracted size against
+// the size recorded when the file was packed, and only then deletes the
+// archive. Any failure leaves the archive and the source paths untouched.
+func restoreArchiveEntry(ctx context.Context, m *models.Movelooper, entry history.Entry) error {
+	if _, err := os.Stat(entry.Destination); err != nil {
+		m.Logger.Warn("archive not found at destination, skipping", m.Logger.Args("path", entry.Destination))
+		return err
+	}
+	plan, err := planArchiveRestore(entry)
+	if errors.Is(err, errArchiveWithoutMembers) {
+		m.Logger.Warn("archive was recorded without its member list and cannot be undone; the archive file was left in place",
+			m.Logger.Args("path", entry.Destination))
+		return err
+	}
+	if err != nil {
+		m.Logger.Warn("cannot undo archive, skipping", m.Logger.Args("path", entry.Destination, "error", err.Error()))
+		return err
+	}
+
+	if len(plan.extract) > 0 {
+		err := archive.Extract(ctx, entry.Destination, plan.extract, archive.ExtractOptions{
+			Format: archive.FormatOf(entry.Destination),
+			Verify: func(name string, size int64) error {
+				if want := plan.sizes[name]; size != want {
+					return fmt.Errorf("size mismatch for %q: archive holds %d bytes, %d were recorded", name, size, want)
+				}
+				return nil
+			},
+		})
+		if err != nil {
+			m.Logger.Error("failed to extract archive", m.Logger.Args("path", entry.Destination, "error", err.Error()))
+			return err
+		}
+	}
+
+	if err := os.Remove(entry.Destination); err != nil {
+		m.Logger.Error("files were extracted but the archive could not be removed",
+			m.Logger.Args("path", entry.Destination, "error", err.Error()))
+		return err
+	}
+	m.Logger.Info("archive undone", m.Logger.Args("archive", entry.Destination,
+		"extracted", len(plan.extract), "already_in_place", plan.inPlace))
+	return nil
+}
+
+// dryRunArchiveEntry logs what undoing an archive entry would do.
+func dryRunArchiveEntry(m *models.Movelooper, entry history.Entry) {
+	if _, err := os.Stat(entry.Destination); os.IsNotExist(err) {
+		m.Logger.Warn("[dry-run] archive not found at destination, would skip", m.Logger.Args("path", entry.Destination))
+		return
+	}
+	plan, err := planArchiveRestore(entry)
+	if errors.Is(err, errArchiveWithoutMembers) {
+		m.Logger.Warn("[dry-run] archive was recorded without its member list and cannot be undone",
+			m.Logger.Args("path", entry.Destination))
+		return
+	}
+	if err != nil {
+		m.Logger.Warn("[dry-run] cannot undo archive, would skip", m.Logger.Args("path", entry.Destination, "error", err.Error()))
+		return
+	}
+	args := []any{"archive", entry.Destination, "already_in_place", plan.inPlace}
+	for _, e := range plan.extract {
+		args = append(args, "path", e.Source)
+	}
+	m.Logger.Info(fmt.Sprintf("[dry-run] would extract %d file(s) and remove the archive", len(plan.extract)), m.Logger.Args(args...))
+}
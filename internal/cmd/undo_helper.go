@@ -6,13 +6,16 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/charmbracelet/huh"
 	"github.com/lucasassuncao/movelooper/internal/fileops"
 	"github.com/lucasassuncao/movelooper/internal/history"
 	"github.com/lucasassuncao/movelooper/internal/models"
+	"golang.org/x/term"
 )
 
 func printBatchList(m *models.Movelooper) error {
@@ -23,15 +26,36 @@ func printBatchList(m *models.Movelooper) error {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	fmt.Fprintln(w, "BATCH ID\tFILES\tTIMESTAMP")
-	fmt.Fprintln(w, "--------\t-----\t---------")
+	fmt.Fprintln(w, "BATCH ID\tFILES\tTIMESTAMP\tDETAILS")
+	fmt.Fprintln(w, "--------\t-----\t---------\t-------")
 	for _, b := range batches {
-		fmt.Fprintf(w, "%s\t%d\t%s\n", b.BatchID, b.Count, b.Timestamp.Format("2006-01-02 15:04:05"))
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", b.BatchID, b.Count, b.Timestamp.Format("2006-01-02 15:04:05"), batchDetails(b))
 	}
 	return w.Flush()
 }
 
-func undoBatch(ctx context.Context, m *models.Movelooper, batchID string, dryRun bool, categoryNames []string) error {
+// batchDetails describes a batch by its label and categories, such as
+// "watch burst from 2025-03-15 13:04 [images, docs]".
+func batchDetails(b history.BatchSummary) string {
+	var parts []string
+	if b.Label != "" {
+		parts = append(parts, b.Label)
+	}
+	if len(b.Categories) > 0 {
+		parts = append(parts, "["+strings.Join(b.Categories, ", ")+"]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// undoOptions controls what undo does with a destination that changed since
+// it was recorded (see history.Entry.Verify). With force, it is restored
+// anyway; otherwise confirm is asked per file, and a nil confirm skips it.
+type undoOptions struct {
+	force   bool
+	confirm func(entry history.Entry, check history.Check) bool
+}
+
+func undoBatch(ctx context.Context, m *models.Movelooper, batchID string, dryRun, force bool, categoryNames []string) error {
 	allEntries := m.History.GetBatch(batchID)
 	if len(allEntries) == 0 {
 		return fmt.Errorf("batch %q not found in history", batchID)
@@ -50,19 +74,119 @@ func undoBatch(ctx context.Context, m *models.Movelooper, batchID string, dryRun
 	if dryRun {
 		return dryRunUndoBatch(m, batchID, entries)
 	}
+	return undoEntries(ctx, m, "batch: "+batchID, entries, force)
+}
+
+// undoSelectors are the undo flags that pick entries by time, path or
+// destination file rather than by batch.
+type undoSelectors struct {
+	since, until string
+	path         string
+	files        []string
+}
+
+func (s undoSelectors) active() bool {
+	return s.since != "" || s.until != "" || s.path != "" || len(s.files) > 0
+}
+
+// buildUndoQuery validates the selectors and turns them, with --category, into
+// a history.Query. A --path without a separator is matched against base
+// names; any other is made absolute and matched against full paths.
+func buildUndoQuery(s undoSelectors, categories string, now time.Time) (history.Query, error) {
+	q, err := buildHistoryQuery(historyQueryFlags{since: s.since, until: s.until, category: categories}, now)
+	if err != nil {
+		return history.Query{}, err
+	}
+	if s.path != "" {
+		if _, err := filepath.Match(s.path, ""); err != nil {
+			return history.Query{}, fmt.Errorf("invalid --path pattern %q: %w", s.path, err)
+		}
+		if strings.ContainsAny(s.path, "/"+string(filepath.Separator)) {
+			q.Path = expandPrefix(s.path)
+		} else {
+			q.Name = s.path
+		}
+	}
+	for _, f := range s.files {
+		q.Destinations = append(q.Destinations, expandPrefix(f))
+	}
+	return q, nil
+}
+
+// undoMatching restores the entries matching q, across every batch in
+// history or only within batchID when it is set. Entries are ordered by the
+// time they were recorded, so a file moved twice is walked back one move at a
+// time, and all of them are moved to the redo stack in one history update.
+func undoMatching(ctx context.Context, m *models.Movelooper, batchID string, q history.Query, dryRun, force bool) error {
+	var entries []history.Entry
+	if batchID != "" {
+		all := m.History.GetBatch(batchID)
+		if len(all) == 0 {
+			return fmt.Errorf("batch %q not found in history", batchID)
+		}
+		for _, e := range all {
+			if q.Match(e) {
+				entries = append(entries, e)
+			}
+		}
+	} else {
+		entries = m.History.Search(q)
+		slices.SortStableFunc(entries, func(a, b history.Entry) int { return a.Timestamp.Compare(b.Timestamp) })
+	}
+	if len(entries) == 0 {
+		m.Logger.Info("no history entries match the selection")
+		return nil
+	}
 
-	if cancelled := confirmUndo(m, batchID, entries); cancelled {
+	batches := make(map[string]bool)
+	for _, e := range entries {
+		batches[e.BatchID] = true
+	}
+	if dryRun {
+		m.Logger.Info("[dry-run] would restore selection", m.Logger.Args("files", len(entries), "batches", len(batches)))
+		return dryRunUndoEntries(m, entries)
+	}
+	scope := fmt.Sprintf("%d file(s) from %d batch(es)", len(entries), len(batches))
+	return undoEntries(ctx, m, scope, entries, force)
+}
+
+// undoEntries confirms and restores entries, then records them as undone.
+// scope describes the selection in the confirmation prompt.
+func undoEntries(ctx context.Context, m *models.Movelooper, scope string, entries []history.Entry, force bool) error {
+	if cancelled := confirmUndo(m, scope, entries); cancelled {
 		return nil
 	}
 
-	restored := restoreEntries(ctx, m, entries)
+	opts := undoOptions{force: force}
+	if !force && term.IsTerminal(int(os.Stdin.Fd())) { //#nosec G115 -- a stdin file descriptor always fits in an int
+		opts.confirm = confirmModifiedRestore
+	}
+	recordUndone(m, restoreEntries(ctx, m, entries, opts))
+	return nil
+}
 
-	if len(restored) > 0 {
-		if err := m.History.RemoveEntries(restored); err != nil {
+// recordUndone updates history after an undo: restored entries move to the
+// redo stack, except archives, which redo cannot pack again; those simply
+// leave history rather than wait on the stack for a redo that never comes.
+func recordUndone(m *models.Movelooper, restored []history.Entry) {
+	var redoable, archives []history.Entry
+	for _, e := range restored {
+		if e.Action == string(models.ActionArchive) {
+			archives = append(archives, e)
+		} else {
+			redoable = append(redoable, e)
+		}
+	}
+	if len(archives) > 0 {
+		if err := m.History.RemoveEntries(archives); err != nil {
+			m.Logger.Error("failed to update history", m.Logger.Args("error", err.Error()))
+		}
+	}
+	if len(redoable) > 0 {
+		if err := m.History.MoveToRedo(redoable); err != nil {
 			m.Logger.Error("failed to update history", m.Logger.Args("error", err.Error()))
 		}
 	}
-	return nil
 }
 
 // filterEntriesByCategory returns the subset of entries matching the given categories.
@@ -94,17 +218,26 @@ func filterEntriesByCategory(m *models.Movelooper, batchID string, all []history
 // dryRunUndoBatch logs what would be restored without performing any file operations.
 func dryRunUndoBatch(m *models.Movelooper, batchID string, entries []history.Entry) error {
 	m.Logger.Info("[dry-run] would restore batch", m.Logger.Args("batch_id", batchID, "files", len(entries)))
-	var restoreArgs, removeArgs []any
+	return dryRunUndoEntries(m, entries)
+}
+
+// dryRunUndoEntries logs, file by file, what undoing entries would do.
+func dryRunUndoEntries(m *models.Movelooper, entries []history.Entry) error {
+	var restoreArgs, removeArgs, replacedArgs []any
 	for i := len(entries) - 1; i >= 0; i-- {
 		entry := entries[i]
 		if entry.Action == string(models.ActionArchive) {
-			m.Logger.Warn("[dry-run] archive batches cannot be undone", m.Logger.Args("path", entry.Destination))
+			dryRunArchiveEntry(m, entry)
 			continue
 		}
 		if _, err := os.Stat(entry.Destination); os.IsNotExist(err) {
 			m.Logger.Warn("[dry-run] file not found at destination, would skip", m.Logger.Args("path", entry.Destination))
 			continue
 		}
+		if check, err := entry.Verify(false); err == nil && check.Status == history.IntegrityModified {
+			m.Logger.Warn("[dry-run] destination changed since the move, would ask before restoring it (or skip it without --force when not interactive)",
+				m.Logger.Args("path", entry.Destination, "change", check.Detail))
+		}
 		switch entry.Action {
 		case "copy", "symlink":
 			// Undo removes the destination; the source still existing is expected.
@@ -116,6 +249,14 @@ func dryRunUndoBatch(m *models.Movelooper, batchID string, entries []history.Ent
 			}
 			restoreArgs = append(restoreArgs, "path", entry.Source)
 		}
+		if entry.Replaced != "" {
+			if _, err := os.Lstat(entry.Replaced); err != nil {
+				m.Logger.Warn("[dry-run] replaced file is missing from the backup store, would not restore it",
+					m.Logger.Args("path", entry.Destination, "backup", entry.Replaced))
+				continue
+			}
+			replacedArgs = append(replacedArgs, "path", entry.Destination)
+		}
 	}
 	if len(removeArgs) > 0 {
 		m.Logger.Info("[dry-run] would remove file(s)", m.Logger.Args(removeArgs...))
@@ -123,11 +264,15 @@ func dryRunUndoBatch(m *models.Movelooper, batchID string, entries []history.Ent
 	if len(restoreArgs) > 0 {
 		m.Logger.Info("[dry-run] would restore file(s)", m.Logger.Args(restoreArgs...))
 	}
+	if len(replacedArgs) > 0 {
+		m.Logger.Info("[dry-run] would put back replaced file(s)", m.Logger.Args(replacedArgs...))
+	}
 	return nil
 }
 
 // confirmUndo shows a confirmation prompt and returns true if the user cancelled.
-func confirmUndo(m *models.Movelooper, batchID string, entries []history.Entry) bool {
+// scope names what is being undone, such as "batch: <id>".
+func confirmUndo(m *models.Movelooper, scope string, entries []history.Entry) bool {
 	var sb strings.Builder
 	for i, entry := range entries {
 		if i < 5 {
@@ -137,8 +282,8 @@ func confirmUndo(m *models.Movelooper, batchID string, entries []history.Entry)
 			break
 		}
 	}
-	msg := fmt.Sprintf("Undo batch: %s\n\nFiles to restore (%d total):\n%s\nProceed with restore?",
-		batchID, len(entries), sb.String())
+	msg := fmt.Sprintf("Undo %s\n\nFiles to restore (%d total):\n%s\nProceed with restore?",
+		scope, len(entries), sb.String())
 
 	var confirm bool
 	err := huh.NewConfirm().Title(msg).Value(&confirm).Run()
@@ -152,8 +297,10 @@ func confirmUndo(m *models.Movelooper, batchID string, entries []history.Entry)
 // restoreEntries moves files back to their source locations in reverse order.
 // Returns the entries that were successfully restored so callers can remove
 // only those from history, leaving failed restores available for retry.
-func restoreEntries(ctx context.Context, m *models.Movelooper, entries []history.Entry) []history.Entry {
+// Destinations changed since the move are handled as opts says.
+func restoreEntries(ctx context.Context, m *models.Movelooper, entries []history.Entry, opts undoOptions) []history.Entry {
 	restored := make([]history.Entry, 0, len(entries))
+	var putBackArgs []any
 	failCount := 0
 
 	m.Logger.Info("undoing batch", m.Logger.Args("files", len(entries)))
@@ -162,8 +309,11 @@ func restoreEntries(ctx context.Context, m *models.Movelooper, entries []history
 		entry := entries[i]
 
 		if entry.Action == string(models.ActionArchive) {
-			m.Logger.Warn("archive batches cannot be undone; the archive file was left in place",
-				m.Logger.Args("path", entry.Destination))
+			if err := restoreArchiveEntry(ctx, m, entry); err != nil {
+				failCount++
+				continue
+			}
+			restored = append(restored, entry)
 			continue
 		}
 
@@ -173,6 +323,11 @@ func restoreEntries(ctx context.Context, m *models.Movelooper, entries []history
 			continue
 		}
 
+		if !approveChangedDestination(m, entry, opts) {
+			failCount++
+			continue
+		}
+
 		// The source checks only apply to move undo, which puts the file back at
 		// the source. copy/symlink undo removes the destination, and the source
 		// still existing is expected (those actions never consumed it).
@@ -195,6 +350,9 @@ func restoreEntries(ctx context.Context, m *models.Movelooper, entries []history
 			failCount++
 			continue
 		}
+		if restoreReplaced(ctx, m, entry) {
+			putBackArgs = append(putBackArgs, "path", entry.Destination)
+		}
 		restored = append(restored, entry)
 	}
 
@@ -205,17 +363,58 @@ func restoreEntries(ctx context.Context, m *models.Movelooper, entries []history
 			case string(models.ActionCopy), string(models.ActionSymlink):
 				// Undo removed the destination; the source was never gone.
 				restoredArgs = append(restoredArgs, "removed", entry.Destination)
+			case string(models.ActionArchive):
+				restoredArgs = append(restoredArgs, "unarchived", entry.Destination)
 			default:
 				restoredArgs = append(restoredArgs, "path", entry.Source)
 			}
 		}
 		m.Logger.Info("file(s) restored", m.Logger.Args(restoredArgs...))
 	}
+	if len(putBackArgs) > 0 {
+		m.Logger.Info("replaced file(s) put back", m.Logger.Args(putBackArgs...))
+	}
 
 	m.Logger.Info("undo completed", m.Logger.Args("restored", len(restored), "failed", failCount))
 	return restored
 }
 
+// approveChangedDestination verifies entry's destination against the
+// fingerprint recorded at move time and reports whether the restore may go
+// ahead. Unchanged destinations, and entries without a fingerprint, always
+// may; a changed one needs --force or the user's confirmation, since undoing
+// it would revert, or for copies delete, whatever changed it.
+func approveChangedDestination(m *models.Movelooper, entry history.Entry, opts undoOptions) bool {
+	check, err := entry.Verify(false)
+	if err != nil {
+		m.Logger.Warn("could not verify destination, skipping", m.Logger.Args("path", entry.Destination, "error", err.Error()))
+		return false
+	}
+	if check.Status != history.IntegrityModified {
+		return true
+	}
+	if opts.force {
+		m.Logger.Warn("destination changed since the move, restoring anyway",
+			m.Logger.Args("path", entry.Destination, "change", check.Detail))
+		return true
+	}
+	if opts.confirm != nil && opts.confirm(entry, check) {
+		return true
+	}
+	m.Logger.Warn("destination changed since the move, skipping; use --force to restore it anyway",
+		m.Logger.Args("path", entry.Destination, "change", check.Detail))
+	return false
+}
+
+// confirmModifiedRestore asks whether to undo an entry whose destination
+// changed since the move.
+func confirmModifiedRestore(entry history.Entry, check history.Check) bool {
+	var confirm bool
+	title := fmt.Sprintf("%s changed since it was moved (%s).\n\nUndo it anyway?", entry.Destination, check.Detail)
+	err := huh.NewConfirm().Title(title).Value(&confirm).Run()
+	return err == nil && confirm
+}
+
 // restoreEntry performs the actual file operation for a single history entry.
 func restoreEntry(ctx context.Context, m *models.Movelooper, entry history.Entry) error {
 	switch entry.Action {
@@ -233,6 +432,32 @@ func restoreEntry(ctx context.Context, m *models.Movelooper, entry history.Entry
 	return nil
 }
 
+// restoreReplaced puts back the file that entry's destination overwrote, once
+// the entry itself was undone and the destination is free again, and reports
+// whether it did. A failure is logged but does not fail the entry: the moved
+// file is already back at its source, and the backup stays in the store for a
+// manual recovery.
+func restoreReplaced(ctx context.Context, m *models.Movelooper, entry history.Entry) bool {
+	if entry.Replaced == "" {
+		return false
+	}
+	if _, err := os.Lstat(entry.Replaced); err != nil {
+		m.Logger.Warn("replaced file is missing from the backup store and cannot be restored",
+			m.Logger.Args("path", entry.Destination, "backup", entry.Replaced))
+		return false
+	}
+	if _, err := os.Lstat(entry.Destination); err == nil {
+		m.Logger.Warn("destination is occupied again; replaced file left in the backup store",
+			m.Logger.Args("path", entry.Destination, "backup", entry.Replaced))
+		return false
+	}
+	if err := fileops.MoveFileCtx(ctx, entry.Replaced, entry.Destination); err != nil {
+		m.Logger.Error("failed to restore replaced file", m.Logger.Args("from", entry.Replaced, "to", entry.Destination, "error", err.Error()))
+		return false
+	}
+	return true
+}
+
 // undoCopyOrSymlink removes the destination file or symlink created by a copy or symlink action.
 func undoCopyOrSymlink(dst string) error {
 	return os.Remove(dst)
@@ -3,9 +3,11 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/lucasassuncao/movelooper/internal/history"
 	"github.com/lucasassuncao/movelooper/internal/models"
@@ -23,7 +25,7 @@ func TestRestoreEntries_SkipsArchiveBatch(t *testing.T) {
 		BatchID:     "batch_x",
 		Category:    "images",
 	}}
-	restored := restoreEntries(context.Background(), m, entries)
+	restored := restoreEntries(context.Background(), m, entries, undoOptions{})
 	assert.Empty(t, restored, "archive entries are not restored")
 	assert.Contains(t, buf.String(), "archive")
 }
@@ -51,7 +53,7 @@ func TestRestoreEntries_CopyRemovesDestination(t *testing.T) {
 		Category:    "docs",
 	}}
 
-	restored := restoreEntries(context.Background(), m, entries)
+	restored := restoreEntries(context.Background(), m, entries, undoOptions{})
 	assert.Len(t, restored, 1, "copy entry must be restored")
 	_, err := os.Stat(dst)
 	assert.True(t, os.IsNotExist(err), "destination must be removed")
@@ -80,7 +82,270 @@ func TestRestoreEntries_MoveSkipsWhenSourceOccupied(t *testing.T) {
 		Category:    "docs",
 	}}
 
-	restored := restoreEntries(context.Background(), m, entries)
+	restored := restoreEntries(context.Background(), m, entries, undoOptions{})
 	assert.Empty(t, restored)
 	assert.Contains(t, buf.String(), "source location already occupied")
 }
+
+// TestRestoreEntries_ArchiveRoundTrip archives with keep-source: false, then
+// undoes the batch: the members come back with their content and the archive
+// is removed.
+func TestRestoreEntries_ArchiveRoundTrip(t *testing.T) {
+	for _, format := range []string{"zip", "tar.gz"} {
+		t.Run(format, func(t *testing.T) {
+			src := t.TempDir()
+			dst := t.TempDir()
+			files := fileEntriesFrom(t, src, "a.jpg", "b.jpg")
+			keep := false
+			cat := archiveTestCategory(src, dst, &models.ArchiveConfig{Format: format, KeepSource: &keep})
+
+			var buf bytes.Buffer
+			m := newBufMovelooper(t, &buf, []*models.Category{cat})
+			rec := &history.Buffer{}
+			batch := moveBatch{moved: make(movedSet), batchID: "batch_arc", stats: &runStats{}, recorder: rec}
+			path, err := archiveCategory(context.Background(), m, cat, files, batch)
+			require.NoError(t, err)
+			require.NoError(t, rec.Flush(m.History))
+			require.NoFileExists(t, filepath.Join(src, "a.jpg"))
+
+			entries := m.History.GetBatch("batch_arc")
+			require.Len(t, entries, 1)
+			require.Len(t, entries[0].Members, 2)
+			assert.Equal(t, int64(len("a.jpg")), entries[0].Members[0].Size)
+
+			restored := restoreEntries(context.Background(), m, entries, undoOptions{})
+			assert.Len(t, restored, 1)
+			assert.NoFileExists(t, path, "archive removed after a verified extract")
+			data, err := os.ReadFile(filepath.Join(src, "a.jpg"))
+			require.NoError(t, err)
+			assert.Equal(t, "a.jpg", string(data))
+			assert.FileExists(t, filepath.Join(src, "b.jpg"))
+		})
+	}
+}
+
+// TestRecordUndone_DropsArchives verifies that undone archives leave history
+// rather than sit on the redo stack, which cannot replay them.
+func TestRecordUndone_DropsArchives(t *testing.T) {
+	var buf bytes.Buffer
+	m := newBufMovelooper(t, &buf, nil)
+	require.NoError(t, m.History.AddBatch([]history.Entry{
+		{Source: "/in", Destination: "/out/images.zip", BatchID: "batch_1", Action: string(models.ActionArchive)},
+		{Source: "/in/a.txt", Destination: "/out/a.txt", BatchID: "batch_1", Action: string(models.ActionMove)},
+	}))
+
+	recordUndone(m, m.History.GetBatch("batch_1"))
+	assert.Empty(t, m.History.GetBatch("batch_1"))
+	redo := m.History.GetRedoBatch("batch_1")
+	require.Len(t, redo, 1)
+	assert.Equal(t, "/out/a.txt", redo[0].Destination)
+}
+
+// TestRestoreEntries_ArchiveSizeMismatchKeepsArchive verifies that a member
+// whose extracted size differs from the recorded one aborts the undo without
+// touching the archive or the source paths.
+func TestRestoreEntries_ArchiveSizeMismatchKeepsArchive(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+	files := fileEntriesFrom(t, src, "a.jpg")
+	keep := false
+	cat := archiveTestCategory(src, dst, &models.ArchiveConfig{Format: "zip", KeepSource: &keep})
+
+	var buf bytes.Buffer
+	m := newBufMovelooper(t, &buf, []*models.Category{cat})
+	rec := &history.Buffer{}
+	batch := moveBatch{moved: make(movedSet), batchID: "batch_arc", stats: &runStats{}, recorder: rec}
+	path, err := archiveCategory(context.Background(), m, cat, files, batch)
+	require.NoError(t, err)
+	require.NoError(t, rec.Flush(m.History))
+
+	entries := m.History.GetBatch("batch_arc")
+	entries[0].Members[0].Size = 999
+
+	assert.Empty(t, restoreEntries(context.Background(), m, entries, undoOptions{}))
+	assert.FileExists(t, path)
+	assert.NoFileExists(t, filepath.Join(src, "a.jpg"))
+	assert.NoFileExists(t, filepath.Join(src, "a.jpg.tmp"))
+	assert.Contains(t, buf.String(), "size mismatch")
+}
+
+// TestRestoreEntries_ArchiveKeptSources verifies that undoing an archive whose
+// sources were kept only removes the archive.
+func TestRestoreEntries_ArchiveKeptSources(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+	files := fileEntriesFrom(t, src, "a.jpg")
+	cat := archiveTestCategory(src, dst, &models.ArchiveConfig{Format: "zip"})
+
+	var buf bytes.Buffer
+	m := newBufMovelooper(t, &buf, []*models.Category{cat})
+	rec := &history.Buffer{}
+	batch := moveBatch{moved: make(movedSet), batchID: "batch_arc", stats: &runStats{}, recorder: rec}
+	path, err := archiveCategory(context.Background(), m, cat, files, batch)
+	require.NoError(t, err)
+	require.NoError(t, rec.Flush(m.History))
+
+	restored := restoreEntries(context.Background(), m, m.History.GetBatch("batch_arc"), undoOptions{})
+	assert.Len(t, restored, 1)
+	assert.NoFileExists(t, path)
+	assert.FileExists(t, filepath.Join(src, "a.jpg"))
+}
+
+// TestRestoreEntries_PutsBackReplacedFile verifies that undoing an entry that
+// overwrote a destination moves the file back to its source and then restores
+// the replaced version from the backup store.
+func TestRestoreEntries_PutsBackReplacedFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src", "file.txt")
+	dst := filepath.Join(dir, "dst", "file.txt")
+	kept := filepath.Join(dir, "backups", "batch_x", "file.txt")
+	require.NoError(t, os.MkdirAll(filepath.Dir(dst), 0o750))
+	require.NoError(t, os.MkdirAll(filepath.Dir(kept), 0o750))
+	require.NoError(t, os.WriteFile(dst, []byte("moved"), 0o600))
+	require.NoError(t, os.WriteFile(kept, []byte("original"), 0o600))
+
+	var buf bytes.Buffer
+	m := newBufMovelooper(t, &buf, nil)
+	entries := []history.Entry{{
+		Source:      src,
+		Destination: dst,
+		Action:      string(models.ActionMove),
+		BatchID:     "batch_x",
+		Category:    "docs",
+		Replaced:    kept,
+	}}
+
+	restored := restoreEntries(context.Background(), m, entries, undoOptions{})
+	require.Len(t, restored, 1)
+	got, err := os.ReadFile(src)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("moved"), got)
+	got, err = os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("original"), got)
+	assert.NoFileExists(t, kept)
+	assert.Contains(t, buf.String(), "replaced file(s) put back")
+}
+
+// TestRestoreEntries_MissingBackupStillRestores verifies that a backup lost
+// from the store only produces a warning: the moved file still goes back.
+func TestRestoreEntries_MissingBackupStillRestores(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src", "file.txt")
+	dst := filepath.Join(dir, "dst", "file.txt")
+	require.NoError(t, os.MkdirAll(filepath.Dir(dst), 0o750))
+	require.NoError(t, os.WriteFile(dst, []byte("moved"), 0o600))
+
+	var buf bytes.Buffer
+	m := newBufMovelooper(t, &buf, nil)
+	entries := []history.Entry{{
+		Source: src, Destination: dst, Action: string(models.ActionMove),
+		BatchID: "batch_x", Category: "docs", Replaced: filepath.Join(dir, "gone.txt"),
+	}}
+
+	restored := restoreEntries(context.Background(), m, entries, undoOptions{})
+	assert.Len(t, restored, 1)
+	assert.FileExists(t, src)
+	assert.Contains(t, buf.String(), "missing from the backup store")
+}
+
+// changedMoveEntry moves nothing but sets up a move entry whose destination
+// was edited after it was fingerprinted.
+func changedMoveEntry(t *testing.T) history.Entry {
+	t.Helper()
+	dir := t.TempDir()
+	entry := history.Entry{
+		Source:      filepath.Join(dir, "src", "file.txt"),
+		Destination: filepath.Join(dir, "dst", "file.txt"),
+		BatchID:     "batch_x",
+	}
+	require.NoError(t, os.MkdirAll(filepath.Dir(entry.Destination), 0o750))
+	require.NoError(t, os.WriteFile(entry.Destination, []byte("moved"), 0o600))
+	require.NoError(t, entry.Stamp(false))
+	require.NoError(t, os.WriteFile(entry.Destination, []byte("edited since"), 0o600))
+	return entry
+}
+
+func TestRestoreEntries_ChangedDestination(t *testing.T) {
+	cases := []struct {
+		name        string
+		opts        undoOptions
+		wantRestore bool
+		wantLog     string
+	}{
+		{"skipped without force", undoOptions{}, false, "use --force"},
+		{"restored with force", undoOptions{force: true}, true, "restoring anyway"},
+		{"restored when confirmed", undoOptions{confirm: func(history.Entry, history.Check) bool { return true }}, true, ""},
+		{"skipped when declined", undoOptions{confirm: func(history.Entry, history.Check) bool { return false }}, false, "use --force"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			m := newBufMovelooper(t, &buf, nil)
+			entry := changedMoveEntry(t)
+
+			restored := restoreEntries(context.Background(), m, []history.Entry{entry}, tt.opts)
+			if tt.wantRestore {
+				assert.Len(t, restored, 1)
+				assert.FileExists(t, entry.Source)
+			} else {
+				assert.Empty(t, restored)
+				assert.FileExists(t, entry.Destination, "a skipped file stays where it is")
+			}
+			assert.Contains(t, buf.String(), tt.wantLog)
+		})
+	}
+}
+
+func TestBuildUndoQuery(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+
+	q, err := buildUndoQuery(undoSelectors{path: "*.jpg"}, "images", now)
+	require.NoError(t, err)
+	assert.Equal(t, "*.jpg", q.Name, "a bare pattern matches base names")
+	assert.Empty(t, q.Path)
+	assert.Equal(t, []string{"images"}, q.Categories)
+
+	q, err = buildUndoQuery(undoSelectors{path: "out/*/*.jpg", files: []string{"out/a.jpg"}}, "", now)
+	require.NoError(t, err)
+	assert.True(t, filepath.IsAbs(q.Path))
+	require.Len(t, q.Destinations, 1)
+	assert.True(t, filepath.IsAbs(q.Destinations[0]))
+
+	_, err = buildUndoQuery(undoSelectors{path: "[bad"}, "", now)
+	assert.ErrorContains(t, err, "invalid --path pattern")
+	_, err = buildUndoQuery(undoSelectors{since: "soon"}, "", now)
+	assert.ErrorContains(t, err, "invalid --since")
+}
+
+// TestUndoMatching_SpansBatches verifies that selectors pick entries across
+// batches, and only within the given batch when one is passed.
+func TestUndoMatching_SpansBatches(t *testing.T) {
+	var buf bytes.Buffer
+	m := newBufMovelooper(t, &buf, nil)
+	base := time.Now().Add(-3 * time.Hour)
+	for i, id := range []string{"watch_1", "watch_2", "watch_3"} {
+		require.NoError(t, m.History.AddBatch([]history.Entry{{
+			Source:      fmt.Sprintf("/in/%d.jpg", i),
+			Destination: fmt.Sprintf("/out/jpg/%d.jpg", i),
+			Timestamp:   base.Add(time.Duration(i) * time.Hour),
+			BatchID:     id,
+			Category:    "images",
+		}}))
+	}
+
+	q, err := buildUndoQuery(undoSelectors{since: "150m"}, "", time.Now())
+	require.NoError(t, err)
+	require.NoError(t, undoMatching(context.Background(), m, "", q, true, false))
+	assert.Contains(t, buf.String(), `"files":2,"batches":2`)
+	assert.Contains(t, buf.String(), "/out/jpg/2.jpg")
+	assert.NotContains(t, buf.String(), "/out/jpg/0.jpg")
+
+	buf.Reset()
+	q = history.Query{Destinations: []string{"/out/jpg/1.jpg"}}
+	require.NoError(t, undoMatching(context.Background(), m, "watch_3", q, true, false))
+	assert.Contains(t, buf.String(), "no history entries match")
+
+	assert.ErrorContains(t, undoMatching(context.Background(), m, "watch_9", q, true, false), "not found")
+}
@@ -155,6 +155,9 @@ func (m batchPicker) viewList() string {
 		label := fmt.Sprintf("  ○  %-24s  %3d files  %s",
 			b.BatchID, b.Count, b.Timestamp.Format("2006-01-02 15:04:05"),
 		)
+		if details := batchDetails(b); details != "" {
+			label += "  " + details
+		}
 		if i == 0 {
 			label += "  (most recent)"
 		}
@@ -180,6 +183,9 @@ func (m batchPicker) viewPreview() string {
 	sb.WriteString(pickerTitleStyle.Render(
 		fmt.Sprintf("Preview: %s   (%d files to restore)", batch.BatchID, len(m.previewEntries)),
 	))
+	if details := batchDetails(batch); details != "" {
+		sb.WriteString("   " + pickerDimStyle.Render(details))
+	}
 	sb.WriteString("\n\n")
 	sb.WriteString(m.previewTable.View())
 	sb.WriteString("\n")
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"github.com/lucasassuncao/movelooper/internal/config"
 	"github.com/lucasassuncao/movelooper/internal/models"
 	"github.com/spf13/cobra"
 )
@@ -10,6 +11,11 @@ type WatchOptions struct {
 	ShowFiles       bool
 	CategoryFilter  string
 	IncludeDisabled bool
+	// MetricsAddr, when set, serves Prometheus metrics at http://<addr>/metrics.
+	MetricsAddr string
+	// ConfigPath is the resolved configuration file. Together with the watched
+	// sources it scopes the watch lock and the saved queue.
+	ConfigPath string
 }
 
 // WatchCmd defines the "watch" command to monitor directories and move files in real-time
@@ -18,16 +24,24 @@ func WatchCmd(m *models.Movelooper) *cobra.Command {
 		showFiles       bool
 		categoryFilter  string
 		includeDisabled bool
+		metricsAddr     string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "watch",
 		Short: "Monitor folders and move files in real-time",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			configFlag, _ := cmd.Root().PersistentFlags().GetString("config")
+			configPath, err := config.ResolveConfigPath(configFlag)
+			if err != nil {
+				return err
+			}
 			opts := WatchOptions{
 				ShowFiles:       showFiles,
 				CategoryFilter:  categoryFilter,
 				IncludeDisabled: includeDisabled,
+				MetricsAddr:     metricsAddr,
+				ConfigPath:      configPath,
 			}
 			return runWatch(cmd.Context(), m, opts)
 		},
@@ -36,6 +50,7 @@ func WatchCmd(m *models.Movelooper) *cobra.Command {
 	cmd.Flags().BoolVar(&showFiles, "show-files", false, "Log each file and its destination as it is moved")
 	cmd.Flags().StringVar(&categoryFilter, "category", "", "Comma-separated list of category names to monitor (default: all)")
 	cmd.Flags().BoolVar(&includeDisabled, "include-disabled", false, "Include categories with enabled: false")
+	cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Serve Prometheus metrics at http://<addr>/metrics (e.g. 127.0.0.1:9310); disabled when empty")
 	_ = cmd.RegisterFlagCompletionFunc("category", categoryNameCompletion)
 	return cmd
 }
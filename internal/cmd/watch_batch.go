@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/lucasassuncao/movelooper/internal/history"
+	"github.com/lucasassuncao/movelooper/internal/models"
+)
+
+// watchBatchTimeLayout formats the times in watch batch labels.
+const watchBatchTimeLayout = "2006-01-02 15:04"
+
+// watchBatcher hands out the history batch of each watch-mode move according
+// to configuration.watch.batching. Only the ticker goroutine moves files, so
+// it needs no locking. A nil batcher gives every move its own batch.
+type watchBatcher struct {
+	mode models.BatchingMode
+	idle time.Duration
+	// id and label describe the open batch; opened is when it started, or
+	// the start of its hour in hourly mode, and last is the latest move in it.
+	id     string
+	label  string
+	opened time.Time
+	last   time.Time
+}
+
+func newWatchBatcher(w models.Watch) *watchBatcher {
+	return &watchBatcher{mode: w.Batching, idle: w.BatchIdle}
+}
+
+// next returns the batch ID and label for a move made at now, opening a new
+// batch when the current one is closed.
+func (b *watchBatcher) next(now time.Time) (id, label string) {
+	if b == nil || b.mode == models.BatchingFile || b.mode == "" {
+		return history.NewWatchBatchID(), ""
+	}
+	switch b.mode {
+	case models.BatchingDaemon:
+		if b.id == "" {
+			b.open(now, "watch session started "+now.Format(watchBatchTimeLayout))
+		}
+	case models.BatchingHourly:
+		hour := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, now.Location())
+		if b.id == "" || !hour.Equal(b.opened) {
+			b.open(hour, "watch "+hour.Format(watchBatchTimeLayout)+" to "+hour.Add(time.Hour).Format("15:04"))
+		}
+	case models.BatchingIdle:
+		if b.id == "" || now.Sub(b.last) >= b.idle {
+			b.open(now, "watch burst from "+now.Format(watchBatchTimeLayout))
+		}
+	}
+	b.last = now
+	return b.id, b.label
+}
+
+func (b *watchBatcher) open(at time.Time, label string) {
+	b.id = history.NewWatchBatchID()
+	b.label = label
+	b.opened = at
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lucasassuncao/movelooper/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWatchBatcher verifies when each batching mode opens a new batch.
+func TestWatchBatcher(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2025, 3, 15, 13, 50, 0, 0, time.Local)
+
+	cases := []struct {
+		mode     models.BatchingMode
+		offsets  []time.Duration
+		wantSame []bool // whether each move after the first joins the previous batch
+		label    string
+	}{
+		{models.BatchingFile, []time.Duration{0, time.Second}, []bool{false}, ""},
+		{models.BatchingDaemon, []time.Duration{0, time.Hour, 48 * time.Hour}, []bool{true, true}, "watch session started 2025-03-15 13:50"},
+		{models.BatchingHourly, []time.Duration{0, 5 * time.Minute, 10 * time.Minute}, []bool{true, false}, "watch 2025-03-15 13:00 to 14:00"},
+		{models.BatchingIdle, []time.Duration{0, 30 * time.Second, 80 * time.Second, 3 * time.Minute}, []bool{true, true, false}, "watch burst from 2025-03-15 13:50"},
+	}
+	for _, tt := range cases {
+		b := newWatchBatcher(models.Watch{Batching: tt.mode, BatchIdle: time.Minute})
+		prev, label := b.next(start.Add(tt.offsets[0]))
+		assert.Equal(t, tt.label, label, tt.mode)
+		for i, off := range tt.offsets[1:] {
+			id, _ := b.next(start.Add(off))
+			assert.Equal(t, tt.wantSame[i], id == prev, "%s move %d", tt.mode, i+1)
+			prev = id
+		}
+	}
+
+	var nilBatcher *watchBatcher
+	a, _ := nilBatcher.next(start)
+	c, _ := nilBatcher.next(start)
+	assert.NotEqual(t, a, c, "a nil batcher gives every move its own batch")
+}
@@ -8,8 +8,6 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
-	"runtime"
-	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -17,111 +15,14 @@ import (
 	"github.com/fsnotify/fsnotify"
 	"github.com/lucasassuncao/movelooper/internal/fileops"
 	"github.com/lucasassuncao/movelooper/internal/filters"
-	"github.com/lucasassuncao/movelooper/internal/history"
+	"github.com/lucasassuncao/movelooper/internal/ignore"
+	"github.com/lucasassuncao/movelooper/internal/metrics"
 	"github.com/lucasassuncao/movelooper/internal/models"
 	"github.com/lucasassuncao/movelooper/internal/scanner"
+	"github.com/lucasassuncao/movelooper/internal/systemd"
 	"github.com/lucasassuncao/movelooper/internal/tokens"
 )
 
-const watchLockFile = "movelooper.lock"
-
-// acquireWatchLock creates an exclusive lock file for watch mode.
-// Returns a release function that removes the file on clean shutdown.
-func acquireWatchLock() (func(), error) {
-	path := watchLockPath()
-	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
-		return nil, fmt.Errorf("could not create lock directory for %s: %w", path, err)
-	}
-	return acquireLockAt(path)
-}
-
-// watchLockPath returns the lock file location. It lives under ~/.movelooper
-// (per-user, like logs and history) rather than the OS temp dir, which is
-// shared between users on Unix and would let one user's watcher block
-// another's. The temp dir remains only as a fallback when the home directory
-// cannot be resolved.
-func watchLockPath() string {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return filepath.Join(os.TempDir(), watchLockFile)
-	}
-	return filepath.Join(home, ".movelooper", watchLockFile)
-}
-
-// acquireLockAt creates an exclusive lock file at path, recording the current
-// PID. If the file already exists, the recorded PID decides the outcome: when
-// that process is no longer running (a stale lock left by a killed instance) the
-// lock is reclaimed; when it is still alive the call fails so two watchers never
-// run at once. The returned function removes the lock on clean shutdown.
-func acquireLockAt(path string) (func(), error) {
-	release, err := createLockFile(path)
-	if err == nil {
-		return release, nil
-	}
-	if !os.IsExist(err) {
-		return nil, fmt.Errorf("could not create lock file %s: %w", path, err)
-	}
-
-	if pid, ok := readLockPID(path); ok && processAlive(pid) {
-		return nil, fmt.Errorf(
-			"another instance of movelooper watch appears to be running (pid %d)\n"+
-				"lock file: %s\n"+
-				"if no instance is running, delete the file manually and retry",
-			pid, path,
-		)
-	}
-
-	// Stale lock (dead or unreadable PID): reclaim it and try once more.
-	if err := os.Remove(path); err != nil {
-		return nil, fmt.Errorf("could not remove stale lock file %s: %w", path, err)
-	}
-	release, err = createLockFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("could not reclaim stale lock file %s: %w", path, err)
-	}
-	return release, nil
-}
-
-// createLockFile creates path exclusively and writes the current PID into it.
-func createLockFile(path string) (func(), error) {
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600) //#nosec G304 -- fixed filename under the user's home (or OS temp dir as fallback)
-	if err != nil {
-		return nil, err
-	}
-	_, _ = fmt.Fprintf(f, "%d\n", os.Getpid())
-	f.Close()
-	return func() { os.Remove(path) }, nil
-}
-
-// readLockPID reads the PID recorded in a lock file. ok is false when the file
-// cannot be read or does not hold a valid positive PID.
-func readLockPID(path string) (pid int, ok bool) {
-	data, err := os.ReadFile(path) //#nosec G304 -- fixed filename under the user's home (or OS temp dir as fallback)
-	if err != nil {
-		return 0, false
-	}
-	pid, err = strconv.Atoi(strings.TrimSpace(string(data)))
-	if err != nil || pid <= 0 {
-		return 0, false
-	}
-	return pid, true
-}
-
-// processAlive reports whether a process with the given PID is currently running.
-func processAlive(pid int) bool {
-	proc, err := os.FindProcess(pid)
-	if err != nil {
-		return false // Windows: FindProcess fails when the process does not exist
-	}
-	if runtime.GOOS == "windows" {
-		_ = proc.Release()
-		return true // Windows: a successful FindProcess means the process exists
-	}
-	// Unix: FindProcess always succeeds; probe liveness with signal 0.
-	err = proc.Signal(syscall.Signal(0))
-	return err == nil || errors.Is(err, syscall.EPERM)
-}
-
 // fileInfoDirEntry adapts an os.FileInfo to the os.DirEntry interface.
 // It is used in watch mode, where we obtain file metadata via os.Lstat
 // rather than os.ReadDir, but downstream helpers expect an os.DirEntry.
@@ -146,6 +47,16 @@ type watchConfig struct {
 	// retries counts consecutive failed move attempts per path. Only touched by
 	// the single ticker goroutine, so no locking is needed.
 	retries map[string]int
+	// probes holds the last stability sample of files waiting on the size or
+	// checksum mode. Ticker-goroutine only, like retries.
+	probes map[string]*stabilityProbe
+	// statePath is where the tracker queue is persisted across restarts.
+	statePath string
+	// notifier reports readiness, status and watchdog pings to systemd. Nil
+	// (and a no-op) when not running under a Type=notify unit.
+	notifier *systemd.Notifier
+	// batches groups moves into history batches per watch.batching.
+	batches *watchBatcher
 }
 
 // categoriesWithHooks returns the names of categories that define before/after
@@ -170,13 +81,15 @@ func runWatch(ctx context.Context, m *models.Movelooper, opts WatchOptions) erro
 	}
 	m.Categories = filtered
 
-	release, err := acquireWatchLock()
+	sources := watchSources(m.Categories)
+	release, err := acquireWatchLock(opts.ConfigPath, sources)
 	if err != nil {
 		return err
 	}
 	defer release()
 
-	m.Logger.Info("starting watch mode", m.Logger.Args("stability_delay", m.Config.Watch.Delay.String()))
+	m.Logger.Info("starting watch mode", m.Logger.Args("stability_delay", m.Config.Watch.Delay.String(),
+		"stability", string(m.Config.Watch.Stability)))
 
 	for _, name := range categoriesWithHooks(m.Categories) {
 		m.Logger.Warn("hooks are ignored in watch mode; they run only on the one-shot 'movelooper' command",
@@ -202,29 +115,127 @@ func runWatch(ctx context.Context, m *models.Movelooper, opts WatchOptions) erro
 
 	cfg := watchConfig{
 		tracker:   newFileTracker(),
-		threshold: m.Config.Watch.Delay,
+		threshold: minWatchDelay(m),
 		showFiles: opts.ShowFiles,
 		retries:   make(map[string]int),
+		probes:    make(map[string]*stabilityProbe),
+		statePath: watchStatePath(watchLockKey(opts.ConfigPath, sources)),
+		notifier:  systemd.NewNotifierFromEnv(),
+		batches:   newWatchBatcher(m.Config.Watch),
 	}
 
 	registerSources(m, watcher)
 
+	loadSavedWatchState(m, &cfg)
+
 	m.Logger.Info("performing initial scan for existing files")
 	performInitialScan(ctx, m, cfg.tracker)
 
 	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	if opts.MetricsAddr != "" {
+		if err := startMetricsServer(ctx, m, opts.MetricsAddr, cfg.tracker); err != nil {
+			return err
+		}
+	}
+
+	tickerDone := make(chan struct{})
 	go runEventLoop(ctx, m, watcher, cfg.tracker)
-	go runTickerLoop(ctx, m, &cfg)
+	go func() {
+		defer close(tickerDone)
+		runTickerLoop(ctx, m, &cfg)
+	}()
 
 	m.Logger.Info("watching for changes — press Ctrl+C to stop")
+	notifySystemd(m, cfg.notifier.Ready(watchStatusLine(cfg.tracker)))
 
 	<-ctx.Done()
 	m.Logger.Info("shutting down watch mode")
+	notifySystemd(m, cfg.notifier.Stopping())
+	// Wait for the ticker to finish its current tick: it owns cfg.retries, and
+	// the final snapshot must not race an in-flight move.
+	<-tickerDone
+	persistWatchState(m, &cfg)
 	return nil
 }
 
+// loadSavedWatchState restores the tracker queue saved by a previous run of the
+// same config and sources. A missing or unreadable state file only costs the
+// saved stability progress, so failures are logged and watch starts with an
+// empty queue.
+func loadSavedWatchState(m *models.Movelooper, cfg *watchConfig) {
+	st, err := loadWatchState(cfg.statePath)
+	if err != nil {
+		m.Logger.Warn("could not load saved watch state; starting with an empty queue",
+			m.Logger.Args("path", cfg.statePath, "error", err.Error()))
+		return
+	}
+	pending, parked := restoreWatchState(st, m, cfg.tracker, cfg.retries)
+	if pending > 0 || parked > 0 {
+		m.Logger.Info("restored watch queue from previous run",
+			m.Logger.Args("pending", pending, "parked", parked, "saved_at", st.SavedAt.Format(time.RFC3339)))
+	}
+}
+
+// persistWatchState snapshots the tracker to cfg.statePath. It must run on the
+// ticker goroutine or after it has stopped, since it reads cfg.retries.
+func persistWatchState(m *models.Movelooper, cfg *watchConfig) {
+	if err := saveWatchState(cfg.statePath, captureWatchState(cfg.tracker, cfg.retries, time.Now())); err != nil {
+		m.Logger.Warn("failed to save watch state", m.Logger.Args("path", cfg.statePath, "error", err.Error()))
+	}
+}
+
+// pruneWatchBookkeeping drops retry counts and stability samples of files the
+// tracker no longer knows about, e.g. files deleted or renamed away while
+// queued. It must run on the ticker goroutine, which owns both maps.
+func pruneWatchBookkeeping(cfg *watchConfig) {
+	for path := range cfg.retries {
+		if !cfg.tracker.has(path) {
+			delete(cfg.retries, path)
+		}
+	}
+	for path := range cfg.probes {
+		if !cfg.tracker.has(path) {
+			delete(cfg.probes, path)
+		}
+	}
+}
+
+// startMetricsServer enables m.Metrics and serves it on addr until ctx is
+// cancelled. The tracker's queue gauges are sampled on each scrape. A listen
+// failure aborts startup, since the user explicitly asked for the endpoint.
+func startMetricsServer(ctx context.Context, m *models.Movelooper, addr string, tracker *fileTracker) error {
+	if m.Metrics == nil {
+		m.Metrics = metrics.NewCollector()
+	}
+	m.Metrics.RegisterQueue(tracker.len, tracker.oldest)
+	done, err := metrics.Serve(ctx, addr, m.Metrics.Registry())
+	if err != nil {
+		return fmt.Errorf("could not start metrics endpoint on %s: %w", addr, err)
+	}
+	go func() {
+		if err := <-done; err != nil {
+			m.Logger.Error("metrics endpoint stopped", m.Logger.Args("addr", addr, "error", err.Error()))
+		}
+	}()
+	m.Logger.Info("serving metrics", m.Logger.Args("url", "http://"+addr+"/metrics"))
+	return nil
+}
+
+// watchStatusLine is the STATUS= text shown by `systemctl status`.
+func watchStatusLine(tracker *fileTracker) string {
+	return fmt.Sprintf("watching; %d file(s) pending", tracker.len())
+}
+
+// notifySystemd logs a failed sd_notify write. Notifications are best-effort:
+// a missing socket must never stop watch mode.
+func notifySystemd(m *models.Movelooper, err error) {
+	if err != nil {
+		m.Logger.Debug("sd_notify failed", m.Logger.Args("error", err.Error()))
+	}
+}
+
 // registerSources adds each unique source directory to the watcher.
 func registerSources(m *models.Movelooper, watcher *fsnotify.Watcher) {
 	seen := make(map[string]bool, len(m.Categories))
@@ -243,7 +254,9 @@ func registerSources(m *models.Movelooper, watcher *fsnotify.Watcher) {
 	}
 }
 
-// runEventLoop captures fsnotify events and updates the tracker.
+// runEventLoop captures fsnotify events and updates the tracker. When the
+// kernel event queue overflows, events were lost, so every source is rescanned
+// to pick up files whose create/write events never arrived.
 func runEventLoop(ctx context.Context, m *models.Movelooper, watcher *fsnotify.Watcher, tracker *fileTracker) {
 	for {
 		select {
@@ -251,15 +264,17 @@ func runEventLoop(ctx context.Context, m *models.Movelooper, watcher *fsnotify.W
 			if !ok {
 				return
 			}
-			if event.Op&fsnotify.Write == fsnotify.Write || event.Op&fsnotify.Create == fsnotify.Create {
-				if !tracker.touch(event.Name, time.Now()) {
-					m.Logger.Info("detected new file", m.Logger.Args("path", event.Name))
-				}
-			}
+			handleWatchEvent(m, tracker, event, time.Now())
 		case err, ok := <-watcher.Errors:
 			if !ok {
 				return
 			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				m.Logger.Warn("watcher event queue overflowed; rescanning source directories",
+					m.Logger.Args("error", err.Error()))
+				performInitialScan(ctx, m, tracker)
+				continue
+			}
 			m.Logger.Error("watcher error", m.Logger.Args("error", err.Error()))
 		case <-ctx.Done():
 			return
@@ -267,14 +282,60 @@ func runEventLoop(ctx context.Context, m *models.Movelooper, watcher *fsnotify.W
 	}
 }
 
-// runTickerLoop periodically checks for stable files and moves them.
+// handleWatchEvent applies one filesystem event to the tracker. Create and
+// write events (a file moved in on the same filesystem arrives as Create) queue
+// the path or push its timestamp forward. Remove and rename events mean the
+// name went away, so the path is dropped from the queue; some backends report
+// a rename under the new name, so a rename whose path still holds a regular
+// file is treated as a move-in instead.
+func handleWatchEvent(m *models.Movelooper, tracker *fileTracker, event fsnotify.Event, now time.Time) {
+	switch {
+	case event.Has(fsnotify.Create) || event.Has(fsnotify.Write):
+		if !tracker.touch(event.Name, now) {
+			m.Logger.Info("detected new file", m.Logger.Args("path", event.Name))
+		}
+	case event.Has(fsnotify.Rename):
+		if info, err := os.Lstat(event.Name); err == nil && info.Mode().IsRegular() {
+			if !tracker.touch(event.Name, now) {
+				m.Logger.Info("detected file moved in", m.Logger.Args("path", event.Name))
+			}
+			return
+		}
+		if tracker.forget(event.Name) {
+			m.Logger.Info("stopped tracking file renamed away", m.Logger.Args("path", event.Name))
+		}
+	case event.Has(fsnotify.Remove):
+		if tracker.forget(event.Name) {
+			m.Logger.Info("stopped tracking deleted file", m.Logger.Args("path", event.Name))
+		}
+	}
+}
+
+// runTickerLoop periodically checks for stable files and moves them, and
+// snapshots the queue every watchStateSaveInterval. Under systemd it also
+// publishes the queue size after every tick and pings the watchdog, so a loop
+// that hangs stops the pings and gets the service restarted.
 func runTickerLoop(ctx context.Context, m *models.Movelooper, cfg *watchConfig) {
 	ticker := time.NewTicker(m.Config.Watch.PollInterval)
 	defer ticker.Stop()
+	saveTicker := time.NewTicker(watchStateSaveInterval)
+	defer saveTicker.Stop()
+	var watchdog <-chan time.Time
+	if interval := cfg.notifier.WatchdogInterval(); interval > 0 {
+		wt := time.NewTicker(interval)
+		defer wt.Stop()
+		watchdog = wt.C
+	}
 	for {
 		select {
 		case <-ticker.C:
 			processPendingFiles(ctx, m, cfg)
+			notifySystemd(m, cfg.notifier.Status(watchStatusLine(cfg.tracker)))
+		case <-saveTicker.C:
+			pruneWatchBookkeeping(cfg)
+			persistWatchState(m, cfg)
+		case <-watchdog:
+			notifySystemd(m, cfg.notifier.Watchdog())
 		case <-ctx.Done():
 			return
 		}
@@ -282,10 +343,11 @@ func runTickerLoop(ctx context.Context, m *models.Movelooper, cfg *watchConfig)
 }
 
 // performInitialScan verifies existing files in source directories and adds
-// them to the tracker. The scan is deliberately non-recursive: fsnotify only
-// watches the top-level source directory and attemptMoveFile only matches
-// files directly under it, so tracking files from subdirectories would queue
-// entries that can never be moved.
+// them to the tracker. Files already queued (restored from a saved state) keep
+// their event time, and parked files stay parked. The scan is deliberately
+// non-recursive: fsnotify only watches the top-level source directory and
+// attemptMoveFile only matches files directly under it, so tracking files from
+// subdirectories would queue entries that can never be moved.
 func performInitialScan(ctx context.Context, m *models.Movelooper, tracker *fileTracker) {
 	for _, cat := range m.Categories {
 		if !cat.IsEnabled() {
@@ -312,7 +374,7 @@ func performInitialScan(ctx context.Context, m *models.Movelooper, tracker *file
 			if !filters.MatchesFilter(cat.Source.Filter, fullPath, info) {
 				continue
 			}
-			tracker.touch(fullPath, time.Now())
+			tracker.add(fullPath, time.Now())
 		}
 	}
 }
@@ -320,34 +382,61 @@ func performInitialScan(ctx context.Context, m *models.Movelooper, tracker *file
 // processPendingFiles moves the files whose stability delay has elapsed. due()
 // pops them off the heap atomically, so a file that received an event after the
 // tick fired stays queued (its timestamp moved forward) instead of moving early.
+// The tracker pops against the shortest configured delay; a file whose own
+// category policy is not yet satisfied (a longer delay, or too few unchanged
+// size/checksum polls) is requeued with its original event time.
 // A failed move is requeued for another stability cycle up to
 // maxWatchMoveRetries times, so a transient failure (e.g. a file briefly locked
 // by another process) does not leave the file behind until a new event arrives.
+// A move interrupted by shutdown is not a failure and keeps its event time.
+// Each move is preceded by a watchdog heartbeat, so a tick that moves many
+// files is not mistaken for a hang; a single move still has to finish within
+// WatchdogSec.
 func processPendingFiles(ctx context.Context, m *models.Movelooper, cfg *watchConfig) {
-	for _, path := range cfg.tracker.due(time.Now(), cfg.threshold) {
+	now := time.Now()
+	for _, pf := range cfg.tracker.duePending(now, cfg.threshold) {
+		path := pf.path
 		if _, err := os.Stat(path); err != nil {
 			if !os.IsNotExist(err) {
 				m.Logger.Warn("failed to stat tracked file, skipping",
 					m.Logger.Args("path", path, "error", err.Error()))
 			}
 			delete(cfg.retries, path)
+			delete(cfg.probes, path)
+			continue
+		}
+
+		if !cfg.isStable(m, pf, now) {
+			cfg.tracker.add(path, pf.detected)
 			continue
 		}
+		delete(cfg.probes, path)
 
-		err := attemptMoveFile(ctx, m, path, cfg.showFiles)
+		notifySystemd(m, cfg.notifier.Watchdog())
+		err := attemptMoveFile(ctx, m, path, cfg.showFiles, cfg.batches)
 		if err == nil || os.IsNotExist(err) {
 			delete(cfg.retries, path)
 			continue
 		}
+		if ctx.Err() != nil {
+			// Shutting down: the move was interrupted, not failed. Requeue the
+			// file untouched so the saved state neither counts a retry nor
+			// restarts its stability clock.
+			cfg.tracker.add(path, pf.detected)
+			continue
+		}
 
 		cfg.retries[path]++
 		if cfg.retries[path] < maxWatchMoveRetries {
 			m.Logger.Warn("failed to move file, will retry",
 				m.Logger.Args("path", path, "attempt", cfg.retries[path], "error", err.Error()))
+			m.Metrics.WatchRetry()
 			cfg.tracker.touch(path, time.Now())
 			continue
 		}
 		delete(cfg.retries, path)
+		cfg.tracker.park(path)
+		m.Metrics.WatchRetriesExhausted()
 		m.Logger.Error("failed to move file, giving up until a new event re-tracks it",
 			m.Logger.Args("path", path, "attempts", maxWatchMoveRetries, "error", err.Error()))
 	}
@@ -365,30 +454,56 @@ func resolveDestDir(cat *models.Category, path string) string {
 	if err != nil {
 		return cat.Destination.Path
 	}
-	tctx := tokens.TokenContext{Info: info, CategoryName: cat.Name, Now: time.Now(), SourcePath: path}
+	tctx := tokens.TokenContext{
+		Info: info, CategoryName: cat.Name, Now: time.Now(),
+		SourcePath: path, SourceRoot: cat.Source.Path,
+		TagDefaults:      cat.Destination.TagDefaults,
+		Captures:         filters.Captures(cat.Source.Filter, filepath.Base(path)),
+		NameDatePatterns: cat.Source.CompiledNameDatePatterns,
+	}
 	return fileops.ResolveDestDir(cat, &tctx)
 }
 
-// attemptMoveFile tries to find a matching category and move the file.
-func attemptMoveFile(ctx context.Context, m *models.Movelooper, path string, showFiles bool) error {
-	fileName := filepath.Base(path)
+// attemptMoveFile tries to find a matching category and move the file,
+// recording it in the batch batches hands out.
+func attemptMoveFile(ctx context.Context, m *models.Movelooper, path string, showFiles bool, batches *watchBatcher) error {
+	cat := matchingWatchCategory(m, path)
+	if cat == nil {
+		return nil
+	}
 	ext := strings.TrimPrefix(filepath.Ext(path), ".")
 	if ext == "" {
 		ext = filters.ExtAll
 	}
+	if showFiles {
+		m.Logger.Info("moving file",
+			m.Logger.Args("file", filepath.Base(path), "to", resolveDestDir(cat, path), "category", cat.Name))
+	}
+	return moveFileToCategory(ctx, m, *cat, path, ext, batches)
+}
 
+// matchingWatchCategory returns the first category whose source directory
+// directly contains path and whose extensions and filters match it, or nil.
+// A file a .mlignore rule ignores matches no category.
+func matchingWatchCategory(m *models.Movelooper, path string) *models.Category {
+	fileName := filepath.Base(path)
 	for _, cat := range m.Categories {
 		if filepath.Clean(filepath.Dir(path)) != filepath.Clean(cat.Source.Path) {
 			continue
 		}
-		if !matchesExtensionAndFilters(cat, fileName, path) {
-			continue
+		rule, err := ignore.Check(cat.Source.Path, path, false)
+		if err != nil {
+			m.Logger.Warn("failed to read ignore file, leaving file in place",
+				m.Logger.Args("path", path, "error", err.Error()))
+			return nil
+		}
+		if rule != nil {
+			m.Logger.Debug("file ignored", m.Logger.Args("path", path, "rule", rule.String()))
+			return nil
 		}
-		if showFiles {
-			m.Logger.Info("moving file",
-				m.Logger.Args("file", fileName, "to", resolveDestDir(cat, path), "category", cat.Name))
+		if matchesExtensionAndFilters(cat, fileName, path) {
+			return cat
 		}
-		return moveFileToCategory(ctx, m, *cat, path, ext)
 	}
 	return nil
 }
@@ -414,25 +529,28 @@ func matchesExtensionAndFilters(cat *models.Category, fileName, path string) boo
 	return filters.MatchesFilter(cat.Source.Filter, path, info)
 }
 
-func moveFileToCategory(ctx context.Context, m *models.Movelooper, cat models.Category, path, ext string) error {
+func moveFileToCategory(ctx context.Context, m *models.Movelooper, cat models.Category, path, ext string, batches *watchBatcher) error {
 	info, err := os.Lstat(path)
 	if err != nil {
 		return fmt.Errorf("failed to stat file before move: %w", err)
 	}
 
 	targetFile := fileInfoDirEntry{info: info}
-	batchID := history.NewWatchBatchID()
+	batchID, label := batches.next(time.Now())
 	// Watch moves one file at a time, so saving per Add is fine here; assign the
 	// concrete *History only when tracking is enabled to avoid a typed-nil Recorder.
-	mctx := fileops.MoveContext{Logger: m.Logger}
+	mctx := fileops.MoveContext{Logger: m.Logger, Metrics: m.Metrics}
 	if m.History != nil {
 		mctx.History = m.History
+		mctx.BackupDir = m.History.BackupDir()
+		mctx.HashDestinations = m.Config.History.Hash
 	}
 	result := fileops.MoveFiles(ctx, mctx, fileops.MoveRequest{
 		Category:    &cat,
 		Files:       []os.DirEntry{targetFile},
 		Extension:   ext,
 		BatchID:     batchID,
+		Label:       label,
 		SourceDir:   filepath.Dir(path),
 		LogEachMove: true,
 	})
@@ -1,13 +1,18 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"net"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strconv"
 	"testing"
+	"time"
 
+	"github.com/fsnotify/fsnotify"
+	"github.com/lucasassuncao/movelooper/internal/logger"
 	"github.com/lucasassuncao/movelooper/internal/models"
+	"github.com/lucasassuncao/movelooper/internal/systemd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -101,70 +106,112 @@ func TestMatchesExtensionAndFilters(t *testing.T) {
 	})
 }
 
-// TestAcquireLockAt covers the PID-aware watch lock: a fresh lock records the
-// current PID, a lock held by a live process is rejected, and a stale lock left
-// by a dead (or unreadable) PID is reclaimed.
-func TestAcquireLockAt(t *testing.T) {
+// TestMatchingWatchCategory_Ignore verifies that a file a .mlignore rule
+// ignores matches no category, and that the ignore file itself never moves.
+func TestMatchingWatchCategory_Ignore(t *testing.T) {
 	t.Parallel()
+	dir := t.TempDir()
+	for name, content := range map[string]string{".mlignore": "*.part\n", "movie.part": "x", "movie.mkv": "x"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	cat := &models.Category{Name: "all", Source: models.CategorySource{Path: dir, Extensions: []string{"all"}}}
+	m := &models.Movelooper{Logger: logger.NewSlog(&bytes.Buffer{}, "info", false), Categories: []*models.Category{cat}}
 
-	t.Run("creates lock with current pid and releases", func(t *testing.T) {
-		t.Parallel()
-		path := filepath.Join(t.TempDir(), "test.lock")
-		release, err := acquireLockAt(path)
-		require.NoError(t, err)
-		assert.FileExists(t, path)
-		pid, ok := readLockPID(path)
-		require.True(t, ok)
-		assert.Equal(t, os.Getpid(), pid)
-		release()
-		assert.NoFileExists(t, path)
-	})
+	assert.Same(t, cat, matchingWatchCategory(m, filepath.Join(dir, "movie.mkv")))
+	assert.Nil(t, matchingWatchCategory(m, filepath.Join(dir, "movie.part")))
+	assert.Nil(t, matchingWatchCategory(m, filepath.Join(dir, ".mlignore")))
+}
 
-	t.Run("rejects a lock held by a live process", func(t *testing.T) {
-		t.Parallel()
-		path := filepath.Join(t.TempDir(), "test.lock")
-		release, err := acquireLockAt(path)
-		require.NoError(t, err)
-		defer release()
-
-		_, err = acquireLockAt(path)
-		require.Error(t, err)
-		assert.Contains(t, err.Error(), "appears to be running")
-	})
+// TestHandleWatchEvent covers the event kinds the watcher reacts to: create
+// and write queue a file, a rename onto an existing file is a move-in, and a
+// rename away or a removal drops the file from the queue.
+func TestHandleWatchEvent(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	present := filepath.Join(dir, "in.jpg")
+	require.NoError(t, os.WriteFile(present, []byte("x"), 0o644))
+	gone := filepath.Join(dir, "gone.jpg")
 
-	t.Run("reclaims a stale lock with a dead pid", func(t *testing.T) {
-		t.Parallel()
-		path := filepath.Join(t.TempDir(), "test.lock")
-		require.NoError(t, os.WriteFile(path, []byte(strconv.Itoa(deadPID(t))+"\n"), 0o600))
+	m := &models.Movelooper{Logger: logger.NewSlog(&bytes.Buffer{}, "info", false)}
+	tr := newFileTracker()
+	now := time.Now()
 
-		release, err := acquireLockAt(path)
-		require.NoError(t, err)
-		defer release()
+	handleWatchEvent(m, tr, fsnotify.Event{Name: gone, Op: fsnotify.Create}, now)
+	assert.True(t, tr.has(gone))
 
-		pid, ok := readLockPID(path)
-		require.True(t, ok)
-		assert.Equal(t, os.Getpid(), pid, "stale lock should be reclaimed with our pid")
-	})
+	handleWatchEvent(m, tr, fsnotify.Event{Name: gone, Op: fsnotify.Rename}, now)
+	assert.False(t, tr.has(gone), "renamed away")
 
-	t.Run("reclaims a lock with an unreadable pid", func(t *testing.T) {
-		t.Parallel()
-		path := filepath.Join(t.TempDir(), "test.lock")
-		require.NoError(t, os.WriteFile(path, []byte("not-a-pid"), 0o600))
+	handleWatchEvent(m, tr, fsnotify.Event{Name: present, Op: fsnotify.Rename}, now)
+	assert.True(t, tr.has(present), "rename reported under the new name is a move-in")
 
-		release, err := acquireLockAt(path)
-		require.NoError(t, err)
-		defer release()
-		assert.FileExists(t, path)
-	})
+	handleWatchEvent(m, tr, fsnotify.Event{Name: present, Op: fsnotify.Remove}, now)
+	assert.False(t, tr.has(present))
+
+	handleWatchEvent(m, tr, fsnotify.Event{Name: present, Op: fsnotify.Chmod}, now)
+	assert.False(t, tr.has(present), "chmod is ignored")
+}
+
+// TestProcessPendingFiles_WatchdogHeartbeat verifies that every move is
+// preceded by a watchdog ping, so a tick busy with many moves keeps the
+// service alive while a hung tick does not.
+func TestProcessPendingFiles_WatchdogHeartbeat(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: sock, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", sock)
+	t.Setenv("WATCHDOG_USEC", "60000000")
+	t.Setenv("WATCHDOG_PID", "")
+
+	path := filepath.Join(t.TempDir(), "a.txt")
+	require.NoError(t, os.WriteFile(path, []byte("a"), 0o644))
+
+	var buf bytes.Buffer
+	m := &models.Movelooper{Logger: logger.NewSlog(&buf, "info", false)}
+	cfg := &watchConfig{
+		tracker:  newFileTracker(),
+		retries:  make(map[string]int),
+		probes:   make(map[string]*stabilityProbe),
+		notifier: systemd.NewNotifierFromEnv(),
+	}
+	cfg.tracker.add(path, time.Now().Add(-time.Hour))
+
+	processPendingFiles(context.Background(), m, cfg)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	msg := make([]byte, 64)
+	n, err := conn.Read(msg)
+	require.NoError(t, err)
+	assert.Equal(t, "WATCHDOG=1", string(msg[:n]))
 }
 
-// deadPID starts a short-lived process and reaps it, returning a PID that is no
-// longer running (and will not be reused for the duration of the test). It runs
-// the test binary itself with a non-matching -test.run, which exits immediately.
-func deadPID(t *testing.T) int {
-	t.Helper()
-	cmd := exec.Command(os.Args[0], "-test.run=^a_subtest_that_never_matches$")
-	require.NoError(t, cmd.Start())
-	_ = cmd.Wait()
-	return cmd.Process.Pid
+// TestProcessPendingFiles_ShutdownKeepsRetryState verifies that a move
+// interrupted by shutdown neither counts as a retry nor restarts the file's
+// stability clock, so repeated restarts cannot push a file to park.
+func TestProcessPendingFiles_ShutdownKeepsRetryState(t *testing.T) {
+	src := t.TempDir()
+	path := filepath.Join(src, "a.txt")
+	require.NoError(t, os.WriteFile(path, []byte("a"), 0o644))
+
+	var buf bytes.Buffer
+	m := newBufMovelooper(t, &buf, []*models.Category{moveTestCategory("docs", src, t.TempDir(), "", []string{"txt"})})
+	cfg := &watchConfig{
+		tracker: newFileTracker(),
+		retries: make(map[string]int),
+		probes:  make(map[string]*stabilityProbe),
+		batches: newWatchBatcher(m.Config.Watch),
+	}
+	detected := time.Now().Add(-time.Hour)
+	cfg.tracker.add(path, detected)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	processPendingFiles(ctx, m, cfg)
+
+	assert.FileExists(t, path, "a cancelled move leaves the file in place")
+	assert.Empty(t, cfg.retries, "an interrupted move is not a failed attempt")
+	due := cfg.tracker.duePending(time.Now(), 0)
+	require.Len(t, due, 1)
+	assert.True(t, due[0].detected.Equal(detected), "the original event time is kept")
 }
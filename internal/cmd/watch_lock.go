@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/lucasassuncao/movelooper/internal/models"
+)
+
+const (
+	// watchLockPrefix and watchLockSuffix frame the per-config lock file name,
+	// watch-<key>.lock, where key is watchLockKey of the config and sources.
+	watchLockPrefix = "watch-"
+	watchLockSuffix = ".lock"
+	// legacyWatchLockFile is the single per-user lock used before locks were
+	// scoped per config. A live one still blocks startup, since the older
+	// binary holding it cannot tell us what it watches.
+	legacyWatchLockFile = "movelooper.lock"
+)
+
+// watchLockInfo is the content of a watch lock file: who holds it and what it
+// watches, so a second watcher can explain exactly what it collides with.
+type watchLockInfo struct {
+	PID     int      `json:"pid"`
+	Config  string   `json:"config"`
+	Sources []string `json:"sources"`
+}
+
+// watchSources returns the sorted, de-duplicated source directories of the
+// enabled categories: the set a watcher actually monitors. Paths are made
+// absolute and resolved through symlinks, so two configs reaching the same
+// directory by different spellings produce the same lock key and overlap check.
+func watchSources(cats []*models.Category) []string {
+	seen := make(map[string]bool, len(cats))
+	var out []string
+	for _, cat := range cats {
+		if !cat.IsEnabled() {
+			continue
+		}
+		p := canonicalSourcePath(cat.Source.Path)
+		if !seen[p] {
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// canonicalSourcePath returns the absolute, symlink-free form of path. A path
+// that does not exist (yet) cannot be resolved and is only made absolute.
+func canonicalSourcePath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved
+	}
+	return abs
+}
+
+// watchLockKey hashes the resolved config path and the watched sources into a
+// short key. Two runs of the same config over the same sources share a key (and
+// so exclude each other); a different config, or the same config narrowed with
+// --category to other sources, gets its own lock.
+func watchLockKey(configPath string, sources []string) string {
+	h := sha256.New()
+	h.Write([]byte(filepath.Clean(configPath)))
+	for _, s := range sources {
+		h.Write([]byte{0})
+		h.Write([]byte(s))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// watchLockDir returns the directory holding watch locks and state. It lives
+// under ~/.movelooper (per-user, like logs and history) rather than the OS temp
+// dir, which is shared between users on Unix and would let one user's watcher
+// block another's. The temp dir remains only as a fallback when the home
+// directory cannot be resolved.
+func watchLockDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return os.TempDir()
+	}
+	return filepath.Join(home, ".movelooper")
+}
+
+// acquireWatchLock takes the lock for watching sources with the config at
+// configPath and returns a release function that removes it on clean shutdown.
+// It fails when the same config and sources are already being watched, or when
+// any live watcher holds one of the same source directories.
+func acquireWatchLock(configPath string, sources []string) (func(), error) {
+	dir := watchLockDir()
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("could not create lock directory %s: %w", dir, err)
+	}
+	path := filepath.Join(dir, watchLockPrefix+watchLockKey(configPath, sources)+watchLockSuffix)
+
+	if err := checkOverlappingWatchers(dir, path, sources); err != nil {
+		return nil, err
+	}
+	release, err := acquireLockAt(path, watchLockInfo{PID: os.Getpid(), Config: configPath, Sources: sources})
+	if err != nil {
+		return nil, err
+	}
+	// Check again now that our own lock is visible: two overlapping watchers
+	// starting at the same moment can both pass the first check, but each will
+	// see the other here. Both may then refuse, which is the safe outcome.
+	if err := checkOverlappingWatchers(dir, path, sources); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// checkOverlappingWatchers scans the other watch locks in dir and returns an
+// error naming the config and PID of a live watcher that monitors one of
+// sources. own is skipped; dead holders are ignored (their lock is stale).
+// Watch mode is not recursive, so only identical directories share files.
+func checkOverlappingWatchers(dir, own string, sources []string) error {
+	mine := make(map[string]bool, len(sources))
+	for _, s := range sources {
+		mine[s] = true
+	}
+
+	if info, ok := readLockInfo(filepath.Join(dir, legacyWatchLockFile)); ok && processAlive(info.PID) {
+		return fmt.Errorf(
+			"an older movelooper watch is running (pid %d) and holds the shared lock %s\n"+
+				"stop it first, or delete the file if no instance is running",
+			info.PID, filepath.Join(dir, legacyWatchLockFile),
+		)
+	}
+
+	locks, _ := filepath.Glob(filepath.Join(dir, watchLockPrefix+"*"+watchLockSuffix))
+	for _, lock := range locks {
+		if lock == own {
+			continue
+		}
+		info, ok := readLockInfo(lock)
+		if !ok || info.PID == os.Getpid() || !processAlive(info.PID) {
+			continue
+		}
+		for _, src := range info.Sources {
+			if mine[canonicalSourcePath(src)] {
+				return fmt.Errorf(
+					"source directory %s is already watched by movelooper watch with config %s (pid %d)\n"+
+						"stop that instance, or narrow one of them with --category so their sources do not overlap",
+					src, info.Config, info.PID,
+				)
+			}
+		}
+	}
+	return nil
+}
+
+// acquireLockAt creates an exclusive lock file at path, recording info. If the
+// file already exists, the recorded PID decides the outcome: when that process
+// is no longer running (a stale lock left by a killed instance) the lock is
+// reclaimed; when it is still alive the call fails so two watchers of the same
+// config never run at once. The returned function removes the lock on clean
+// shutdown.
+func acquireLockAt(path string, info watchLockInfo) (func(), error) {
+	release, err := createLockFile(path, info)
+	if err == nil {
+		return release, nil
+	}
+	if !os.IsExist(err) {
+		return nil, fmt.Errorf("could not create lock file %s: %w", path, err)
+	}
+
+	if held, ok := readLockInfo(path); ok && processAlive(held.PID) {
+		config := held.Config
+		if config == "" {
+			config = "unknown config"
+		}
+		return nil, fmt.Errorf(
+			"another instance of movelooper watch appears to be running (pid %d, %s)\n"+
+				"lock file: %s\n"+
+				"if no instance is running, delete the file manually and retry",
+			held.PID, config, path,
+		)
+	}
+
+	// Stale lock (dead or unreadable PID): reclaim it and try once more.
+	if err := os.Remove(path); err != nil {
+		return nil, fmt.Errorf("could not remove stale lock file %s: %w", path, err)
+	}
+	release, err = createLockFile(path, info)
+	if err != nil {
+		return nil, fmt.Errorf("could not reclaim stale lock file %s: %w", path, err)
+	}
+	return release, nil
+}
+
+// createLockFile creates path exclusively and writes info into it as JSON.
+func createLockFile(path string, info watchLockInfo) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600) //#nosec G304 -- fixed filename under the user's home (or OS temp dir as fallback)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := json.Marshal(info)
+	_, _ = f.Write(append(data, '\n'))
+	f.Close()
+	return func() { os.Remove(path) }, nil
+}
+
+// readLockInfo reads a lock file. Besides the JSON form it accepts the bare PID
+// written by older versions. ok is false when the file cannot be read or does
+// not hold a valid positive PID.
+func readLockInfo(path string) (info watchLockInfo, ok bool) {
+	data, err := os.ReadFile(path) //#nosec G304 -- fixed filename under the user's home (or OS temp dir as fallback)
+	if err != nil {
+		return watchLockInfo{}, false
+	}
+	text := strings.TrimSpace(string(data))
+	if strings.HasPrefix(text, "{") {
+		if err := json.Unmarshal([]byte(text), &info); err != nil || info.PID <= 0 {
+			return watchLockInfo{}, false
+		}
+		return info, true
+	}
+	pid, err := strconv.Atoi(text)
+	if err != nil || pid <= 0 {
+		return watchLockInfo{}, false
+	}
+	return watchLockInfo{PID: pid}, true
+}
+
+// processAlive reports whether a process with the given PID is currently running.
+func processAlive(pid int) bool {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false // Windows: FindProcess fails when the process does not exist
+	}
+	if runtime.GOOS == "windows" {
+		_ = proc.Release()
+		return true // Windows: a successful FindProcess means the process exists
+	}
+	// Unix: FindProcess always succeeds; probe liveness with signal 0.
+	err = proc.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/lucasassuncao/movelooper/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAcquireLockAt covers the PID-aware watch lock: a fresh lock records the
+// current PID, a lock held by a live process is rejected, and a stale lock left
+// by a dead (or unreadable) PID is reclaimed.
+func TestAcquireLockAt(t *testing.T) {
+	t.Parallel()
+
+	t.Run("creates lock with current pid and releases", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "test.lock")
+		release, err := acquireLockAt(path, watchLockInfo{PID: os.Getpid()})
+		require.NoError(t, err)
+		assert.FileExists(t, path)
+		info, ok := readLockInfo(path)
+		require.True(t, ok)
+		assert.Equal(t, os.Getpid(), info.PID)
+		release()
+		assert.NoFileExists(t, path)
+	})
+
+	t.Run("rejects a lock held by a live process", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "test.lock")
+		release, err := acquireLockAt(path, watchLockInfo{PID: os.Getpid()})
+		require.NoError(t, err)
+		defer release()
+
+		_, err = acquireLockAt(path, watchLockInfo{PID: os.Getpid()})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "appears to be running")
+	})
+
+	t.Run("reclaims a stale lock with a dead pid", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "test.lock")
+		require.NoError(t, os.WriteFile(path, []byte(strconv.Itoa(deadPID(t))+"\n"), 0o600))
+
+		release, err := acquireLockAt(path, watchLockInfo{PID: os.Getpid()})
+		require.NoError(t, err)
+		defer release()
+
+		info, ok := readLockInfo(path)
+		require.True(t, ok)
+		assert.Equal(t, os.Getpid(), info.PID, "stale lock should be reclaimed with our pid")
+	})
+
+	t.Run("reclaims a lock with an unreadable pid", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "test.lock")
+		require.NoError(t, os.WriteFile(path, []byte("not-a-pid"), 0o600))
+
+		release, err := acquireLockAt(path, watchLockInfo{PID: os.Getpid()})
+		require.NoError(t, err)
+		defer release()
+		assert.FileExists(t, path)
+	})
+}
+
+// deadPID starts a short-lived process and reaps it, returning a PID that is no
+// longer running (and will not be reused for the duration of the test). It runs
+// the test binary itself with a non-matching -test.run, which exits immediately.
+func deadPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^a_subtest_that_never_matches$")
+	require.NoError(t, cmd.Start())
+	_ = cmd.Wait()
+	return cmd.Process.Pid
+}
+
+// TestWatchSources verifies that only enabled categories contribute, paths
+// are cleaned, and duplicates collapse.
+func TestWatchSources(t *testing.T) {
+	t.Parallel()
+	cats := []*models.Category{
+		watchCategory("b", "/src/b/"),
+		watchCategory("a", "/src/a"),
+		watchCategory("a2", "/src/a"),
+		{Name: "off", Source: models.CategorySource{Path: "/src/off"}},
+	}
+	assert.Equal(t, []string{"/src/a", "/src/b"}, watchSources(cats))
+}
+
+// TestWatchSources_ResolvesSymlinksAndRelativePaths verifies that a source
+// reached through a symlink or spelled relative to the working directory
+// collapses onto the same directory, so it shares a lock key.
+func TestWatchSources_ResolvesSymlinksAndRelativePaths(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	dir := filepath.Join(root, "real")
+	require.NoError(t, os.Mkdir(dir, 0o755))
+	link := filepath.Join(root, "link")
+	if err := os.Symlink(dir, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	t.Chdir(root)
+
+	cats := []*models.Category{
+		watchCategory("real", dir),
+		watchCategory("link", link),
+		watchCategory("rel", "real"),
+	}
+	assert.Equal(t, []string{dir}, watchSources(cats))
+}
+
+// TestWatchLockKey verifies that the key depends on both the config path and
+// the watched sources.
+func TestWatchLockKey(t *testing.T) {
+	t.Parallel()
+	base := watchLockKey("/cfg/personal.yaml", []string{"/src/a"})
+	assert.Len(t, base, 16)
+	assert.Equal(t, base, watchLockKey("/cfg/personal.yaml", []string{"/src/a"}))
+	assert.NotEqual(t, base, watchLockKey("/cfg/work.yaml", []string{"/src/a"}))
+	assert.NotEqual(t, base, watchLockKey("/cfg/personal.yaml", []string{"/src/a", "/src/b"}))
+}
+
+func writeLockInfo(t *testing.T, path string, info watchLockInfo) {
+	t.Helper()
+	data, err := json.Marshal(info)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+// TestCheckOverlappingWatchers covers the cross-config check: a live watcher on
+// a shared source is refused with its config and PID, while disjoint sources,
+// dead holders, and our own lock are ignored.
+func TestCheckOverlappingWatchers(t *testing.T) {
+	t.Parallel()
+	dead := deadPID(t)
+	// A dead PID stands in for a stale lock; the parent process stands in for
+	// another live watcher.
+	alive := os.Getppid()
+
+	t.Run("overlap with a live watcher is refused", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		writeLockInfo(t, filepath.Join(dir, "watch-other.lock"),
+			watchLockInfo{PID: alive, Config: "/cfg/work.yaml", Sources: []string{"/share/in"}})
+
+		err := checkOverlappingWatchers(dir, filepath.Join(dir, "watch-mine.lock"), []string{"/home/dl", "/share/in"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "/cfg/work.yaml")
+		assert.Contains(t, err.Error(), strconv.Itoa(alive))
+	})
+
+	t.Run("disjoint sources coexist", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		writeLockInfo(t, filepath.Join(dir, "watch-other.lock"),
+			watchLockInfo{PID: alive, Config: "/cfg/work.yaml", Sources: []string{"/share/in"}})
+
+		assert.NoError(t, checkOverlappingWatchers(dir, filepath.Join(dir, "watch-mine.lock"), []string{"/home/dl"}))
+	})
+
+	t.Run("stale holder is ignored", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		writeLockInfo(t, filepath.Join(dir, "watch-other.lock"),
+			watchLockInfo{PID: dead, Config: "/cfg/work.yaml", Sources: []string{"/share/in"}})
+
+		assert.NoError(t, checkOverlappingWatchers(dir, filepath.Join(dir, "watch-mine.lock"), []string{"/share/in"}))
+	})
+
+	t.Run("live legacy lock is refused", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, legacyWatchLockFile), []byte(strconv.Itoa(alive)+"\n"), 0o600))
+
+		err := checkOverlappingWatchers(dir, filepath.Join(dir, "watch-mine.lock"), []string{"/home/dl"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "older movelooper watch")
+	})
+}
+
+// TestReadLockInfo verifies both the JSON lock format and the bare PID written
+// by older versions.
+func TestReadLockInfo(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	jsonLock := filepath.Join(dir, "new.lock")
+	want := watchLockInfo{PID: 42, Config: "/cfg/a.yaml", Sources: []string{"/src"}}
+	writeLockInfo(t, jsonLock, want)
+	got, ok := readLockInfo(jsonLock)
+	require.True(t, ok)
+	assert.Equal(t, want, got)
+
+	legacy := filepath.Join(dir, "old.lock")
+	require.NoError(t, os.WriteFile(legacy, []byte("42\n"), 0o600))
+	got, ok = readLockInfo(legacy)
+	require.True(t, ok)
+	assert.Equal(t, watchLockInfo{PID: 42}, got)
+}
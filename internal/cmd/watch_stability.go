@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lucasassuncao/movelooper/internal/models"
+)
+
+// stabilityTailSize is how much of a file's end the checksum mode hashes. A
+// writer appending to the file changes its tail, so hashing only the tail keeps
+// every poll cheap even for multi-gigabyte downloads.
+const stabilityTailSize = 64 << 10
+
+// stabilityPolicy is the effective stability rule for one file: the global
+// configuration.watch settings with its category's watch overrides applied.
+type stabilityPolicy struct {
+	mode  models.StabilityMode
+	delay time.Duration
+	polls int
+}
+
+// watchPolicyFor resolves the stability policy for files of cat. cat may be nil
+// (no category claims the file), in which case the global settings apply.
+func watchPolicyFor(w models.Watch, cat *models.Category) stabilityPolicy {
+	p := stabilityPolicy{mode: w.Stability, delay: w.Delay, polls: w.StablePolls}
+	if cat != nil && cat.Watch != nil {
+		if cat.Watch.Delay > 0 {
+			p.delay = cat.Watch.Delay
+		}
+		if cat.Watch.Stability != "" {
+			p.mode = cat.Watch.Stability
+		}
+	}
+	if p.mode == "" {
+		p.mode = models.StabilityEvents
+	}
+	if p.polls <= 0 {
+		p.polls = 1
+	}
+	return p
+}
+
+// minWatchDelay returns the shortest stability delay across the global setting
+// and every enabled category's override. The tracker pops files against this
+// threshold; files whose own delay has not elapsed yet are requeued.
+func minWatchDelay(m *models.Movelooper) time.Duration {
+	lowest := m.Config.Watch.Delay
+	for _, cat := range m.Categories {
+		if cat.IsEnabled() && cat.Watch != nil && cat.Watch.Delay > 0 && cat.Watch.Delay < lowest {
+			lowest = cat.Watch.Delay
+		}
+	}
+	return lowest
+}
+
+// stabilitySample is what the size and checksum modes compare between polls.
+// mtime is only recorded in size mode; tail only in checksum mode.
+type stabilitySample struct {
+	size  int64
+	mtime time.Time
+	tail  string
+}
+
+// stabilityProbe tracks consecutive identical samples of one pending file.
+type stabilityProbe struct {
+	last      stabilitySample
+	unchanged int
+}
+
+// sampleFile takes one stability sample of path for mode.
+func sampleFile(path string, mode models.StabilityMode) (stabilitySample, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return stabilitySample{}, err
+	}
+	s := stabilitySample{size: info.Size()}
+	switch mode {
+	case models.StabilitySize:
+		s.mtime = info.ModTime()
+	case models.StabilityChecksum:
+		tail, err := tailHash(path, info.Size())
+		if err != nil {
+			return stabilitySample{}, err
+		}
+		s.tail = tail
+	}
+	return s, nil
+}
+
+// tailHash returns the SHA-256 of the last stabilityTailSize bytes of path.
+func tailHash(path string, size int64) (string, error) {
+	f, err := os.Open(filepath.Clean(path)) //#nosec G304 -- path comes from a watched source directory
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if size > stabilityTailSize {
+		if _, err := f.Seek(size-stabilityTailSize, io.SeekStart); err != nil {
+			return "", err
+		}
+	}
+	h := sha256.New()
+	if _, err := io.CopyN(h, f, stabilityTailSize); err != nil && err != io.EOF {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// observe records sample for path and reports whether the file has now been
+// unchanged for polls consecutive polls. The first sample only sets the
+// baseline, so a file is never judged stable from a single look.
+func (cfg *watchConfig) observe(path string, sample stabilitySample, polls int) bool {
+	p, ok := cfg.probes[path]
+	if !ok {
+		cfg.probes[path] = &stabilityProbe{last: sample}
+		return false
+	}
+	if p.last == sample {
+		p.unchanged++
+	} else {
+		p.last = sample
+		p.unchanged = 0
+	}
+	return p.unchanged >= polls
+}
+
+// isStable reports whether a file popped from the tracker may be moved now
+// under its category's policy: its own delay must have elapsed and, in the
+// size and checksum modes, it must have passed the consecutive-poll check. A
+// file that cannot be sampled is handed to the move, whose error handling and
+// retries already cover unreadable or vanished files.
+func (cfg *watchConfig) isStable(m *models.Movelooper, pf pendingFile, now time.Time) bool {
+	policy := watchPolicyFor(m.Config.Watch, matchingWatchCategory(m, pf.path))
+	if now.Sub(pf.detected) <= policy.delay {
+		return false
+	}
+	if policy.mode == models.StabilityEvents {
+		return true
+	}
+	sample, err := sampleFile(pf.path, policy.mode)
+	if err != nil {
+		return true
+	}
+	return cfg.observe(pf.path, sample, policy.polls)
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lucasassuncao/movelooper/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func watchCategory(name, src string, exts ...string) *models.Category {
+	on := true
+	return &models.Category{Name: name, Enabled: &on, Source: models.CategorySource{Path: src, Extensions: exts}}
+}
+
+// TestWatchPolicyFor verifies that category overrides win over the global
+// watch settings and that zero values inherit them.
+func TestWatchPolicyFor(t *testing.T) {
+	t.Parallel()
+	global := models.Watch{Delay: 5 * time.Minute, Stability: models.StabilitySize, StablePolls: 3}
+
+	p := watchPolicyFor(global, nil)
+	assert.Equal(t, stabilityPolicy{mode: models.StabilitySize, delay: 5 * time.Minute, polls: 3}, p)
+
+	cat := &models.Category{Watch: &models.CategoryWatch{Delay: 2 * time.Second}}
+	p = watchPolicyFor(global, cat)
+	assert.Equal(t, 2*time.Second, p.delay)
+	assert.Equal(t, models.StabilitySize, p.mode, "unset stability inherits the global mode")
+
+	cat.Watch.Stability = models.StabilityEvents
+	assert.Equal(t, models.StabilityEvents, watchPolicyFor(global, cat).mode)
+}
+
+// TestMinWatchDelay verifies that the tracker threshold is the shortest delay
+// among the global setting and enabled categories.
+func TestMinWatchDelay(t *testing.T) {
+	t.Parallel()
+	shots := watchCategory("shots", "/src", "png")
+	shots.Watch = &models.CategoryWatch{Delay: 2 * time.Second}
+	off := &models.Category{Name: "off", Watch: &models.CategoryWatch{Delay: time.Second}}
+
+	m := &models.Movelooper{
+		Config:     models.Configuration{Watch: models.Watch{Delay: 5 * time.Minute}},
+		Categories: []*models.Category{shots, off},
+	}
+	assert.Equal(t, 2*time.Second, minWatchDelay(m), "disabled categories are ignored")
+}
+
+// TestWatchConfigObserve verifies the consecutive-poll counter: the first
+// sample is a baseline and any change restarts the count.
+func TestWatchConfigObserve(t *testing.T) {
+	t.Parallel()
+	cfg := &watchConfig{probes: make(map[string]*stabilityProbe)}
+	a := stabilitySample{size: 10}
+	b := stabilitySample{size: 20}
+
+	assert.False(t, cfg.observe("/f", a, 2), "baseline")
+	assert.False(t, cfg.observe("/f", a, 2))
+	assert.False(t, cfg.observe("/f", b, 2), "size changed: count restarts")
+	assert.False(t, cfg.observe("/f", b, 2))
+	assert.True(t, cfg.observe("/f", b, 2))
+}
+
+// TestSampleFile_Checksum verifies that the checksum mode notices a change in
+// the file's tail even when the size stays the same.
+func TestSampleFile_Checksum(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "big.bin")
+	data := make([]byte, stabilityTailSize+100)
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	first, err := sampleFile(path, models.StabilityChecksum)
+	require.NoError(t, err)
+	again, err := sampleFile(path, models.StabilityChecksum)
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+
+	data[len(data)-1] = 1
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	changed, err := sampleFile(path, models.StabilityChecksum)
+	require.NoError(t, err)
+	assert.Equal(t, first.size, changed.size)
+	assert.NotEqual(t, first.tail, changed.tail)
+}
+
+// TestIsStable covers the per-category delay and the size mode's poll check.
+func TestIsStable(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	png := filepath.Join(dir, "shot.png")
+	iso := filepath.Join(dir, "disk.iso")
+	require.NoError(t, os.WriteFile(png, []byte("x"), 0o644))
+	require.NoError(t, os.WriteFile(iso, []byte("x"), 0o644))
+
+	shots := watchCategory("shots", dir, "png")
+	shots.Watch = &models.CategoryWatch{Delay: 2 * time.Second, Stability: models.StabilityEvents}
+	downloads := watchCategory("downloads", dir, "iso")
+
+	m := &models.Movelooper{
+		Config: models.Configuration{Watch: models.Watch{
+			Delay: time.Minute, Stability: models.StabilitySize, StablePolls: 1,
+		}},
+		Categories: []*models.Category{shots, downloads},
+	}
+	cfg := &watchConfig{probes: make(map[string]*stabilityProbe)}
+	now := time.Now()
+
+	assert.True(t, cfg.isStable(m, pendingFile{path: png, detected: now.Add(-3 * time.Second)}, now))
+	assert.False(t, cfg.isStable(m, pendingFile{path: iso, detected: now.Add(-3 * time.Second)}, now),
+		"global delay applies to the downloads category")
+
+	old := pendingFile{path: iso, detected: now.Add(-2 * time.Minute)}
+	assert.False(t, cfg.isStable(m, old, now), "first size sample is only a baseline")
+	assert.True(t, cfg.isStable(m, old, now), "unchanged on the next poll")
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lucasassuncao/movelooper/internal/models"
+)
+
+const (
+	watchStateVersion = 1
+	// watchStateSaveInterval is how often the ticker loop snapshots the queue,
+	// bounding how much stability progress a crash (as opposed to a clean
+	// shutdown, which always saves) can lose.
+	watchStateSaveInterval = time.Minute
+)
+
+// watchState is the on-disk form of the watch tracker: the pending queue with
+// each file's last event time and retry count, plus the parked files that
+// exhausted their retries.
+type watchState struct {
+	Version int                `json:"version"`
+	SavedAt time.Time          `json:"saved_at"`
+	Pending []watchStateEntry  `json:"pending"`
+	Parked  []watchStateParked `json:"parked,omitempty"`
+}
+
+type watchStateEntry struct {
+	Path      string    `json:"path"`
+	LastEvent time.Time `json:"last_event"`
+	Retries   int       `json:"retries,omitempty"`
+}
+
+type watchStateParked struct {
+	Path string `json:"path"`
+}
+
+// watchStatePath returns the state file for the watcher with lock key key,
+// next to its lock, so concurrent watchers of different configs keep separate
+// queues.
+func watchStatePath(key string) string {
+	return filepath.Join(watchLockDir(), "watch-state-"+key+".json")
+}
+
+// captureWatchState builds a snapshot of the tracker and the retry counts.
+// retries is owned by the ticker goroutine, so this must run on it (or after
+// it has stopped).
+func captureWatchState(tracker *fileTracker, retries map[string]int, now time.Time) watchState {
+	pending, parked := tracker.snapshot()
+	st := watchState{Version: watchStateVersion, SavedAt: now, Pending: make([]watchStateEntry, 0, len(pending))}
+	for _, p := range pending {
+		st.Pending = append(st.Pending, watchStateEntry{Path: p.path, LastEvent: p.detected, Retries: retries[p.path]})
+	}
+	for _, p := range parked {
+		st.Parked = append(st.Parked, watchStateParked{Path: p})
+	}
+	return st
+}
+
+// saveWatchState writes st to path atomically (temp file + rename), so a crash
+// mid-write leaves the previous snapshot intact.
+func saveWatchState(path string, st watchState) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil { //#nosec G304 -- fixed filename under the user's home (or OS temp dir as fallback)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadWatchState reads a snapshot from path. A missing file yields an empty
+// state and no error; a snapshot from an unknown version is rejected.
+func loadWatchState(path string) (watchState, error) {
+	data, err := os.ReadFile(path) //#nosec G304 -- fixed filename under the user's home (or OS temp dir as fallback)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return watchState{}, nil
+		}
+		return watchState{}, err
+	}
+	var st watchState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return watchState{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if st.Version != watchStateVersion {
+		return watchState{}, fmt.Errorf("unsupported watch state version %d in %s", st.Version, path)
+	}
+	return st, nil
+}
+
+// restoreWatchState reconciles a snapshot against disk and loads what is still
+// valid into the tracker and retries map: entries whose file is gone, or whose
+// directory is no longer a watched source, are dropped. Pending files keep
+// their recorded event time, so a restart does not reset their stability
+// clock. It returns how many pending and parked entries were restored.
+func restoreWatchState(st watchState, m *models.Movelooper, tracker *fileTracker, retries map[string]int) (pending, parked int) {
+	watched := make(map[string]bool, len(m.Categories))
+	for _, cat := range m.Categories {
+		if cat.IsEnabled() {
+			watched[filepath.Clean(cat.Source.Path)] = true
+		}
+	}
+	stillValid := func(path string) bool {
+		if !watched[filepath.Clean(filepath.Dir(path))] {
+			return false
+		}
+		info, err := os.Lstat(path)
+		return err == nil && info.Mode().IsRegular()
+	}
+
+	for _, p := range st.Parked {
+		if stillValid(p.Path) {
+			tracker.park(p.Path)
+			parked++
+		}
+	}
+	for _, e := range st.Pending {
+		if !stillValid(e.Path) || tracker.isParked(e.Path) {
+			continue
+		}
+		tracker.touch(e.Path, e.LastEvent)
+		if e.Retries > 0 {
+			retries[e.Path] = e.Retries
+		}
+		pending++
+	}
+	return pending, parked
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lucasassuncao/movelooper/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchState_SaveLoadRoundTrip(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "state", "watch-state.json")
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tr := newFileTracker()
+	tr.touch("/src/a.jpg", now.Add(-2*time.Minute))
+	tr.touch("/src/b.jpg", now.Add(-1*time.Minute))
+	tr.park("/src/stuck.jpg")
+	retries := map[string]int{"/src/b.jpg": 2}
+
+	require.NoError(t, saveWatchState(path, captureWatchState(tr, retries, now)))
+	st, err := loadWatchState(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, now, st.SavedAt)
+	assert.Equal(t, []watchStateEntry{
+		{Path: "/src/a.jpg", LastEvent: now.Add(-2 * time.Minute)},
+		{Path: "/src/b.jpg", LastEvent: now.Add(-1 * time.Minute), Retries: 2},
+	}, st.Pending)
+	assert.Equal(t, []watchStateParked{{Path: "/src/stuck.jpg"}}, st.Parked)
+}
+
+func TestLoadWatchState(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing file is an empty state", func(t *testing.T) {
+		t.Parallel()
+		st, err := loadWatchState(filepath.Join(t.TempDir(), "nope.json"))
+		require.NoError(t, err)
+		assert.Empty(t, st.Pending)
+	})
+	t.Run("unknown version is rejected", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "watch-state.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"version":99}`), 0o600))
+		_, err := loadWatchState(path)
+		require.Error(t, err)
+	})
+	t.Run("malformed file is an error", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "watch-state.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{`), 0o600))
+		_, err := loadWatchState(path)
+		require.Error(t, err)
+	})
+}
+
+// TestRestoreWatchState covers reconciliation against disk: surviving entries
+// keep their event time and retry count, parked files stay parked, and entries
+// for deleted files or unwatched directories are dropped.
+func TestRestoreWatchState(t *testing.T) {
+	t.Parallel()
+	src := t.TempDir()
+	other := t.TempDir()
+	keep := filepath.Join(src, "keep.jpg")
+	stuck := filepath.Join(src, "stuck.jpg")
+	unwatched := filepath.Join(other, "x.jpg")
+	for _, p := range []string{keep, stuck, unwatched} {
+		require.NoError(t, os.WriteFile(p, []byte("x"), 0o600))
+	}
+
+	enabled := true
+	m := &models.Movelooper{Categories: []*models.Category{
+		{Name: "images", Enabled: &enabled, Source: models.CategorySource{Path: src}},
+	}}
+	lastEvent := time.Now().Add(-4 * time.Minute)
+	st := watchState{
+		Version: watchStateVersion,
+		Pending: []watchStateEntry{
+			{Path: keep, LastEvent: lastEvent, Retries: 1},
+			{Path: filepath.Join(src, "gone.jpg"), LastEvent: lastEvent},
+			{Path: unwatched, LastEvent: lastEvent},
+		},
+		Parked: []watchStateParked{{Path: stuck}, {Path: filepath.Join(src, "gone-too.jpg")}},
+	}
+
+	tr := newFileTracker()
+	retries := make(map[string]int)
+	pending, parked := restoreWatchState(st, m, tr, retries)
+
+	assert.Equal(t, 1, pending)
+	assert.Equal(t, 1, parked)
+	at, ok := tr.oldest()
+	require.True(t, ok)
+	assert.Equal(t, lastEvent, at, "restored file keeps its stability clock")
+	assert.Equal(t, map[string]int{keep: 1}, retries)
+	assert.True(t, tr.isParked(stuck))
+
+	// The startup scan must neither reset the restored clock nor requeue a parked file.
+	assert.False(t, tr.add(keep, time.Now()))
+	assert.False(t, tr.add(stuck, time.Now()))
+	at, _ = tr.oldest()
+	assert.Equal(t, lastEvent, at)
+}
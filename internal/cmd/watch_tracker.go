@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"container/heap"
+	"sort"
 	"sync"
 	"time"
 )
@@ -46,22 +47,29 @@ func (h *trackedHeap) Pop() any {
 // new event pushes a file's timestamp forward (re-heapified in O(log n)); the
 // ticker pops only the files whose stability delay has elapsed, instead of
 // scanning every tracked file each tick. Safe for concurrent use.
+//
+// parked holds files that exhausted their move retries. They are left out of
+// the queue (and of the startup scan) until a new filesystem event re-tracks
+// them, so a file that keeps failing is not retried forever across restarts.
 type fileTracker struct {
-	mu    sync.Mutex
-	heap  trackedHeap
-	index map[string]*trackedFile
+	mu     sync.Mutex
+	heap   trackedHeap
+	index  map[string]*trackedFile
+	parked map[string]bool
 }
 
 func newFileTracker() *fileTracker {
-	return &fileTracker{index: make(map[string]*trackedFile)}
+	return &fileTracker{index: make(map[string]*trackedFile), parked: make(map[string]bool)}
 }
 
 // touch records a create/write event for path at time at, adding it to the queue
-// or pushing an existing entry's timestamp forward. It reports whether the file
+// or pushing an existing entry's timestamp forward. A parked file is unparked,
+// since a new event is exactly what re-tracks it. It reports whether the file
 // was already being tracked.
 func (t *fileTracker) touch(path string, at time.Time) (alreadyTracked bool) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	delete(t.parked, path)
 	if tf, ok := t.index[path]; ok {
 		tf.detected = at
 		heap.Fix(&t.heap, tf.index)
@@ -73,14 +81,41 @@ func (t *fileTracker) touch(path string, at time.Time) (alreadyTracked bool) {
 	return false
 }
 
+// add queues path with event time at unless it is already tracked or parked,
+// leaving an existing entry's timestamp untouched. The startup scan uses it so
+// files restored from a saved state keep their stability clock. It reports
+// whether the file was added.
+func (t *fileTracker) add(path string, at time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.index[path]; ok || t.parked[path] {
+		return false
+	}
+	tf := &trackedFile{path: path, detected: at}
+	heap.Push(&t.heap, tf)
+	t.index[path] = tf
+	return true
+}
+
 // due removes and returns the paths whose most recent event is older than
 // threshold relative to now. Because the heap is ordered by detection time, it
 // stops at the first file that is not yet stable, so a file still receiving
 // events is never returned early.
 func (t *fileTracker) due(now time.Time, threshold time.Duration) []string {
+	var ready []string
+	for _, p := range t.duePending(now, threshold) {
+		ready = append(ready, p.path)
+	}
+	return ready
+}
+
+// duePending is due, but also returns each file's last event time so a caller
+// that decides the file is not ready yet can requeue it with add without
+// resetting its stability clock.
+func (t *fileTracker) duePending(now time.Time, threshold time.Duration) []pendingFile {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	var ready []string
+	var ready []pendingFile
 	for t.heap.Len() > 0 {
 		top := t.heap[0]
 		if now.Sub(top.detected) <= threshold {
@@ -88,7 +123,7 @@ func (t *fileTracker) due(now time.Time, threshold time.Duration) []string {
 		}
 		heap.Pop(&t.heap)
 		delete(t.index, top.path)
-		ready = append(ready, top.path)
+		ready = append(ready, pendingFile{path: top.path, detected: top.detected})
 	}
 	// Once the queue fully drains (the watcher's normal idle state between
 	// bursts) reclaim peak memory: the heap's backing array keeps its high-water
@@ -99,3 +134,86 @@ func (t *fileTracker) due(now time.Time, threshold time.Duration) []string {
 	}
 	return ready
 }
+
+// len returns the number of files currently waiting in the queue.
+func (t *fileTracker) len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.heap.Len()
+}
+
+// oldest returns the most recent event time of the file that has been quiet
+// the longest (the heap root). ok is false when the queue is empty.
+func (t *fileTracker) oldest() (at time.Time, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.heap.Len() == 0 {
+		return time.Time{}, false
+	}
+	return t.heap[0].detected, true
+}
+
+// park sets path aside after its retries were exhausted. It is dropped from the
+// queue if present and ignored by the startup scan until touch re-tracks it.
+func (t *fileTracker) park(path string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if tf, ok := t.index[path]; ok {
+		heap.Remove(&t.heap, tf.index)
+		delete(t.index, path)
+	}
+	t.parked[path] = true
+}
+
+// forget drops path from the queue and from the parked set, e.g. after it was
+// deleted or renamed away. It reports whether the path was known.
+func (t *fileTracker) forget(path string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	known := t.parked[path]
+	delete(t.parked, path)
+	if tf, ok := t.index[path]; ok {
+		heap.Remove(&t.heap, tf.index)
+		delete(t.index, path)
+		known = true
+	}
+	return known
+}
+
+// has reports whether path is queued or parked.
+func (t *fileTracker) has(path string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, queued := t.index[path]
+	return queued || t.parked[path]
+}
+
+// isParked reports whether path is parked.
+func (t *fileTracker) isParked(path string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.parked[path]
+}
+
+// pendingFile is a point-in-time copy of one queued file, used to persist the
+// queue across restarts.
+type pendingFile struct {
+	path     string
+	detected time.Time
+}
+
+// snapshot returns the queued files ordered oldest event first, and the parked
+// paths in no particular order.
+func (t *fileTracker) snapshot() (pending []pendingFile, parked []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pending = make([]pendingFile, 0, len(t.heap))
+	for _, tf := range t.heap {
+		pending = append(pending, pendingFile{path: tf.path, detected: tf.detected})
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].detected.Before(pending[j].detected) })
+	for p := range t.parked {
+		parked = append(parked, p)
+	}
+	return pending, parked
+}
@@ -59,3 +59,50 @@ func TestFileTracker(t *testing.T) {
 		assert.Equal(t, []string{"/b"}, tr.due(now, 5*time.Second))
 	})
 }
+
+func TestFileTracker_LenAndOldest(t *testing.T) {
+	t.Parallel()
+	tr := newFileTracker()
+	_, ok := tr.oldest()
+	assert.False(t, ok)
+	assert.Equal(t, 0, tr.len())
+
+	now := time.Now()
+	tr.touch("/new", now)
+	tr.touch("/old", now.Add(-time.Minute))
+	assert.Equal(t, 2, tr.len())
+	at, ok := tr.oldest()
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(-time.Minute), at)
+}
+
+func TestFileTracker_Park(t *testing.T) {
+	t.Parallel()
+	tr := newFileTracker()
+	now := time.Now()
+	tr.touch("/f", now.Add(-10*time.Second))
+	tr.park("/f")
+	assert.True(t, tr.isParked("/f"))
+	assert.Nil(t, tr.due(now, 5*time.Second), "a parked file leaves the queue")
+
+	// a new event re-tracks and unparks the file
+	assert.False(t, tr.touch("/f", now.Add(-10*time.Second)))
+	assert.False(t, tr.isParked("/f"))
+	assert.Equal(t, []string{"/f"}, tr.due(now, 5*time.Second))
+}
+
+func TestFileTracker_Forget(t *testing.T) {
+	t.Parallel()
+	tr := newFileTracker()
+	now := time.Now()
+	tr.touch("/a", now.Add(-10*time.Second))
+	tr.touch("/b", now.Add(-10*time.Second))
+	tr.park("/c")
+
+	assert.True(t, tr.forget("/a"))
+	assert.False(t, tr.has("/a"))
+	assert.True(t, tr.forget("/c"), "parked files are forgotten too")
+	assert.False(t, tr.isParked("/c"))
+	assert.False(t, tr.forget("/unknown"))
+	assert.Equal(t, []string{"/b"}, tr.due(now, 5*time.Second))
+}
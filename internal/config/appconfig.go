@@ -1,15 +1,21 @@
 package config
 
 import (
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/knadh/koanf/v2"
 	"github.com/lucasassuncao/movelooper/internal/models"
+	"github.com/lucasassuncao/movelooper/internal/tokens"
 )
 
 const defaultHistoryLimit = 100
 const defaultWatchDelay = 5 * time.Minute
 const defaultPollInterval = 5 * time.Second
+const defaultStablePolls = 3
+const defaultBatchIdle = time.Minute
 
 // LoadConfig reads the application-level settings from k and returns a
 // fully populated Configuration. It must be called after InitConfig has
@@ -28,13 +34,21 @@ func LoadConfig(k *koanf.Koanf) models.Configuration {
 		Watch: models.Watch{
 			Delay:        k.Duration("configuration.watch.delay"),
 			PollInterval: k.Duration("configuration.watch.poll-interval"),
+			Stability:    models.StabilityMode(k.String("configuration.watch.stability")),
+			StablePolls:  k.Int("configuration.watch.stable-polls"),
+			Batching:     models.BatchingMode(k.String("configuration.watch.batching")),
+			BatchIdle:    k.Duration("configuration.watch.batch-idle"),
 		},
 		History: models.History{
-			Limit:   k.Int("configuration.history.limit"),
-			File:    k.String("configuration.history.file"),
-			Enabled: historyEnabled(k),
+			Limit:        k.Int("configuration.history.limit"),
+			File:         k.String("configuration.history.file"),
+			Enabled:      historyEnabled(k),
+			KeepReplaced: k.Bool("configuration.history.keep-replaced"),
+			Hash:         k.Bool("configuration.history.hash"),
 		},
 		Defaults: loadDefaults(k),
+		Timezone: k.String("configuration.timezone"),
+		Locale:   k.String("configuration.locale"),
 	}
 
 	if cfg.Watch.Delay == 0 {
@@ -43,6 +57,18 @@ func LoadConfig(k *koanf.Koanf) models.Configuration {
 	if cfg.Watch.PollInterval == 0 {
 		cfg.Watch.PollInterval = defaultPollInterval
 	}
+	if cfg.Watch.Stability == "" {
+		cfg.Watch.Stability = models.StabilityEvents
+	}
+	if cfg.Watch.StablePolls <= 0 {
+		cfg.Watch.StablePolls = defaultStablePolls
+	}
+	if cfg.Watch.Batching == "" {
+		cfg.Watch.Batching = models.BatchingFile
+	}
+	if cfg.Watch.BatchIdle <= 0 {
+		cfg.Watch.BatchIdle = defaultBatchIdle
+	}
 	if cfg.History.Limit == 0 {
 		cfg.History.Limit = defaultHistoryLimit
 	}
@@ -71,3 +97,22 @@ func loadDefaults(k *koanf.Koanf) *models.Defaults {
 		OrganizeBy:       k.String("configuration.defaults.organize-by"),
 	}
 }
+
+// ApplyDateSettings checks configuration.timezone and configuration.locale
+// and hands them to the tokens package, which renders every date token in
+// that zone and language.
+func ApplyDateSettings(cfg models.Configuration) error {
+	if cfg.Locale != "" && !slices.Contains(tokens.Locales, cfg.Locale) {
+		return fmt.Errorf("invalid configuration.locale %q - must be one of: %s", cfg.Locale, strings.Join(tokens.Locales, ", "))
+	}
+	var loc *time.Location
+	if cfg.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(cfg.Timezone); err != nil {
+			return fmt.Errorf("invalid configuration.timezone %q: %w", cfg.Timezone, err)
+		}
+	}
+	tokens.SetTimezone(loc)
+	tokens.SetLocale(cfg.Locale)
+	return nil
+}
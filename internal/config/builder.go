@@ -85,6 +85,15 @@ func NewApp(m *models.Movelooper, configPath string, opts ...Option) (retErr err
 
 	if o.loadConfig {
 		m.Config = LoadConfig(k)
+		if !ValidStabilityMode(m.Config.Watch.Stability) {
+			return fmt.Errorf("invalid configuration.watch.stability %q - must be events, size, or checksum", m.Config.Watch.Stability)
+		}
+		if !ValidBatchingMode(m.Config.Watch.Batching) {
+			return fmt.Errorf("invalid configuration.watch.batching %q - must be file, daemon, hourly, or idle", m.Config.Watch.Batching)
+		}
+		if err := ApplyDateSettings(m.Config); err != nil {
+			return err
+		}
 	}
 
 	if o.loadCategories {
@@ -108,6 +117,9 @@ func NewApp(m *models.Movelooper, configPath string, opts ...Option) (retErr err
 		if hist, err := history.NewHistory(histPath, m.Config.History.Limit); err != nil {
 			m.Logger.Warn("failed to initialize history tracking", m.Logger.Args("error", err.Error()))
 		} else {
+			if m.Config.History.KeepReplaced {
+				hist.SetBackupDir(defaultBackupDir())
+			}
 			m.History = hist
 		}
 	}
@@ -137,6 +149,16 @@ func defaultHistoryFilePath() string {
 	return filepath.Join(homeDir, ".movelooper", "history", "movelooper.json")
 }
 
+// defaultBackupDir is the root of the replaced-file store used when
+// history.keep-replaced is on.
+func defaultBackupDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "movelooper", "backups")
+	}
+	return filepath.Join(homeDir, ".movelooper", "backups")
+}
+
 func validateSourceDirs(m *models.Movelooper) {
 	for _, cat := range m.Categories {
 		if !cat.IsEnabled() {
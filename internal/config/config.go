@@ -5,14 +5,20 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/rawbytes"
 	"github.com/knadh/koanf/v2"
 	"github.com/lucasassuncao/movelooper/internal/filters"
 	"github.com/lucasassuncao/movelooper/internal/models"
+	"github.com/lucasassuncao/movelooper/internal/namedate"
 	"github.com/lucasassuncao/movelooper/internal/tokens"
 )
 
@@ -46,11 +52,25 @@ func InitConfig(k *koanf.Koanf, path string) error {
 // regex patterns. Returns an error if any category is misconfigured.
 func UnmarshalConfig(k *koanf.Koanf) ([]*models.Category, error) {
 	var categories []*models.Category
-	if err := k.UnmarshalWithConf("categories", &categories, koanf.UnmarshalConf{Tag: "mapstructure"}); err != nil {
+	conf := koanf.UnmarshalConf{Tag: "mapstructure", DecoderConfig: &mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.TextUnmarshallerHookFunc(),
+			timeToStringHook),
+		WeaklyTypedInput: true,
+	}}
+	if err := k.UnmarshalWithConf("categories", &categories, conf); err != nil {
 		return nil, fmt.Errorf("unable to decode categories: %w", err)
 	}
 
 	for _, cat := range categories {
+		// Expand paths first: validation binds the source path into path
+		// filters, which match against the expanded paths the scanner yields.
+		cat.Source.Path = ExpandTilde(cat.Source.Path)
+		cat.Destination.Path = ExpandTilde(cat.Destination.Path)
+		for i, p := range cat.Source.ExcludePaths {
+			cat.Source.ExcludePaths[i] = ExpandTilde(p)
+		}
 		if err := validateCategory(cat); err != nil {
 			return nil, err
 		}
@@ -59,16 +79,26 @@ func UnmarshalConfig(k *koanf.Koanf) ([]*models.Category, error) {
 		for i, ext := range cat.Source.Extensions {
 			cat.Source.Extensions[i] = strings.ToLower(ext)
 		}
-		cat.Source.Path = ExpandTilde(cat.Source.Path)
-		cat.Destination.Path = ExpandTilde(cat.Destination.Path)
-		for i, p := range cat.Source.ExcludePaths {
-			cat.Source.ExcludePaths[i] = ExpandTilde(p)
-		}
 	}
 
 	return categories, nil
 }
 
+// timeToStringHook turns the time.Time that YAML makes of an unquoted date or
+// timestamp back into text for string fields such as date.after, so
+// "after: 2024-01-01" works without quotes. A bare date comes back as
+// YYYY-MM-DD, anything else as RFC 3339.
+func timeToStringHook(_ reflect.Type, to reflect.Type, data any) (any, error) {
+	t, ok := data.(time.Time)
+	if !ok || to.Kind() != reflect.String {
+		return data, nil
+	}
+	if t.Location() == time.UTC && t.Equal(t.Truncate(24*time.Hour)) {
+		return t.Format("2006-01-02"), nil
+	}
+	return t.Format(time.RFC3339Nano), nil
+}
+
 // applyCategoryDefaults fills each category's empty destination fields from the
 // global defaults block. Per-category values always take precedence. The default
 // values are validated here, since they bypass the per-category validation that
@@ -113,6 +143,11 @@ func applyCategoryDefaults(cats []*models.Category, d *models.Defaults) error {
 				return err
 			}
 		}
+		// An inherited organize-by may reference capture groups the
+		// category's regex does not have.
+		if err := validateMatchGroups(cat); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -175,6 +210,24 @@ var validConflictStrategies = map[models.ConflictStrategy]bool{
 	models.ConflictStrategySmaller:   true,
 }
 
+// ValidStabilityMode reports whether s is an accepted watch.stability value.
+func ValidStabilityMode(s models.StabilityMode) bool {
+	switch s {
+	case models.StabilityEvents, models.StabilitySize, models.StabilityChecksum:
+		return true
+	}
+	return false
+}
+
+// ValidBatchingMode reports whether b is an accepted watch.batching value.
+func ValidBatchingMode(b models.BatchingMode) bool {
+	switch b {
+	case models.BatchingFile, models.BatchingDaemon, models.BatchingHourly, models.BatchingIdle:
+		return true
+	}
+	return false
+}
+
 // validateCategory validates a single category and pre-compiles its filter.
 func validateCategory(cat *models.Category) error {
 	if cat.Name == "" {
@@ -221,11 +274,101 @@ func validateCategory(cat *models.Category) error {
 		}
 	}
 
+	for key := range cat.Destination.TagDefaults {
+		if !slices.Contains(tokens.TagDefaultKeys, key) {
+			return fmt.Errorf("category %q: unknown destination.tag-defaults key %q - must be one of: %s", cat.Name, key, strings.Join(tokens.TagDefaultKeys, ", "))
+		}
+	}
+
 	if err := validateHooks(cat.Name, cat.Hooks); err != nil {
 		return err
 	}
 
-	return validateFilter(cat.Name, &cat.Source.Filter)
+	if cat.Watch != nil {
+		if cat.Watch.Delay < 0 {
+			return fmt.Errorf("category %q: watch.delay must not be negative", cat.Name)
+		}
+		if cat.Watch.Stability != "" && !ValidStabilityMode(cat.Watch.Stability) {
+			return fmt.Errorf("category %q: invalid watch.stability %q - must be events, size, or checksum", cat.Name, cat.Watch.Stability)
+		}
+	}
+
+	cat.Source.CompiledNameDatePatterns = nil
+	for _, expr := range cat.Source.NameDatePatterns {
+		re, err := namedate.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("category %q: invalid source.name-date-patterns entry %q: %w", cat.Name, expr, err)
+		}
+		cat.Source.CompiledNameDatePatterns = append(cat.Source.CompiledNameDatePatterns, re)
+	}
+
+	for _, pattern := range cat.Source.ExcludePatterns {
+		if err := filters.ValidateGlob(pattern); err != nil {
+			return fmt.Errorf("category %q: invalid source.exclude-patterns entry %q: %w", cat.Name, pattern, err)
+		}
+	}
+
+	if err := validateFilter(cat.Name, &cat.Source.Filter); err != nil {
+		return err
+	}
+	bindSource(&cat.Source.Filter, &cat.Source)
+	return validateMatchGroups(cat)
+}
+
+// bindSource hands the parts of the category's source that filters need to
+// every filter in f: the name date patterns to age and date filters, for
+// from: name, and the source path to path filters.
+func bindSource(f *models.CategoryFilter, src *models.CategorySource) {
+	if f.Path != nil {
+		f.Path.Root = src.Path
+	}
+	if f.Age != nil {
+		f.Age.NamePatterns = src.CompiledNameDatePatterns
+	}
+	if f.Date != nil {
+		f.Date.NamePatterns = src.CompiledNameDatePatterns
+	}
+	for _, children := range [][]models.CategoryFilter{f.Any, f.All, f.Not} {
+		for i := range children {
+			bindSource(&children[i], src)
+		}
+	}
+}
+
+// validateMatchGroups checks that every {match:GROUP} token in the category's
+// rename and organize-by templates names a capture group of a match.regex in
+// its filter. It runs after validateFilter, which compiles the regexes.
+func validateMatchGroups(cat *models.Category) error {
+	regexes := filters.CaptureRegexes(cat.Source.Filter)
+	for _, tmpl := range []struct{ field, template string }{
+		{"rename", cat.Destination.Rename},
+		{"organize-by", cat.Destination.OrganizeBy},
+	} {
+		for _, group := range tokens.MatchGroups(tmpl.template) {
+			if len(regexes) == 0 {
+				return fmt.Errorf("category %q: %s uses {match:%s} but source.filter has no match.regex", cat.Name, tmpl.field, group)
+			}
+			if !hasCaptureGroup(regexes, group) {
+				return fmt.Errorf("category %q: %s uses {match:%s} but no match.regex in source.filter has that capture group", cat.Name, tmpl.field, group)
+			}
+		}
+	}
+	return nil
+}
+
+// hasCaptureGroup reports whether one of regexes has the capture group named
+// or numbered group; group 0 is the whole match.
+func hasCaptureGroup(regexes []*regexp.Regexp, group string) bool {
+	n, err := strconv.Atoi(group)
+	for _, re := range regexes {
+		if err == nil && n <= re.NumSubexp() {
+			return true
+		}
+		if err != nil && re.SubexpIndex(group) >= 0 {
+			return true
+		}
+	}
+	return false
 }
 
 // validateHooks validates both before and after hooks for a category.
@@ -260,7 +403,7 @@ func validateHook(catName, position string, hook *models.CategoryHook) error {
 // hasDirectFilterFields reports whether f has any direct leaf fields set.
 // not is excluded: it is a modifier that can coexist with any/all.
 func hasDirectFilterFields(f *models.CategoryFilter) bool {
-	return f.Match != nil || f.Age != nil || f.Size != nil || f.Mime != ""
+	return f.Match != nil || f.Path != nil || f.Age != nil || f.Date != nil || f.Size != nil || f.Mime != "" || f.Exif != nil || f.Tags != nil || f.Video != nil
 }
 
 // validateFilter validates a filter node recursively.
@@ -347,11 +490,21 @@ func validateLeafFilter(catName string, f *models.CategoryFilter) error {
 			return err
 		}
 	}
+	if f.Path != nil {
+		if err := validatePathFilter(catName, f.Path); err != nil {
+			return err
+		}
+	}
 	if f.Age != nil {
 		if err := validateAgeFilter(catName, f.Age); err != nil {
 			return err
 		}
 	}
+	if f.Date != nil {
+		if err := validateDateFilter(catName, f.Date); err != nil {
+			return err
+		}
+	}
 	if f.Size != nil {
 		if err := validateSizeFilter(catName, f.Size); err != nil {
 			return err
@@ -362,6 +515,21 @@ func validateLeafFilter(catName string, f *models.CategoryFilter) error {
 			return fmt.Errorf("category %q: invalid filter mime %q: %w", catName, f.Mime, err)
 		}
 	}
+	if f.Exif != nil {
+		if err := validateExifFilter(catName, f.Exif); err != nil {
+			return err
+		}
+	}
+	if f.Tags != nil {
+		if err := validateTagsFilter(catName, f.Tags); err != nil {
+			return err
+		}
+	}
+	if f.Video != nil {
+		if err := validateVideoFilter(catName, f.Video); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -405,11 +573,99 @@ func compileMatchRegex(catName string, m *models.MatchFilter) error {
 	return nil
 }
 
-// validateAgeFilter checks that age.min <= age.max.
+// validatePathFilter validates a PathFilter: regex and glob are mutually
+// exclusive, the glob must parse and the regex is compiled, with (?i) added
+// when not case-sensitive.
+func validatePathFilter(catName string, p *models.PathFilter) error {
+	if p.Regex != "" && p.Glob != "" {
+		return fmt.Errorf("category %q: path.regex and path.glob are mutually exclusive", catName)
+	}
+	if p.Regex == "" && p.Glob == "" {
+		return fmt.Errorf("category %q: path needs a regex or a glob", catName)
+	}
+	if p.Glob != "" {
+		if err := filters.ValidateGlob(p.Glob); err != nil {
+			return fmt.Errorf("category %q: path: %w", catName, err)
+		}
+		return nil
+	}
+	pattern := p.Regex
+	if !p.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("category %q: invalid path.regex: %w", catName, err)
+	}
+	p.CompiledRegex = compiled
+	return nil
+}
+
+// validDateFields is the set of accepted values for age.field and
+// date.field: the file system times.
+var validDateFields = map[models.DateSource]bool{
+	"":                     true, // empty = default (mtime)
+	models.DateSourceMtime: true,
+	models.DateSourceAtime: true,
+	models.DateSourceCtime: true,
+	models.DateSourceBirth: true,
+}
+
+// validDateSources is the set of accepted values for age.from and date.from.
+var validDateSources = map[models.DateSource]bool{
+	"":                     true, // empty = default (mtime)
+	models.DateSourceMtime: true,
+	models.DateSourceName:  true,
+	models.DateSourceBirth: true,
+	models.DateSourceExif:  true,
+}
+
+// validateAgeFilter checks that age.min <= age.max and the date it measures.
 func validateAgeFilter(catName string, a *models.AgeFilter) error {
 	if a.Min != 0 && a.Max != 0 && a.Min > a.Max {
 		return fmt.Errorf("category %q: age.min (%s) must be less than age.max (%s)", catName, a.Min, a.Max)
 	}
+	return validateDateChoice(catName, "age", a.Field, a.From)
+}
+
+// validateDateFilter checks the date it compares and that after and before
+// parse. Two absolute bounds must be ordered; calendar expressions move with
+// the clock, so a pair involving one is not checked.
+func validateDateFilter(catName string, d *models.DateFilter) error {
+	if d.After == "" && d.Before == "" {
+		return fmt.Errorf("category %q: date needs after, before, or both", catName)
+	}
+	for _, bound := range []struct{ field, value string }{
+		{"after", d.After},
+		{"before", d.Before},
+	} {
+		if bound.value == "" {
+			continue
+		}
+		if _, err := filters.ParseDateBound(bound.value, time.Now()); err != nil {
+			return fmt.Errorf("category %q: invalid date.%s: %w", catName, bound.field, err)
+		}
+	}
+	after, errAfter := filters.ParseDate(d.After)
+	before, errBefore := filters.ParseDate(d.Before)
+	if errAfter == nil && errBefore == nil && !after.Before(before) {
+		return fmt.Errorf("category %q: date.after (%s) must be before date.before (%s)", catName, d.After, d.Before)
+	}
+	return validateDateChoice(catName, "date", d.Field, d.From)
+}
+
+// validateDateChoice checks the field and from keys of the age or date
+// filter, which choose the date it looks at.
+func validateDateChoice(catName, filter string, field, from models.DateSource) error {
+	if !validDateFields[field] {
+		return fmt.Errorf("category %q: invalid %s.field %q - must be mtime, atime, ctime, or birth", catName, filter, field)
+	}
+	if !validDateSources[from] {
+		return fmt.Errorf("category %q: invalid %s.from %q - must be mtime, name, birth, or exif", catName, filter, from)
+	}
+	if field != "" && from != "" {
+		return fmt.Errorf("category %q: %s.field and %s.from are mutually exclusive", catName, filter, filter)
+	}
 	return nil
 }
 
@@ -435,6 +691,63 @@ func validateSizeFilter(catName string, s *models.SizeFilter) error {
 	return nil
 }
 
+// validateExifFilter checks the camera glob and parses the taken-after and
+// taken-before dates, which must be ordered.
+func validateExifFilter(catName string, e *models.ExifFilter) error {
+	if e.Camera != "" {
+		if err := filters.ValidateGlob(e.Camera); err != nil {
+			return fmt.Errorf("category %q: invalid exif.camera: %w", catName, err)
+		}
+	}
+	var err error
+	if e.TakenAfter != "" {
+		if e.After, err = filters.ParseDate(e.TakenAfter); err != nil {
+			return fmt.Errorf("category %q: invalid exif.taken-after: %w", catName, err)
+		}
+	}
+	if e.TakenBefore != "" {
+		if e.Before, err = filters.ParseDate(e.TakenBefore); err != nil {
+			return fmt.Errorf("category %q: invalid exif.taken-before: %w", catName, err)
+		}
+	}
+	if !e.After.IsZero() && !e.Before.IsZero() && !e.After.Before(e.Before) {
+		return fmt.Errorf("category %q: exif.taken-after (%s) must be before exif.taken-before (%s)", catName, e.TakenAfter, e.TakenBefore)
+	}
+	return nil
+}
+
+// validateTagsFilter checks the artist, album and genre globs.
+func validateTagsFilter(catName string, t *models.TagsFilter) error {
+	for _, rule := range []struct{ field, glob string }{
+		{"artist", t.Artist},
+		{"album", t.Album},
+		{"genre", t.Genre},
+	} {
+		if rule.glob == "" {
+			continue
+		}
+		if err := filters.ValidateGlob(rule.glob); err != nil {
+			return fmt.Errorf("category %q: invalid tags.%s: %w", catName, rule.field, err)
+		}
+	}
+	return nil
+}
+
+// validateVideoFilter checks that the bounds are not negative and that
+// min-duration <= max-duration.
+func validateVideoFilter(catName string, v *models.VideoFilter) error {
+	if v.MinDuration < 0 || v.MaxDuration < 0 {
+		return fmt.Errorf("category %q: video.min-duration and video.max-duration must not be negative", catName)
+	}
+	if v.MinHeight < 0 {
+		return fmt.Errorf("category %q: video.min-height must not be negative", catName)
+	}
+	if v.MinDuration != 0 && v.MaxDuration != 0 && v.MinDuration > v.MaxDuration {
+		return fmt.Errorf("category %q: video.min-duration (%s) must be less than video.max-duration (%s)", catName, v.MinDuration, v.MaxDuration)
+	}
+	return nil
+}
+
 // ResolveConfigPath returns the absolute path to the config file.
 // If configPath is provided it is used directly (after verifying existence).
 // Otherwise it searches for movelooper.yaml in the executable directory and
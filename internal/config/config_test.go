@@ -408,11 +408,29 @@ var testLoadConfigTestCases = []testLoadConfig{
 		check: func(t *testing.T, cfg models.Configuration) {
 			assert.Equal(t, defaultWatchDelay, cfg.Watch.Delay)
 			assert.Equal(t, defaultPollInterval, cfg.Watch.PollInterval)
+			assert.Equal(t, models.StabilityEvents, cfg.Watch.Stability)
+			assert.Equal(t, defaultStablePolls, cfg.Watch.StablePolls)
+			assert.Equal(t, models.BatchingFile, cfg.Watch.Batching)
+			assert.Equal(t, defaultBatchIdle, cfg.Watch.BatchIdle)
 			assert.Equal(t, defaultHistoryLimit, cfg.History.Limit)
 			assert.True(t, cfg.History.Enabled, "history enabled by default")
+			assert.False(t, cfg.History.KeepReplaced, "replaced files are not kept by default")
+			assert.False(t, cfg.History.Hash, "destinations are not hashed by default")
 			assert.Nil(t, cfg.Defaults, "no defaults block when absent")
 		},
 	},
+	{
+		name: "timezone and locale",
+		yaml: `
+configuration:
+  timezone: America/Sao_Paulo
+  locale: pt
+`,
+		check: func(t *testing.T, cfg models.Configuration) {
+			assert.Equal(t, "America/Sao_Paulo", cfg.Timezone)
+			assert.Equal(t, "pt", cfg.Locale)
+		},
+	},
 	{
 		name: "history disabled and custom poll-interval",
 		yaml: `
@@ -427,6 +445,45 @@ configuration:
 			assert.False(t, cfg.History.Enabled)
 		},
 	},
+	{
+		name: "history keep-replaced",
+		yaml: `
+configuration:
+  history:
+    keep-replaced: true
+    hash: true
+`,
+		check: func(t *testing.T, cfg models.Configuration) {
+			assert.True(t, cfg.History.KeepReplaced)
+			assert.True(t, cfg.History.Hash)
+		},
+	},
+	{
+		name: "stability mode and stable-polls",
+		yaml: `
+configuration:
+  watch:
+    stability: checksum
+    stable-polls: 5
+`,
+		check: func(t *testing.T, cfg models.Configuration) {
+			assert.Equal(t, models.StabilityChecksum, cfg.Watch.Stability)
+			assert.Equal(t, 5, cfg.Watch.StablePolls)
+		},
+	},
+	{
+		name: "watch batching",
+		yaml: `
+configuration:
+  watch:
+    batching: hourly
+    batch-idle: 30s
+`,
+		check: func(t *testing.T, cfg models.Configuration) {
+			assert.Equal(t, models.BatchingHourly, cfg.Watch.Batching)
+			assert.Equal(t, 30*time.Second, cfg.Watch.BatchIdle)
+		},
+	},
 	{
 		name: "defaults block is read",
 		yaml: `
@@ -541,6 +598,22 @@ func TestValidateCategoryAction(t *testing.T) {
 	}
 }
 
+// TestValidateCategoryWatch covers the per-category watch overrides.
+func TestValidateCategoryWatch(t *testing.T) {
+	t.Parallel()
+	newCat := func(w *models.CategoryWatch) *models.Category {
+		return &models.Category{
+			Name:        "test",
+			Source:      models.CategorySource{Extensions: []string{"png"}},
+			Destination: models.CategoryDestination{Path: "/tmp/dst"},
+			Watch:       w,
+		}
+	}
+	assert.NoError(t, validateCategory(newCat(&models.CategoryWatch{Delay: 2 * time.Second, Stability: models.StabilitySize})))
+	assert.ErrorContains(t, validateCategory(newCat(&models.CategoryWatch{Stability: "mtime"})), "watch.stability")
+	assert.ErrorContains(t, validateCategory(newCat(&models.CategoryWatch{Delay: -time.Second})), "watch.delay")
+}
+
 // testValidateCategoryRename defines the structure for test cases of the validateCategory function
 // for the rename field, containing the rename template and an error expectation flag.
 type testValidateCategoryRename struct {
@@ -810,6 +883,13 @@ func TestApplyCategoryDefaults(t *testing.T) {
 		assert.Contains(t, err.Error(), "conflict-strategy")
 	})
 
+	t.Run("inherited organize-by must name the category's capture groups", func(t *testing.T) {
+		t.Parallel()
+		cats := []*models.Category{{Name: "plain"}}
+		err := applyCategoryDefaults(cats, &models.Defaults{OrganizeBy: "{match:client}"})
+		assert.ErrorContains(t, err, `category "plain": organize-by uses {match:client}`)
+	})
+
 	t.Run("invalid default organize-by errors", func(t *testing.T) {
 		t.Parallel()
 		err := applyCategoryDefaults(nil, &models.Defaults{OrganizeBy: "{nope}"})
@@ -907,20 +987,217 @@ func TestValidateCategory_Archive(t *testing.T) {
 	})
 }
 
-func TestValidateCategory_MimeFilter(t *testing.T) {
+// categoryWithFilter returns an enabled category from /src to /dst that takes
+// every extension through filter f, for tests that only vary the filter.
+func categoryWithFilter(f models.CategoryFilter) *models.Category {
 	enabled := true
-	base := func(mime string) *models.Category {
-		return &models.Category{
-			Name:    "c",
-			Enabled: &enabled,
-			Source: models.CategorySource{
-				Path:       "/src",
-				Extensions: []string{"all"},
-				Filter:     models.CategoryFilter{Mime: mime},
-			},
-			Destination: models.CategoryDestination{Path: "/dst"},
-		}
+	return &models.Category{
+		Name:    "c",
+		Enabled: &enabled,
+		Source: models.CategorySource{
+			Path:       "/src",
+			Extensions: []string{"all"},
+			Filter:     f,
+		},
+		Destination: models.CategoryDestination{Path: "/dst"},
+	}
+}
+
+func TestValidateCategory_MimeFilter(t *testing.T) {
+	assert.NoError(t, validateCategory(categoryWithFilter(models.CategoryFilter{Mime: "image/*"})))
+	require.Error(t, validateCategory(categoryWithFilter(models.CategoryFilter{Mime: "image/["})), "malformed glob is rejected")
+}
+
+func TestValidateCategory_ExifFilter(t *testing.T) {
+	base := func(e models.ExifFilter) *models.Category {
+		return categoryWithFilter(models.CategoryFilter{Exif: &e})
+	}
+	c := base(models.ExifFilter{Camera: "*EOS*", TakenAfter: "2024-06-01", TakenBefore: "2024-09-01T00:00:00Z"})
+	require.NoError(t, validateCategory(c))
+	assert.Equal(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local), c.Source.Filter.Exif.After)
+	assert.False(t, c.Source.Filter.Exif.Before.IsZero())
+
+	assert.ErrorContains(t, validateCategory(base(models.ExifFilter{Camera: "[bad"})), "exif.camera")
+	assert.ErrorContains(t, validateCategory(base(models.ExifFilter{TakenAfter: "June"})), "exif.taken-after")
+	assert.ErrorContains(t, validateCategory(base(models.ExifFilter{TakenAfter: "2024-09-01", TakenBefore: "2024-06-01"})), "must be before")
+}
+
+// TestUnmarshalConfig_UnquotedExifDates verifies that unquoted exif bounds,
+// which YAML reads as timestamps, load and parse.
+func TestUnmarshalConfig_UnquotedExifDates(t *testing.T) {
+	cats := unmarshalYAMLCategories(t, `
+categories:
+  - name: photos
+    enabled: true
+    source:
+      path: /src
+      extensions: [jpg]
+      filter:
+        exif:
+          taken-after: 2024-06-01
+          taken-before: 2024-09-01T10:00:00Z
+    destination:
+      path: /dst
+`)
+	require.Len(t, cats, 1)
+	e := cats[0].Source.Filter.Exif
+	require.NotNil(t, e)
+	assert.Equal(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local), e.After)
+	assert.True(t, e.Before.Equal(time.Date(2024, 9, 1, 10, 0, 0, 0, time.UTC)))
+}
+
+func TestValidateCategory_VideoFilter(t *testing.T) {
+	base := func(v models.VideoFilter) *models.Category {
+		return categoryWithFilter(models.CategoryFilter{Video: &v})
+	}
+	require.NoError(t, validateCategory(base(models.VideoFilter{MinDuration: time.Second, MaxDuration: time.Hour, MinHeight: 720})))
+	assert.ErrorContains(t, validateCategory(base(models.VideoFilter{MinDuration: time.Hour, MaxDuration: time.Second})), "must be less than")
+	assert.ErrorContains(t, validateCategory(base(models.VideoFilter{MinHeight: -1})), "video.min-height")
+	assert.ErrorContains(t, validateCategory(base(models.VideoFilter{MaxDuration: -time.Second})), "must not be negative")
+}
+
+func TestValidateCategory_Tags(t *testing.T) {
+	base := func(f models.TagsFilter, defaults map[string]string) *models.Category {
+		c := categoryWithFilter(models.CategoryFilter{Tags: &f})
+		c.Destination.TagDefaults = defaults
+		return c
+	}
+	require.NoError(t, validateCategory(base(models.TagsFilter{Artist: "Miles*", Genre: "*jazz*"}, map[string]string{"artist": "Various", "track": "0"})))
+	assert.ErrorContains(t, validateCategory(base(models.TagsFilter{Genre: "[bad"}, nil)), "tags.genre")
+	assert.ErrorContains(t, validateCategory(base(models.TagsFilter{}, map[string]string{"composer": "x"})), "tag-defaults key \"composer\"")
+}
+
+func TestValidateCategory_MatchGroups(t *testing.T) {
+	base := func(f models.CategoryFilter, rename, organizeBy string) *models.Category {
+		c := categoryWithFilter(f)
+		c.Destination.Rename, c.Destination.OrganizeBy = rename, organizeBy
+		return c
+	}
+	invoice := func() models.CategoryFilter {
+		return models.CategoryFilter{Match: &models.MatchFilter{Regex: `^INV-(?P<client>\w+)-(?P<year>\d{4})`}}
+	}
+	require.NoError(t, validateCategory(base(invoice(), "{match:year}_{name}.{ext}", "{match:client|upper}/{match:2}")))
+	require.NoError(t, validateCategory(base(models.CategoryFilter{Any: []models.CategoryFilter{
+		{Match: &models.MatchFilter{Glob: "*.pdf"}}, invoice(),
+	}}, "", "{match:client}")), "a regex in an any branch counts")
+
+	assert.ErrorContains(t, validateCategory(base(invoice(), "", "{match:month}")), "no match.regex in source.filter has that capture group")
+	assert.ErrorContains(t, validateCategory(base(invoice(), "{match:3}", "")), "rename uses {match:3}")
+	assert.ErrorContains(t, validateCategory(base(models.CategoryFilter{}, "", "{match:1}")), "source.filter has no match.regex")
+	assert.ErrorContains(t, validateCategory(base(models.CategoryFilter{Not: []models.CategoryFilter{invoice()}}, "", "{match:client}")),
+		"no match.regex", "a regex under not never matches a moved file")
+}
+
+func TestValidateCategory_NameDate(t *testing.T) {
+	base := func(patterns []string, f models.CategoryFilter) *models.Category {
+		c := categoryWithFilter(f)
+		c.Source.NameDatePatterns = patterns
+		c.Destination.OrganizeBy = "{name-year}/{name-month}"
+		return c
 	}
-	assert.NoError(t, validateCategory(base("image/*")))
-	require.Error(t, validateCategory(base("image/[")), "malformed glob is rejected")
+	cat := base([]string{`(?P<day>\d{2})\.(?P<month>\d{2})\.(?P<year>\d{4})`}, models.CategoryFilter{Any: []models.CategoryFilter{
+		{Age: &models.AgeFilter{Max: time.Hour, From: models.DateSourceName}},
+		{Size: &models.SizeFilter{Min: "1MB"}},
+	}})
+	require.NoError(t, validateCategory(cat))
+	require.Len(t, cat.Source.CompiledNameDatePatterns, 1)
+	assert.Equal(t, cat.Source.CompiledNameDatePatterns, cat.Source.Filter.Any[0].Age.NamePatterns,
+		"age filters nested in any/all/not get the category's patterns")
+
+	for _, from := range []models.DateSource{"", models.DateSourceMtime, models.DateSourceBirth, models.DateSourceExif} {
+		assert.NoError(t, validateCategory(base(nil, models.CategoryFilter{Age: &models.AgeFilter{Min: time.Hour, From: from}})), from)
+	}
+	assert.ErrorContains(t, validateCategory(base(nil, models.CategoryFilter{Age: &models.AgeFilter{From: "atime"}})), `invalid age.from "atime"`)
+	assert.ErrorContains(t, validateCategory(base([]string{`(?P<year>\d{4})(?P<month>\d{2})`}, models.CategoryFilter{})),
+		"must have a (?P<day>...) group")
+	assert.ErrorContains(t, validateCategory(base([]string{`(?P<year>`}, models.CategoryFilter{})), "invalid source.name-date-patterns entry")
+}
+
+func TestValidateCategory_DateFilters(t *testing.T) {
+	date := func(d models.DateFilter) models.CategoryFilter { return models.CategoryFilter{Date: &d} }
+	age := func(a models.AgeFilter) models.CategoryFilter { return models.CategoryFilter{Age: &a} }
+
+	require.NoError(t, validateCategory(categoryWithFilter(date(models.DateFilter{After: "start-of-last-quarter", Before: "start-of-quarter"}))))
+	require.NoError(t, validateCategory(categoryWithFilter(date(models.DateFilter{After: "2024-01-01", Before: "2024-07-01", Field: models.DateSourceBirth}))))
+	require.NoError(t, validateCategory(categoryWithFilter(date(models.DateFilter{After: "start-of-year", Before: "2000-01-01"}))),
+		"a calendar bound is not ordered against an absolute one")
+	require.NoError(t, validateCategory(categoryWithFilter(age(models.AgeFilter{Min: time.Hour, Field: models.DateSourceAtime}))))
+	require.NoError(t, validateCategory(categoryWithFilter(age(models.AgeFilter{Min: time.Hour, Field: models.DateSourceCtime}))))
+
+	assert.ErrorContains(t, validateCategory(categoryWithFilter(date(models.DateFilter{}))), "date needs after, before, or both")
+	assert.ErrorContains(t, validateCategory(categoryWithFilter(date(models.DateFilter{After: "start-of-decade"}))), "invalid date.after")
+	assert.ErrorContains(t, validateCategory(categoryWithFilter(date(models.DateFilter{Before: "2024-02-30"}))), "invalid date.before")
+	assert.ErrorContains(t, validateCategory(categoryWithFilter(date(models.DateFilter{After: "2024-07-01", Before: "2024-01-01"}))), "must be before date.before")
+	assert.ErrorContains(t, validateCategory(categoryWithFilter(date(models.DateFilter{After: "today", Field: models.DateSourceExif}))), `invalid date.field "exif"`)
+	assert.ErrorContains(t, validateCategory(categoryWithFilter(age(models.AgeFilter{Min: time.Hour, From: models.DateSourceAtime}))), `invalid age.from "atime"`)
+	assert.ErrorContains(t, validateCategory(categoryWithFilter(age(models.AgeFilter{Field: models.DateSourceBirth, From: models.DateSourceName}))),
+		"age.field and age.from are mutually exclusive")
+	assert.ErrorContains(t, validateCategory(categoryWithFilter(models.CategoryFilter{Date: &models.DateFilter{After: "today"}, Any: []models.CategoryFilter{{Mime: "image/*"}}})),
+		"cannot mix")
+}
+
+// unmarshalYAMLCategories loads a config file holding content and decodes its
+// categories the way a run does.
+func unmarshalYAMLCategories(t *testing.T, content string) []*models.Category {
+	t.Helper()
+	cats, err := UnmarshalConfig(loadKoanfFromYAML(t, content))
+	require.NoError(t, err)
+	return cats
+}
+
+// TestUnmarshalConfig_UnquotedDates verifies that date bounds YAML reads as
+// timestamps because they are not quoted still decode into the string fields.
+func TestUnmarshalConfig_UnquotedDates(t *testing.T) {
+	cats := unmarshalYAMLCategories(t, `
+categories:
+  - name: reports
+    enabled: true
+    source:
+      path: /src
+      extensions: [pdf]
+      filter:
+        date:
+          after: 2024-01-01
+          before: 2024-07-01T12:30:00+02:00
+    destination:
+      path: /dst
+`)
+	require.Len(t, cats, 1)
+	d := cats[0].Source.Filter.Date
+	require.NotNil(t, d)
+	assert.Equal(t, "2024-01-01", d.After)
+	assert.Equal(t, "2024-07-01T12:30:00+02:00", d.Before)
+}
+
+func TestValidateCategory_PathFilters(t *testing.T) {
+	base := func(f models.CategoryFilter, excludes ...string) *models.Category {
+		c := categoryWithFilter(f)
+		c.Source.ExcludePatterns = excludes
+		return c
+	}
+	path := func(p models.PathFilter) models.CategoryFilter { return models.CategoryFilter{Path: &p} }
+
+	cat := base(models.CategoryFilter{Any: []models.CategoryFilter{path(models.PathFilter{Glob: "raw/**"}), path(models.PathFilter{Regex: "^scans/"})}})
+	require.NoError(t, validateCategory(cat))
+	assert.Equal(t, "/src", cat.Source.Filter.Any[0].Path.Root, "the source path is bound into nested path filters")
+	require.NotNil(t, cat.Source.Filter.Any[1].Path.CompiledRegex)
+	assert.True(t, cat.Source.Filter.Any[1].Path.CompiledRegex.MatchString("SCANS/a.pdf"), "case-insensitive by default")
+	require.NoError(t, validateCategory(base(models.CategoryFilter{}, "**/node_modules", "backups/{2023,2024}")))
+
+	assert.ErrorContains(t, validateCategory(base(path(models.PathFilter{}))), "path needs a regex or a glob")
+	assert.ErrorContains(t, validateCategory(base(path(models.PathFilter{Glob: "raw/**", Regex: "raw"}))), "mutually exclusive")
+	assert.ErrorContains(t, validateCategory(base(path(models.PathFilter{Glob: "raw/["}))), "invalid glob pattern")
+	assert.ErrorContains(t, validateCategory(base(path(models.PathFilter{Regex: "raw/("}))), "invalid path.regex")
+	assert.ErrorContains(t, validateCategory(base(models.CategoryFilter{}, "tmp/[")), `invalid source.exclude-patterns entry "tmp/["`)
+}
+
+// TestApplyDateSettings sets the process-wide date settings of the tokens
+// package, so it does not run in parallel with the other tests.
+func TestApplyDateSettings(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, ApplyDateSettings(models.Configuration{})) })
+
+	require.NoError(t, ApplyDateSettings(models.Configuration{Timezone: "Europe/Paris", Locale: "fr"}))
+	require.NoError(t, ApplyDateSettings(models.Configuration{Timezone: "UTC"}))
+	assert.ErrorContains(t, ApplyDateSettings(models.Configuration{Timezone: "Mars/Olympus"}), `invalid configuration.timezone "Mars/Olympus"`)
+	assert.ErrorContains(t, ApplyDateSettings(models.Configuration{Locale: "klingon"}), `invalid configuration.locale "klingon" - must be one of: en, de`)
 }
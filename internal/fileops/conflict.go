@@ -1,6 +1,7 @@
 package fileops
 
 import (
+	"context"
 	"crypto/sha256"
 	"fmt"
 	"io"
@@ -21,6 +22,10 @@ type ConflictArgs struct {
 	// source file (hash_check) must not do so unless the action is a move,
 	// where consuming the source is part of the contract.
 	Action models.Action
+	// KeepReplaced, when set, is where a replace-style resolver keeps the
+	// destination it displaces once the action succeeds, instead of deleting
+	// it. Empty discards the displaced file as before.
+	KeepReplaced string
 }
 
 // FinalizeFunc commits or rolls back a destination that a resolver moved aside
@@ -40,10 +45,11 @@ type ConflictResolver interface {
 }
 
 // swapAside renames an existing destination to a unique temporary backup and
-// returns a FinalizeFunc that restores it when the action fails or removes it
-// when the action succeeds. This lets a replace-style strategy recover the
-// original file if the subsequent action fails partway through.
-func swapAside(dst string) (FinalizeFunc, error) {
+// returns a FinalizeFunc that restores it when the action fails. When the action
+// succeeds the backup is removed, or moved to keep when keep is set. This lets a
+// replace-style strategy recover the original file if the subsequent action
+// fails partway through.
+func swapAside(dst, keep string) (FinalizeFunc, error) {
 	backup, err := uniqueBackupPath(dst)
 	if err != nil {
 		return nil, err
@@ -56,10 +62,26 @@ func swapAside(dst string) (FinalizeFunc, error) {
 			_ = os.Remove(dst) // drop any partial output the failed action left behind
 			return os.Rename(backup, dst)
 		}
-		return os.Remove(backup)
+		if keep == "" {
+			return os.Remove(backup)
+		}
+		if err := keepBackup(backup, keep); err != nil {
+			return fmt.Errorf("could not keep replaced file, it was left at %s: %w", backup, err)
+		}
+		return nil
 	}, nil
 }
 
+// keepBackup moves a set-aside destination into the backup store. The store
+// usually lives on another filesystem than the destination, so this goes
+// through MoveFileCtx rather than a plain rename.
+func keepBackup(backup, keep string) error {
+	if err := CreateDirectory(filepath.Dir(keep)); err != nil {
+		return err
+	}
+	return MoveFileCtx(context.Background(), backup, keep)
+}
+
 // uniqueBackupPath returns a path next to dst that does not yet exist.
 func uniqueBackupPath(dst string) (string, error) {
 	for i := 0; i < 10000; i++ {
@@ -98,10 +120,11 @@ func (r *renameResolver) SkipMessage(ConflictArgs) string { return "" }
 type overwriteResolver struct{}
 
 func (r *overwriteResolver) Resolve(args ConflictArgs) (string, bool, FinalizeFunc, error) {
-	if runtime.GOOS == "windows" {
+	if runtime.GOOS == "windows" || args.KeepReplaced != "" {
 		// os.Rename fails on Windows when the destination exists. Move it aside
-		// instead of deleting it, so a failed action can be rolled back.
-		finalize, err := swapAside(args.Dst)
+		// instead of deleting it, so a failed action can be rolled back; the same
+		// path keeps the replaced file when backups are on.
+		finalize, err := swapAside(args.Dst, args.KeepReplaced)
 		if err != nil {
 			return "", false, nil, fmt.Errorf("failed to set aside destination file for overwrite: %w", err)
 		}
@@ -125,6 +148,12 @@ func (r *skipResolver) SkipMessage(ConflictArgs) string {
 	return "file skipped due to conflict strategy"
 }
 
+// SameContent reports whether two files have identical content, comparing
+// sizes before hashing.
+func SameContent(file1, file2 string) (bool, error) {
+	return compareFileHashes(file1, file2)
+}
+
 func compareFileHashes(file1, file2 string) (bool, error) {
 	info1, err := os.Stat(file1)
 	if err != nil {
@@ -187,7 +216,7 @@ func (r *comparatorResolver) Resolve(args ConflictArgs) (string, bool, FinalizeF
 	if !r.shouldReplace(srcInfo, dstInfo) {
 		return "", false, nil, nil
 	}
-	finalize, err := swapAside(args.Dst)
+	finalize, err := swapAside(args.Dst, args.KeepReplaced)
 	if err != nil {
 		return "", false, nil, fmt.Errorf("%s: failed to set aside destination: %w", r.name, err)
 	}
@@ -383,3 +383,34 @@ func writeFile(t *testing.T, path string, content []byte) {
 	t.Helper()
 	require.NoError(t, os.WriteFile(path, content, 0o644))
 }
+
+// TestSafeSwap_KeepReplaced verifies that with KeepReplaced set, the overwrite
+// resolver sets the destination aside even on POSIX and that a successful
+// action moves it into the backup store instead of deleting it.
+func TestSafeSwap_KeepReplaced(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	dst := filepath.Join(dir, "dst.txt")
+	keep := filepath.Join(dir, "backups", "batch_1", "dst.txt")
+	writeFile(t, src, []byte("new content"))
+	writeFile(t, dst, []byte("original"))
+
+	_, shouldMove, finalize, err := (&overwriteResolver{}).Resolve(
+		ConflictArgs{Src: src, Dst: dst, DestDir: dir, FileName: "dst.txt", KeepReplaced: keep})
+	require.NoError(t, err)
+	require.True(t, shouldMove)
+	require.NotNil(t, finalize)
+
+	writeFile(t, dst, []byte("new content"))
+	require.NoError(t, finalize(false))
+
+	got, err := os.ReadFile(keep)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("original"), got)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, e := range entries {
+		assert.NotContains(t, e.Name(), ".ml-bak", "set-aside copy should be moved to the store")
+	}
+}
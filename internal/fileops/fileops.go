@@ -15,6 +15,7 @@ import (
 	"github.com/lucasassuncao/movelooper/internal/filters"
 	"github.com/lucasassuncao/movelooper/internal/history"
 	"github.com/lucasassuncao/movelooper/internal/logger"
+	"github.com/lucasassuncao/movelooper/internal/metrics"
 	"github.com/lucasassuncao/movelooper/internal/models"
 	"github.com/lucasassuncao/movelooper/internal/tokens"
 )
@@ -27,10 +28,17 @@ var ErrTimestampPreserve = errors.New("could not preserve file timestamps")
 // History may be a *history.History (saved per file, used by watch mode) or a
 // *history.Buffer (collected in memory and flushed once per batch by the
 // one-shot run). Callers must leave it nil — not a typed-nil pointer — when
-// history tracking is disabled.
+// history tracking is disabled. Metrics is optional; a nil collector records
+// nothing. BackupDir, when set, is the root of the backup store: destinations
+// replaced by a conflict strategy are kept under BackupDir/<batch> and recorded
+// in the entry's Replaced field instead of being deleted. HashDestinations adds
+// a SHA-256 to the size and mtime recorded for each destination.
 type MoveContext struct {
-	Logger  logger.Logger
-	History history.Recorder
+	Logger           logger.Logger
+	History          history.Recorder
+	Metrics          *metrics.Collector
+	BackupDir        string
+	HashDestinations bool
 }
 
 // CreateDirectory creates dir and all necessary parents with full permissions.
@@ -45,6 +53,8 @@ type MoveRequest struct {
 	Files     []os.DirEntry
 	Extension string
 	BatchID   string
+	// Label is recorded on every history entry; see history.Entry.Label.
+	Label     string
 	SourceDir string // actual directory of the files; may differ from Category.Source.Path when recursive
 	// LogEachMove logs an INFO line per processed file. Watch mode sets it to
 	// report files as they arrive; batch mode leaves it false and logs a single
@@ -74,6 +84,14 @@ func MoveFiles(ctx context.Context, mctx MoveContext, req MoveRequest) MoveResul
 	// One allocator per call seeds each destination directory once, then hands
 	// out sequence numbers in memory instead of re-scanning the directory per file.
 	seqAlloc := tokens.NewSeqAllocator()
+	strategy := category.Destination.ConflictStrategy
+	if strategy == "" {
+		strategy = models.ConflictStrategyRename
+	}
+	action := category.Destination.Action
+	if action == "" {
+		action = models.ActionMove
+	}
 	for _, file := range files {
 		select {
 		case <-ctx.Done():
@@ -87,42 +105,48 @@ func MoveFiles(ctx context.Context, mctx MoveContext, req MoveRequest) MoveResul
 		info, err := file.Info()
 		if err != nil {
 			mctx.Logger.Error("failed to stat file", mctx.Logger.Args("file", file.Name(), "error", err.Error()))
+			mctx.Metrics.FileFailed(category.Name, string(action))
 			continue
 		}
 
 		sourcePath := filepath.Join(req.SourceDir, file.Name())
 
-		tctx := tokens.TokenContext{Info: info, CategoryName: category.Name, Now: time.Now(), SourcePath: sourcePath, SeqAlloc: seqAlloc}
+		tctx := tokens.TokenContext{
+			Info: info, CategoryName: category.Name, Now: time.Now(), SeqAlloc: seqAlloc,
+			SourcePath: sourcePath, SourceRoot: category.Source.Path,
+			TagDefaults:      category.Destination.TagDefaults,
+			Captures:         filters.Captures(category.Source.Filter, file.Name()),
+			NameDatePatterns: category.Source.CompiledNameDatePatterns,
+		}
 		destDir, destName := ResolveDestination(category, &tctx)
 
 		if err := CreateDirectory(destDir); err != nil {
 			mctx.Logger.Error("failed to create directory", mctx.Logger.Args("path", destDir, "error", err.Error()))
+			mctx.Metrics.FileFailed(category.Name, string(action))
 			continue
 		}
 
 		destPath := filepath.Join(destDir, destName)
 
-		strategy := category.Destination.ConflictStrategy
-		if strategy == "" {
-			strategy = models.ConflictStrategyRename
-		}
-		action := category.Destination.Action
-		if action == "" {
-			action = models.ActionMove
-		}
-		resolved, skip, finalize, stratErr := applyConflictStrategy(mctx, strategy, ConflictArgs{
+		conflict := ConflictArgs{
 			Src:      sourcePath,
 			Dst:      destPath,
 			DestDir:  destDir,
 			FileName: destName,
 			Action:   action,
-		})
+		}
+		if mctx.BackupDir != "" {
+			conflict.KeepReplaced = replacedBackupPath(mctx.BackupDir, req.BatchID, destPath)
+		}
+		resolved, skip, finalize, stratErr := applyConflictStrategy(mctx, strategy, conflict)
 		if stratErr != nil {
 			mctx.Logger.Error("cannot process file", mctx.Logger.Args("file", sourcePath, "error", stratErr.Error()))
+			mctx.Metrics.FileFailed(category.Name, string(action))
 			continue
 		}
 		if skip {
 			result.Skipped++
+			mctx.Metrics.FileSkipped(category.Name, string(action))
 			continue
 		}
 		destPath = resolved
@@ -133,19 +157,34 @@ func MoveFiles(ctx context.Context, mctx MoveContext, req MoveRequest) MoveResul
 				mctx.Logger.Warn("file processed but timestamps could not be preserved", mctx.Logger.Args("file", sourcePath))
 			} else {
 				mctx.Logger.Warn("failed to perform action on file", mctx.Logger.Args("file", sourcePath, "action", action, "destination", destPath, "conflict_strategy", strategy, "error", actionErr.Error()))
+				mctx.Metrics.FileFailed(category.Name, string(action))
 				continue
 			}
 		}
 
+		var replaced string
+		if finalize != nil && conflict.KeepReplaced != "" {
+			if _, err := os.Lstat(conflict.KeepReplaced); err == nil {
+				replaced = conflict.KeepReplaced
+			}
+		}
+
 		if mctx.History != nil {
-			if err := mctx.History.Add(history.Entry{
+			entry := history.Entry{
 				Source:      sourcePath,
 				Destination: destPath,
 				Timestamp:   time.Now(),
 				BatchID:     req.BatchID,
 				Action:      string(action),
 				Category:    category.Name,
-			}); err != nil {
+				Label:       req.Label,
+				Replaced:    replaced,
+			}
+			if err := entry.Stamp(mctx.HashDestinations); err != nil {
+				mctx.Logger.Warn("could not fingerprint destination; undo will not notice later changes to it",
+					mctx.Logger.Args("file", destPath, "error", err.Error()))
+			}
+			if err := mctx.History.Add(entry); err != nil {
 				mctx.Logger.Warn("failed to record history; undo will not work for this file",
 					mctx.Logger.Args("file", sourcePath, "error", err.Error()))
 			}
@@ -157,10 +196,25 @@ func MoveFiles(ctx context.Context, mctx MoveContext, req MoveRequest) MoveResul
 		result.Details = append(result.Details, MovedDetail{Source: sourcePath, Destination: destPath})
 		result.Moved = append(result.Moved, file.Name())
 		result.Bytes += info.Size()
+		mctx.Metrics.FileMoved(category.Name, string(action), info.Size())
 	}
 	return result
 }
 
+// replacedBackupPath returns where the destination dst would be kept if a
+// conflict strategy replaces it: a free name under root/<batch>. It returns ""
+// when dst does not exist, so files without a conflict cost a single stat.
+func replacedBackupPath(root, batchID, dst string) string {
+	if _, err := os.Lstat(dst); err != nil {
+		return ""
+	}
+	keep, err := getUniqueDestinationPath(filepath.Join(root, batchID), filepath.Base(dst))
+	if err != nil {
+		return ""
+	}
+	return keep
+}
+
 // FileAction executes a file operation from src to dst.
 type FileAction interface {
 	Execute(ctx context.Context, src, dst string) error
@@ -211,6 +265,17 @@ func performAction(ctx context.Context, mctx MoveContext, action models.Action,
 	return actionErr
 }
 
+// Replay performs action from src to dst without conflict handling, for redo,
+// which checks the destination itself. An empty action is a move, matching
+// history entries recorded before actions were tracked. ErrTimestampPreserve
+// is passed through; the file was placed.
+func Replay(ctx context.Context, action models.Action, src, dst string) error {
+	if action == "" {
+		action = models.ActionMove
+	}
+	return dispatchAction(ctx, action, src, dst)
+}
+
 // dispatchAction performs the file operation indicated by action.
 // Supported values: ActionMove (default), ActionCopy, ActionSymlink.
 func dispatchAction(ctx context.Context, action models.Action, src, dst string) error {
@@ -243,14 +308,17 @@ func applyConflictStrategy(ctx MoveContext, strategy models.ConflictStrategy, ar
 	resolvedPath, shouldMove, fin, resolveErr := resolver.Resolve(args)
 	if resolveErr != nil {
 		ctx.Logger.Error("failed to resolve conflict", ctx.Logger.Args("file", args.FileName, "error", resolveErr.Error()))
+		ctx.Metrics.ConflictDecision(string(strategy), metrics.DecisionError)
 		return "", true, nil, nil
 	}
 	if !shouldMove {
 		if msg := resolver.SkipMessage(args); msg != "" {
 			ctx.Logger.Info(msg, ctx.Logger.Args("file", args.FileName))
 		}
+		ctx.Metrics.ConflictDecision(string(strategy), metrics.DecisionSkipped)
 		return "", true, nil, nil
 	}
+	ctx.Metrics.ConflictDecision(string(strategy), metrics.DecisionResolved)
 	return resolvedPath, false, fin, nil
 }
 
@@ -4,10 +4,13 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/lucasassuncao/movelooper/internal/history"
+	"github.com/lucasassuncao/movelooper/internal/metrics"
 	"github.com/lucasassuncao/movelooper/internal/models"
 	"github.com/pterm/pterm"
 	"github.com/stretchr/testify/assert"
@@ -601,3 +604,131 @@ func newTestMoveContext() MoveContext {
 		Logger: newTestLogger(),
 	}
 }
+
+// TestMoveFiles_RecordsMetrics verifies that MoveFiles reports moved bytes,
+// skipped files, and the conflict decision to the metrics collector.
+func TestMoveFiles_RecordsMetrics(t *testing.T) {
+	t.Parallel()
+	src := t.TempDir()
+	dst := t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), []byte("aaaa"))
+	writeFile(t, filepath.Join(src, "b.txt"), []byte("b"))
+	writeFile(t, filepath.Join(dst, "b.txt"), []byte("existing"))
+
+	entries, err := os.ReadDir(src)
+	require.NoError(t, err)
+	mctx := newTestMoveContext()
+	mctx.Metrics = metrics.NewCollector()
+	cat := &models.Category{
+		Name:        "docs",
+		Destination: models.CategoryDestination{Path: dst, ConflictStrategy: models.ConflictStrategySkip},
+	}
+	MoveFiles(context.Background(), mctx, MoveRequest{Category: cat, Files: entries, Extension: "txt", SourceDir: src})
+
+	var sb strings.Builder
+	require.NoError(t, mctx.Metrics.Registry().WriteText(&sb))
+	out := sb.String()
+	assert.Contains(t, out, `movelooper_files_moved_total{category="docs",action="move"} 1`)
+	assert.Contains(t, out, `movelooper_bytes_moved_total{category="docs",action="move"} 4`)
+	assert.Contains(t, out, `movelooper_files_skipped_total{category="docs",action="move"} 1`)
+	assert.Contains(t, out, `movelooper_conflict_decisions_total{strategy="skip",decision="skipped"} 1`)
+}
+
+// TestMoveFiles_KeepsReplacedDestination verifies that with a BackupDir the
+// overwrite strategy keeps the displaced file under <BackupDir>/<batch> and
+// records it in the history entry, while files without a conflict record none.
+func TestMoveFiles_KeepsReplacedDestination(t *testing.T) {
+	t.Parallel()
+	src := t.TempDir()
+	dst := t.TempDir()
+	backups := t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), []byte("new"))
+	writeFile(t, filepath.Join(src, "b.txt"), []byte("b"))
+	writeFile(t, filepath.Join(dst, "a.txt"), []byte("old"))
+
+	entries, err := os.ReadDir(src)
+	require.NoError(t, err)
+	var rec history.Buffer
+	mctx := newTestMoveContext()
+	mctx.History = &rec
+	mctx.BackupDir = backups
+	cat := &models.Category{
+		Name:        "docs",
+		Destination: models.CategoryDestination{Path: dst, ConflictStrategy: models.ConflictStrategyOverwrite},
+	}
+	result := MoveFiles(context.Background(), mctx, MoveRequest{Category: cat, Files: entries, Extension: "txt", SourceDir: src, BatchID: "batch_1"})
+	require.Len(t, result.Moved, 2)
+
+	h, err := history.NewHistory(filepath.Join(t.TempDir(), "h.json"), 10)
+	require.NoError(t, err)
+	require.NoError(t, rec.Flush(h))
+	recorded := h.GetBatch("batch_1")
+	require.Len(t, recorded, 2)
+
+	kept := filepath.Join(backups, "batch_1", "a.txt")
+	assert.Equal(t, kept, recorded[0].Replaced)
+	assert.Empty(t, recorded[1].Replaced, "no conflict, nothing replaced")
+	got, err := os.ReadFile(kept)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("old"), got)
+	got, err = os.ReadFile(filepath.Join(dst, "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("new"), got)
+}
+
+// TestMoveFiles_FingerprintsDestination verifies that history entries carry
+// the size and mtime of the placed file, and its hash when asked.
+func TestMoveFiles_FingerprintsDestination(t *testing.T) {
+	t.Parallel()
+	src := t.TempDir()
+	dst := t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), []byte("abc"))
+
+	entries, err := os.ReadDir(src)
+	require.NoError(t, err)
+	var rec history.Buffer
+	mctx := newTestMoveContext()
+	mctx.History = &rec
+	mctx.HashDestinations = true
+	cat := &models.Category{Name: "docs", Destination: models.CategoryDestination{Path: dst}}
+	MoveFiles(context.Background(), mctx, MoveRequest{Category: cat, Files: entries, Extension: "txt", SourceDir: src, BatchID: "batch_1"})
+
+	h, err := history.NewHistory(filepath.Join(t.TempDir(), "h.json"), 10)
+	require.NoError(t, err)
+	require.NoError(t, rec.Flush(h))
+	recorded := h.GetBatch("batch_1")
+	require.Len(t, recorded, 1)
+	assert.Equal(t, int64(3), recorded[0].Size)
+	assert.False(t, recorded[0].ModTime.IsZero())
+	assert.Equal(t, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", recorded[0].SHA256)
+
+	check, err := recorded[0].Verify(true)
+	require.NoError(t, err)
+	assert.Equal(t, history.IntegrityOK, check.Status)
+}
+
+// TestMoveFiles_MirrorsSourceTree verifies that {relpath} in organize-by
+// recreates the file's folder below source.path, and that the regex captures
+// of the category's filter reach the rename template.
+func TestMoveFiles_MirrorsSourceTree(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	src := filepath.Join(root, "clients", "acme")
+	dst := t.TempDir()
+	require.NoError(t, os.MkdirAll(src, 0o755))
+	writeFile(t, filepath.Join(src, "INV-0042.txt"), []byte("x"))
+
+	entries, err := os.ReadDir(src)
+	require.NoError(t, err)
+	cat := &models.Category{
+		Name: "invoices",
+		Source: models.CategorySource{Path: root, Filter: models.CategoryFilter{
+			Match: &models.MatchFilter{CompiledRegex: regexp.MustCompile(`^INV-(?P<number>\d+)`)},
+		}},
+		Destination: models.CategoryDestination{Path: dst, OrganizeBy: "{relpath}", Rename: "{parent}-{match:number}.{ext}"},
+	}
+	result := MoveFiles(context.Background(), newTestMoveContext(), MoveRequest{Category: cat, Files: entries, Extension: "txt", SourceDir: src})
+
+	require.Len(t, result.Moved, 1)
+	assert.FileExists(t, filepath.Join(dst, "clients", "acme", "acme-0042.txt"))
+}
@@ -0,0 +1,131 @@
+package filters
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lucasassuncao/movelooper/internal/media"
+	"github.com/lucasassuncao/movelooper/internal/models"
+	"github.com/lucasassuncao/movelooper/internal/namedate"
+	"github.com/lucasassuncao/movelooper/internal/tokens"
+)
+
+// fileDate returns the date of the file at path that field, or else from,
+// selects. A file without that date, and the default mtime, give the
+// modification time.
+func fileDate(field, from models.DateSource, patterns []*regexp.Regexp, path string, info os.FileInfo) time.Time {
+	source := from
+	if field != "" {
+		source = field
+	}
+	var date time.Time
+	switch source {
+	case models.DateSourceName:
+		date, _ = namedate.Parse(filepath.Base(path), patterns)
+	case models.DateSourceBirth:
+		date = tokens.BirthTime(info)
+	case models.DateSourceAtime:
+		date = tokens.AccessTime(info)
+	case models.DateSourceCtime:
+		date = tokens.ChangeTime(info)
+	case models.DateSourceExif:
+		md, _ := media.Read(path)
+		date = md.Taken
+	}
+	if date.IsZero() {
+		return info.ModTime()
+	}
+	return date
+}
+
+// matchesDateFilter reports whether the date d selects lies in
+// [d.After, d.Before), with calendar bounds evaluated at the current time.
+func matchesDateFilter(d *models.DateFilter, path string, info os.FileInfo) bool {
+	if d == nil {
+		return true
+	}
+	now := time.Now()
+	date := fileDate(d.Field, d.From, d.NamePatterns, path, info)
+	if d.After != "" {
+		after, err := ParseDateBound(d.After, now)
+		if err != nil || date.Before(after) {
+			return false
+		}
+	}
+	if d.Before != "" {
+		before, err := ParseDateBound(d.Before, now)
+		if err != nil || !date.Before(before) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseDate parses a date (YYYY-MM-DD, midnight local time) or an RFC 3339
+// time.
+func ParseDate(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date (YYYY-MM-DD) or RFC 3339 time", s)
+}
+
+// ParseDateBound resolves a filter.date bound at now. It accepts what
+// ParseDate does, and the calendar expressions today, yesterday, tomorrow and
+// start-of-[last-|next-]UNIT, where UNIT is day, week (starting on Monday),
+// month, quarter or year, all in local time.
+func ParseDateBound(s string, now time.Time) (time.Time, error) {
+	if t, ok := calendarBound(s, now); ok {
+		return t, nil
+	}
+	if t, err := ParseDate(s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date (YYYY-MM-DD), RFC 3339 time or calendar expression such as start-of-month", s)
+}
+
+// calendarBound evaluates a calendar expression at now.
+func calendarBound(s string, now time.Time) (time.Time, bool) {
+	switch s {
+	case "today":
+		s = "start-of-day"
+	case "yesterday":
+		s = "start-of-last-day"
+	case "tomorrow":
+		s = "start-of-next-day"
+	}
+	unit, ok := strings.CutPrefix(s, "start-of-")
+	if !ok {
+		return time.Time{}, false
+	}
+	shift := 0
+	if rest, ok := strings.CutPrefix(unit, "last-"); ok {
+		unit, shift = rest, -1
+	} else if rest, ok := strings.CutPrefix(unit, "next-"); ok {
+		unit, shift = rest, 1
+	}
+	now = now.Local()
+	y, m, d := now.Date()
+	switch unit {
+	case "day":
+		return time.Date(y, m, d+shift, 0, 0, 0, 0, time.Local), true
+	case "week":
+		sinceMonday := (int(now.Weekday()) + 6) % 7
+		return time.Date(y, m, d-sinceMonday+7*shift, 0, 0, 0, 0, time.Local), true
+	case "month":
+		return time.Date(y, m+time.Month(shift), 1, 0, 0, 0, 0, time.Local), true
+	case "quarter":
+		first := (m-1)/3*3 + 1
+		return time.Date(y, first+time.Month(3*shift), 1, 0, 0, 0, 0, time.Local), true
+	case "year":
+		return time.Date(y+shift, 1, 1, 0, 0, 0, 0, time.Local), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,115 @@
+package filters
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/lucasassuncao/movelooper/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDateBound(t *testing.T) {
+	t.Parallel()
+	// Wednesday, 14 May 2025
+	now := time.Date(2025, 5, 14, 15, 30, 0, 0, time.Local)
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.Local) }
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"today", day(2025, 5, 14)},
+		{"yesterday", day(2025, 5, 13)},
+		{"tomorrow", day(2025, 5, 15)},
+		{"start-of-day", day(2025, 5, 14)},
+		{"start-of-week", day(2025, 5, 12)},
+		{"start-of-last-week", day(2025, 5, 5)},
+		{"start-of-next-week", day(2025, 5, 19)},
+		{"start-of-month", day(2025, 5, 1)},
+		{"start-of-last-month", day(2025, 4, 1)},
+		{"start-of-quarter", day(2025, 4, 1)},
+		{"start-of-last-quarter", day(2025, 1, 1)},
+		{"start-of-next-quarter", day(2025, 7, 1)},
+		{"start-of-year", day(2025, 1, 1)},
+		{"start-of-last-year", day(2024, 1, 1)},
+		{"2024-01-01", day(2024, 1, 1)},
+		{"2024-01-01T10:00:00Z", time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range cases {
+		got, err := ParseDateBound(tt.expr, now)
+		require.NoError(t, err, tt.expr)
+		assert.True(t, tt.want.Equal(got), "%s: got %s", tt.expr, got)
+	}
+
+	sunday := time.Date(2025, 5, 18, 23, 0, 0, 0, time.Local)
+	got, err := ParseDateBound("start-of-week", sunday)
+	require.NoError(t, err)
+	assert.True(t, day(2025, 5, 12).Equal(got), "weeks start on Monday")
+
+	january := time.Date(2025, 1, 20, 0, 0, 0, 0, time.Local)
+	got, err = ParseDateBound("start-of-last-quarter", january)
+	require.NoError(t, err)
+	assert.True(t, day(2024, 10, 1).Equal(got), "the last quarter may be in the previous year")
+
+	for _, bad := range []string{"", "start-of-decade", "start-of-last", "last-month", "2024-13-01", "01/02/2024"} {
+		_, err := ParseDateBound(bad, now)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestMatchesFilter_Date(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "invoice.pdf")
+	require.NoError(t, os.WriteFile(path, []byte("x"), 0o644))
+	mtime := time.Date(2024, 3, 15, 12, 0, 0, 0, time.Local)
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name string
+		date models.DateFilter
+		want bool
+	}{
+		{"inside the range", models.DateFilter{After: "2024-01-01", Before: "2024-04-01"}, true},
+		{"after is inclusive", models.DateFilter{After: "2024-03-15T12:00:00" + mtime.Format("Z07:00")}, true},
+		{"before is exclusive", models.DateFilter{Before: "2024-03-15T12:00:00" + mtime.Format("Z07:00")}, false},
+		{"too old", models.DateFilter{After: "2024-06-01"}, false},
+		{"too new", models.DateFilter{Before: "2024-01-01"}, false},
+		{"calendar bound", models.DateFilter{Before: "start-of-month"}, true},
+		{"file name date", models.DateFilter{After: "2025-01-01", From: models.DateSourceName}, false},
+	}
+	for _, tt := range cases {
+		assert.Equal(t, tt.want, MatchesFilter(models.CategoryFilter{Date: &tt.date}, path, info), tt.name)
+	}
+
+	named := filepath.Join(dir, "scan_2019-07-04.pdf")
+	require.NoError(t, os.WriteFile(named, []byte("x"), 0o644))
+	namedInfo, err := os.Stat(named)
+	require.NoError(t, err)
+	assert.True(t, MatchesFilter(models.CategoryFilter{Date: &models.DateFilter{Before: "2020-01-01", From: models.DateSourceName}}, named, namedInfo))
+	assert.False(t, MatchesFilter(models.CategoryFilter{Date: &models.DateFilter{Before: "2020-01-01"}}, named, namedInfo))
+}
+
+func TestMatchesFilter_AgeField(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "old.log")
+	require.NoError(t, os.WriteFile(path, []byte("x"), 0o644))
+	old := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(path, time.Now(), old))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	stale := func(field models.DateSource) models.CategoryFilter {
+		return models.CategoryFilter{Age: &models.AgeFilter{Min: 24 * time.Hour, Field: field}}
+	}
+	assert.True(t, MatchesFilter(stale(models.DateSourceMtime), path, info))
+	if runtime.GOOS == "linux" || runtime.GOOS == "darwin" {
+		assert.False(t, MatchesFilter(stale(models.DateSourceAtime), path, info), "the access time is now")
+		assert.False(t, MatchesFilter(stale(models.DateSourceCtime), path, info), "the inode changed just now")
+	}
+}
@@ -6,11 +6,13 @@ import (
 	"os"
 	gpath "path"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/lucasassuncao/movelooper/internal/content"
+	"github.com/lucasassuncao/movelooper/internal/media"
 	"github.com/lucasassuncao/movelooper/internal/models"
 )
 
@@ -179,7 +181,8 @@ func MatchesNameFilters(fileName string, f models.CategoryFilter) bool {
 
 // MatchesFilter reports whether the file at path (with metadata info) passes
 // filter f. path is the file's full path; the base name is used for name
-// filters and the full path for MIME detection.
+// filters, the path relative to the source for path filters and the full path
+// for MIME detection.
 func MatchesFilter(f models.CategoryFilter, path string, info os.FileInfo) bool {
 	// not is a modifier that excludes files and may coexist with any/all at the
 	// same level, so it must be evaluated before the any/all branches return.
@@ -204,13 +207,35 @@ func MatchesFilter(f models.CategoryFilter, path string, info os.FileInfo) bool
 		}
 		return true
 	}
-	if !MatchesNameFilters(filepath.Base(path), f) {
+	if !MatchesNameFilters(filepath.Base(path), f) || !matchesPathFilter(f.Path, path) {
 		return false
 	}
-	if !MeetsAgeSizeFilters(info, f) {
+	if !MeetsAgeSizeFilters(ageInfo(f.Age, path, info), f) {
 		return false
 	}
-	return matchesMimeFilter(f, path)
+	if !matchesDateFilter(f.Date, path, info) {
+		return false
+	}
+	return matchesMimeFilter(f, path) && matchesExifFilter(f.Exif, path) && matchesTagsFilter(f.Tags, path) &&
+		matchesVideoFilter(f.Video, path)
+}
+
+// datedInfo is a FileInfo whose ModTime is the date an age filter measures.
+type datedInfo struct {
+	os.FileInfo
+	date time.Time
+}
+
+func (d datedInfo) ModTime() time.Time { return d.date }
+
+// ageInfo returns info with its ModTime replaced by the date a.Field or
+// a.From selects, so the age checks measure that date. With neither set
+// info is returned as is.
+func ageInfo(a *models.AgeFilter, path string, info os.FileInfo) os.FileInfo {
+	if a == nil || (a.Field == "" && a.From == "") {
+		return info
+	}
+	return datedInfo{FileInfo: info, date: fileDate(a.Field, a.From, a.NamePatterns, path, info)}
 }
 
 // matchesMimeFilter reports whether the file at path matches f.Mime, a glob
@@ -228,19 +253,154 @@ func matchesMimeFilter(f models.CategoryFilter, path string) bool {
 	return err == nil && matched
 }
 
-func matchesName(m *models.MatchFilter, fileName string) bool {
-	if m.CompiledRegex != nil && !m.CompiledRegex.MatchString(fileName) {
+// matchesExifFilter reports whether the photo at path satisfies e. The file
+// is only read when e is set. A file without EXIF metadata fails the camera
+// and taken-date rules and counts as having no GPS position.
+func matchesExifFilter(e *models.ExifFilter, path string) bool {
+	if e == nil {
+		return true
+	}
+	md, _ := media.Read(path)
+	if e.Camera != "" {
+		model := strings.TrimSpace(md.Make + " " + md.Model)
+		if md.Model == "" || (!MatchesGlob(md.Model, e.Camera, false) && !MatchesGlob(model, e.Camera, false)) {
+			return false
+		}
+	}
+	if (!e.After.IsZero() || !e.Before.IsZero()) && md.Taken.IsZero() {
 		return false
 	}
-	if m.Glob != "" && !MatchesGlob(fileName, m.Glob, m.CaseSensitive) {
+	if !e.After.IsZero() && md.Taken.Before(e.After) {
+		return false
+	}
+	if !e.Before.IsZero() && !md.Taken.Before(e.Before) {
+		return false
+	}
+	if e.HasGPS != nil && *e.HasGPS != md.HasGPS {
 		return false
 	}
+	return true
+}
+
+// matchesTagsFilter reports whether the audio file at path satisfies t. The
+// file is only read when t is set. A rule on a tag the file does not record
+// fails; the artist rule also accepts the album artist.
+func matchesTagsFilter(t *models.TagsFilter, path string) bool {
+	if t == nil {
+		return true
+	}
+	tags, _ := media.ReadTags(path)
+	matches := func(value, glob string) bool {
+		return value != "" && MatchesGlob(value, glob, false)
+	}
+	if t.Artist != "" && !matches(tags.Artist, t.Artist) && !matches(tags.AlbumArtist, t.Artist) {
+		return false
+	}
+	if t.Album != "" && !matches(tags.Album, t.Album) {
+		return false
+	}
+	if t.Genre != "" && !matches(tags.Genre, t.Genre) {
+		return false
+	}
+	return true
+}
+
+// matchesVideoFilter reports whether the video at path satisfies v. The file
+// is only read when v is set; a file that is not a video fails every rule.
+func matchesVideoFilter(v *models.VideoFilter, path string) bool {
+	if v == nil {
+		return true
+	}
+	md, err := media.ReadVideo(path)
+	if err != nil {
+		return false
+	}
+	if v.MinDuration > 0 && md.Duration < v.MinDuration {
+		return false
+	}
+	if v.MaxDuration > 0 && md.Duration > v.MaxDuration {
+		return false
+	}
+	return v.MinHeight <= 0 || md.Height >= v.MinHeight
+}
+
+func matchesName(m *models.MatchFilter, fileName string) bool {
+	_, ok := nameCaptures(m, fileName)
+	return ok
+}
+
+// nameCaptures reports whether fileName passes m and, when m has a regex,
+// returns its capture groups keyed by group number ("0" is the whole match)
+// and by name.
+func nameCaptures(m *models.MatchFilter, fileName string) (map[string]string, bool) {
+	var captures map[string]string
+	if m.CompiledRegex != nil {
+		sub := m.CompiledRegex.FindStringSubmatch(fileName)
+		if sub == nil {
+			return nil, false
+		}
+		captures = make(map[string]string, 2*len(sub))
+		for i, name := range m.CompiledRegex.SubexpNames() {
+			captures[strconv.Itoa(i)] = sub[i]
+			if name != "" {
+				captures[name] = sub[i]
+			}
+		}
+	}
+	if m.Glob != "" && !MatchesGlob(fileName, m.Glob, m.CaseSensitive) {
+		return nil, false
+	}
 	if m.Literal != "" {
 		if normalizeCase(fileName, m.CaseSensitive) != normalizeCase(m.Literal, m.CaseSensitive) {
-			return false
+			return nil, false
 		}
 	}
-	return true
+	return captures, true
+}
+
+// Captures returns the capture groups of the match.regex rules in f that
+// fileName matches, for the {match:GROUP} tokens. Rules under not are
+// skipped, since a file that passes the filter never matches them. When
+// several regexes match, the first one in evaluation order wins for a group
+// they share.
+func Captures(f models.CategoryFilter, fileName string) map[string]string {
+	captures := map[string]string{}
+	collectCaptures(f, fileName, captures)
+	return captures
+}
+
+func collectCaptures(f models.CategoryFilter, fileName string, captures map[string]string) {
+	if f.Match != nil && f.Match.CompiledRegex != nil {
+		found, _ := nameCaptures(f.Match, fileName)
+		for k, v := range found {
+			if _, ok := captures[k]; !ok {
+				captures[k] = v
+			}
+		}
+	}
+	for _, child := range f.Any {
+		collectCaptures(child, fileName, captures)
+	}
+	for _, child := range f.All {
+		collectCaptures(child, fileName, captures)
+	}
+}
+
+// CaptureRegexes returns the compiled match.regex rules of f that Captures
+// reads, in evaluation order, so callers can check which groups a template
+// may reference.
+func CaptureRegexes(f models.CategoryFilter) []*regexp.Regexp {
+	var regexes []*regexp.Regexp
+	if f.Match != nil && f.Match.CompiledRegex != nil {
+		regexes = append(regexes, f.Match.CompiledRegex)
+	}
+	for _, child := range f.Any {
+		regexes = append(regexes, CaptureRegexes(child)...)
+	}
+	for _, child := range f.All {
+		regexes = append(regexes, CaptureRegexes(child)...)
+	}
+	return regexes
 }
 
 // GenerateLogArgs generates log arguments for a given extension.
@@ -1,6 +1,8 @@
 package filters
 
 import (
+	"bytes"
+	"encoding/binary"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -252,6 +254,26 @@ func TestMatchesNameFilters(t *testing.T) {
 	}
 }
 
+func TestCaptures(t *testing.T) {
+	t.Parallel()
+	invoice := &models.MatchFilter{CompiledRegex: regexp.MustCompile(`^INV-(?P<client>\w+)-(?P<year>\d{4})`)}
+	receipt := &models.MatchFilter{CompiledRegex: regexp.MustCompile(`^RCPT-(?P<client>\w+)`)}
+
+	got := Captures(models.CategoryFilter{Match: invoice}, "INV-acme-2024.pdf")
+	assert.Equal(t, map[string]string{
+		"0": "INV-acme-2024", "1": "acme", "2": "2024", "client": "acme", "year": "2024",
+	}, got)
+
+	either := models.CategoryFilter{Any: []models.CategoryFilter{{Match: receipt}, {Match: invoice}}}
+	assert.Equal(t, "acme", Captures(either, "INV-acme-2024.pdf")["client"], "the matching any branch supplies the groups")
+	assert.Equal(t, "shop", Captures(either, "RCPT-shop.pdf")["client"])
+
+	excluded := models.CategoryFilter{Match: invoice, Not: []models.CategoryFilter{{Match: receipt}}}
+	assert.Empty(t, Captures(models.CategoryFilter{Match: invoice}, "notes.txt"))
+	assert.Len(t, CaptureRegexes(either), 2)
+	assert.Len(t, CaptureRegexes(excluded), 1, "regexes under not are skipped")
+}
+
 // testParseSize defines the structure for test cases of the ParseSize function,
 // containing the input string, expected byte count, and an error expectation flag.
 type testParseSize struct {
@@ -763,3 +785,205 @@ func TestMatchesFilter_Mime(t *testing.T) {
 	missing := filepath.Join(dir, "gone.bin")
 	assert.False(t, MatchesFilter(models.CategoryFilter{Mime: "image/*"}, missing, info), "unreadable file does not match a positive mime rule")
 }
+
+// exifTIFF returns a little-endian TIFF file whose IFD0 records model and the
+// DateTime taken ("2006:01:02 15:04:05"), plus a GPS IFD with a latitude tag
+// when gps is set.
+func exifTIFF(model, taken string, gps bool) []byte {
+	le := binary.LittleEndian
+	n := 2
+	if gps {
+		n = 3
+	}
+	gpsOff := 8 + 2 + 12*n + 4
+	data := gpsOff
+	if gps {
+		data += 2 + 12 + 4
+	}
+	var values []byte
+	entry := func(b []byte, tag, typ uint16, count, value uint32) []byte {
+		b = le.AppendUint16(b, tag)
+		b = le.AppendUint16(b, typ)
+		b = le.AppendUint32(b, count)
+		return le.AppendUint32(b, value)
+	}
+	ascii := func(b []byte, tag uint16, s string) []byte {
+		b = entry(b, tag, 2, uint32(len(s)+1), uint32(data+len(values)))
+		values = append(append(values, s...), 0)
+		return b
+	}
+	b := []byte("II*\x00\x08\x00\x00\x00")
+	b = le.AppendUint16(b, uint16(n))
+	b = ascii(b, 0x0110, model)
+	b = ascii(b, 0x0132, taken)
+	if gps {
+		b = entry(b, 0x8825, 4, 1, uint32(gpsOff))
+	}
+	b = le.AppendUint32(b, 0)
+	if gps {
+		b = le.AppendUint16(b, 1)
+		b = entry(b, 0x0002, 5, 0, 0)
+		b = le.AppendUint32(b, 0)
+	}
+	return append(b, values...)
+}
+
+func TestMatchesFilter_Exif(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	photo := filepath.Join(dir, "IMG_0001.tif")
+	require.NoError(t, os.WriteFile(photo, exifTIFF("Canon EOS R6", "2024:07:14 18:30:05", true), 0o644))
+	plain := createTempFile(t, dir, "notes.txt")
+	info, err := os.Stat(photo)
+	require.NoError(t, err)
+
+	yes, no := true, false
+	day := func(s string) time.Time {
+		d, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		require.NoError(t, err)
+		return d
+	}
+	cases := []struct {
+		name string
+		exif models.ExifFilter
+		want bool
+	}{
+		{"camera glob", models.ExifFilter{Camera: "*eos*"}, true},
+		{"camera miss", models.ExifFilter{Camera: "NIKON*"}, false},
+		{"taken inside range", models.ExifFilter{After: day("2024-07-01"), Before: day("2024-08-01")}, true},
+		{"taken before range", models.ExifFilter{After: day("2024-07-15")}, false},
+		{"before is exclusive", models.ExifFilter{Before: day("2024-07-14")}, false},
+		{"has gps", models.ExifFilter{HasGPS: &yes}, true},
+		{"without gps", models.ExifFilter{HasGPS: &no}, false},
+	}
+	for _, tt := range cases {
+		assert.Equal(t, tt.want, MatchesFilter(models.CategoryFilter{Exif: &tt.exif}, photo, info), tt.name)
+	}
+
+	assert.False(t, MatchesFilter(models.CategoryFilter{Exif: &models.ExifFilter{Camera: "*"}}, plain, info), "no EXIF fails a camera rule")
+	assert.True(t, MatchesFilter(models.CategoryFilter{Exif: &models.ExifFilter{HasGPS: &no}}, plain, info), "no EXIF counts as no GPS")
+}
+
+// flacFile builds a minimal FLAC stream holding the given Vorbis comments.
+func flacFile(comments ...string) []byte {
+	var vc bytes.Buffer
+	str := func(s string) {
+		_ = binary.Write(&vc, binary.LittleEndian, uint32(len(s)))
+		vc.WriteString(s)
+	}
+	str("test")
+	_ = binary.Write(&vc, binary.LittleEndian, uint32(len(comments)))
+	for _, c := range comments {
+		str(c)
+	}
+	n := vc.Len()
+	out := append([]byte("fLaC"), 0x80|4, byte(n>>16), byte(n>>8), byte(n))
+	return append(out, vc.Bytes()...)
+}
+
+func TestMatchesFilter_Tags(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	song := filepath.Join(dir, "01.flac")
+	require.NoError(t, os.WriteFile(song, flacFile("ARTIST=John Coltrane", "ALBUMARTIST=Various Artists", "ALBUM=Blue Train", "GENRE=Jazz"), 0o644))
+	plain := createTempFile(t, dir, "notes.txt")
+	info, err := os.Stat(song)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name string
+		tags models.TagsFilter
+		want bool
+	}{
+		{"artist glob", models.TagsFilter{Artist: "john*"}, true},
+		{"album artist", models.TagsFilter{Artist: "various*"}, true},
+		{"artist miss", models.TagsFilter{Artist: "Miles*"}, false},
+		{"album", models.TagsFilter{Album: "blue train"}, true},
+		{"genre", models.TagsFilter{Genre: "*JAZZ*"}, true},
+		{"genre miss", models.TagsFilter{Genre: "Rock"}, false},
+		{"all rules", models.TagsFilter{Artist: "*coltrane", Genre: "jazz"}, true},
+	}
+	for _, tt := range cases {
+		assert.Equal(t, tt.want, MatchesFilter(models.CategoryFilter{Tags: &tt.tags}, song, info), tt.name)
+	}
+
+	assert.False(t, MatchesFilter(models.CategoryFilter{Tags: &models.TagsFilter{Artist: "*"}}, plain, info), "no tags fails an artist rule")
+}
+
+// mp4File builds a minimal MP4 file: a movie header with the given duration
+// and one video track of the given size.
+func mp4File(duration time.Duration, width, height uint32) []byte {
+	box := func(typ string, payload []byte) []byte {
+		out := binary.BigEndian.AppendUint32(nil, uint32(8+len(payload)))
+		return append(append(out, typ...), payload...)
+	}
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)
+	binary.BigEndian.PutUint32(mvhd[16:], uint32(duration.Milliseconds()))
+	tkhd := make([]byte, 84)
+	binary.BigEndian.PutUint32(tkhd[76:], width<<16)
+	binary.BigEndian.PutUint32(tkhd[80:], height<<16)
+	moov := box("moov", append(box("mvhd", mvhd), box("trak", box("tkhd", tkhd))...))
+	return append(box("ftyp", []byte("mp42\x00\x00\x00\x00isom")), moov...)
+}
+
+func TestMatchesFilter_Video(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	clip := filepath.Join(dir, "clip.mp4")
+	require.NoError(t, os.WriteFile(clip, mp4File(90*time.Second, 1280, 720), 0o644))
+	plain := createTempFile(t, dir, "notes.txt")
+	info, err := os.Stat(clip)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name  string
+		video models.VideoFilter
+		want  bool
+	}{
+		{"inside duration range", models.VideoFilter{MinDuration: time.Minute, MaxDuration: 2 * time.Minute}, true},
+		{"too short", models.VideoFilter{MinDuration: 2 * time.Minute}, false},
+		{"too long", models.VideoFilter{MaxDuration: time.Minute}, false},
+		{"tall enough", models.VideoFilter{MinHeight: 720}, true},
+		{"not tall enough", models.VideoFilter{MinHeight: 1080}, false},
+	}
+	for _, tt := range cases {
+		assert.Equal(t, tt.want, MatchesFilter(models.CategoryFilter{Video: &tt.video}, clip, info), tt.name)
+	}
+
+	assert.False(t, MatchesFilter(models.CategoryFilter{Video: &models.VideoFilter{}}, plain, info), "a file that is not a video fails")
+}
+
+func TestMatchesFilter_AgeFrom(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "IMG_20200105_101500.jpg")
+	require.NoError(t, os.WriteFile(path, []byte("not a photo"), 0o644))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	plain := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(plain, []byte("x"), 0o644))
+	plainInfo, err := os.Stat(plain)
+	require.NoError(t, err)
+
+	old := func(from models.DateSource) models.CategoryFilter {
+		return models.CategoryFilter{Age: &models.AgeFilter{Min: 365 * 24 * time.Hour, From: from}}
+	}
+	assert.True(t, MatchesFilter(old(models.DateSourceName), path, info), "the name date is years old")
+	assert.False(t, MatchesFilter(old(models.DateSourceMtime), path, info), "the file was just written")
+	assert.False(t, MatchesFilter(old(""), path, info), "mtime is the default")
+	assert.False(t, MatchesFilter(old(models.DateSourceExif), path, info), "no EXIF falls back to mtime")
+	assert.False(t, MatchesFilter(old(models.DateSourceName), plain, plainInfo), "no date in the name falls back to mtime")
+
+	recent := models.CategoryFilter{Age: &models.AgeFilter{Max: time.Hour, From: models.DateSourceName}}
+	assert.False(t, MatchesFilter(recent, path, info))
+
+	scan := filepath.Join(dir, "scan 05.01.2020.pdf")
+	require.NoError(t, os.WriteFile(scan, []byte("x"), 0o644))
+	scanInfo, err := os.Stat(scan)
+	require.NoError(t, err)
+	byName := old(models.DateSourceName)
+	assert.False(t, MatchesFilter(byName, scan, scanInfo), "no built-in pattern reads dd.mm.yyyy")
+	byName.Age.NamePatterns = []*regexp.Regexp{regexp.MustCompile(`(?P<day>\d{2})\.(?P<month>\d{2})\.(?P<year>\d{4})`)}
+	assert.True(t, MatchesFilter(byName, scan, scanInfo), "custom patterns are tried")
+}
@@ -0,0 +1,76 @@
+package filters
+
+import (
+	gpath "path"
+	"path/filepath"
+	"strings"
+
+	"github.com/lucasassuncao/movelooper/internal/models"
+)
+
+// RelPath returns path relative to root with forward slashes, the form path
+// globs and regexes are matched against. A path outside root, or an empty
+// root, yields the base name.
+func RelPath(root, path string) string {
+	if root != "" {
+		rel, err := filepath.Rel(root, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.Base(path)
+}
+
+// MatchesPathGlob reports whether relPath, a slash-separated relative path,
+// matches the glob pattern as a whole. * and ? never cross a /, while a **
+// segment matches zero or more directories, so "**/node_modules/**" matches
+// anything under a node_modules folder at any depth. Brace expansion works as
+// in MatchesGlob.
+func MatchesPathGlob(relPath, pattern string, caseSensitive bool) bool {
+	name := strings.Split(normalizeCase(relPath, caseSensitive), "/")
+	for _, p := range expandGlobPattern(normalizeCase(pattern, caseSensitive)) {
+		if matchSegments(strings.Split(p, "/"), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches path segments against pattern segments, letting a
+// ** segment absorb any number of path segments.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for len(pattern) > 1 && pattern[1] == "**" {
+				pattern = pattern[1:]
+			}
+			for i := range len(name) + 1 {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := gpath.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// matchesPathFilter reports whether the file at path passes p, matching its
+// path relative to p.Root.
+func matchesPathFilter(p *models.PathFilter, path string) bool {
+	if p == nil {
+		return true
+	}
+	rel := RelPath(p.Root, path)
+	if p.CompiledRegex != nil && !p.CompiledRegex.MatchString(rel) {
+		return false
+	}
+	return p.Glob == "" || MatchesPathGlob(rel, p.Glob, p.CaseSensitive)
+}
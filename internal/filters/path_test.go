@@ -0,0 +1,68 @@
+package filters
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/lucasassuncao/movelooper/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchesPathGlob(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		path, pattern string
+		want          bool
+	}{
+		{"raw/IMG_0001.jpg", "raw/*", true},
+		{"raw/2024/IMG_0001.jpg", "raw/*", false},
+		{"raw/2024/IMG_0001.jpg", "raw/**", true},
+		{"raw/2024/IMG_0001.jpg", "raw/**/*.jpg", true},
+		{"raw/IMG_0001.jpg", "raw/**/*.jpg", true},
+		{"IMG_0001.jpg", "**/*.jpg", true},
+		{"a/b/c/IMG_0001.jpg", "**/*.jpg", true},
+		{"a/node_modules/x/y.js", "**/node_modules/**", true},
+		{"node_modules", "**/node_modules", true},
+		{"a/node_modules_old/y.js", "**/node_modules/**", false},
+		{"a/b/report.pdf", "*/report.pdf", false},
+		{"a/b/report.pdf", "a/**/**/report.pdf", true},
+		{"Photos/Raw/x.CR2", "photos/raw/*.{cr2,nef}", true},
+		{"other/x.jpg", "raw/**", false},
+	}
+	for _, tt := range cases {
+		assert.Equal(t, tt.want, MatchesPathGlob(tt.path, tt.pattern, false), "%s ~ %s", tt.path, tt.pattern)
+	}
+	assert.False(t, MatchesPathGlob("Raw/x.jpg", "raw/*", true), "case-sensitive")
+}
+
+func TestRelPath(t *testing.T) {
+	t.Parallel()
+	root := filepath.FromSlash("/src")
+	assert.Equal(t, "raw/a.jpg", RelPath(root, filepath.FromSlash("/src/raw/a.jpg")))
+	assert.Equal(t, "a.jpg", RelPath(root, filepath.FromSlash("/src/a.jpg")))
+	assert.Equal(t, "a.jpg", RelPath(root, filepath.FromSlash("/elsewhere/a.jpg")), "outside the root")
+	assert.Equal(t, "a.jpg", RelPath("", filepath.FromSlash("/src/raw/a.jpg")), "no root")
+}
+
+func TestMatchesFilter_Path(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	path := filepath.Join(root, "raw", "2024", "IMG_0001.jpg")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte("x"), 0o644))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	glob := func(g string) models.CategoryFilter {
+		return models.CategoryFilter{Path: &models.PathFilter{Glob: g, Root: root}}
+	}
+	assert.True(t, MatchesFilter(glob("raw/**"), path, info))
+	assert.False(t, MatchesFilter(glob("edited/**"), path, info))
+	assert.False(t, MatchesFilter(models.CategoryFilter{Not: []models.CategoryFilter{glob("**/2024/**")}}, path, info))
+
+	re := models.CategoryFilter{Path: &models.PathFilter{CompiledRegex: regexp.MustCompile(`^raw/\d{4}/`), Root: root}}
+	assert.True(t, MatchesFilter(re, path, info))
+}
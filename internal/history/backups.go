@@ -0,0 +1,66 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// SetBackupDir turns on the backup store for destinations replaced by a
+// conflict strategy. Each batch keeps its backups in dir/<batch id>, and that
+// directory is deleted when the batch is pruned from history. An empty dir
+// turns the store off.
+func (h *History) SetBackupDir(dir string) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.backupDir = dir
+}
+
+// BackupDir returns the root of the backup store, or "" when it is off. It is
+// safe to call on a nil History.
+func (h *History) BackupDir() string {
+	if h == nil {
+		return ""
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.backupDir
+}
+
+// batchBackupPath returns the backup directory of batchID, or "" when the store
+// is off or the ID could escape the store (history files can be hand-edited).
+// Callers must hold h.mu.
+func (h *History) batchBackupPath(batchID string) string {
+	if h.backupDir == "" || batchID == "" || batchID == "." || batchID == ".." || filepath.Base(batchID) != batchID {
+		return ""
+	}
+	return filepath.Join(h.backupDir, batchID)
+}
+
+// dropBackups deletes the backup directories of batches that left history
+// through pruning; the files they held can no longer be restored by undo.
+// Callers must hold h.mu.
+func (h *History) dropBackups(batchIDs []string) {
+	for _, id := range batchIDs {
+		if dir := h.batchBackupPath(id); dir != "" {
+			_ = os.RemoveAll(dir)
+		}
+	}
+}
+
+// tidyBackups removes the backup directories of batches that no longer have
+// entries, but only when they are empty: undo moves backups back into place,
+// and a file it failed to restore must survive for a manual recovery.
+// Callers must hold h.mu.
+func (h *History) tidyBackups(batchIDs []string) {
+	for _, id := range batchIDs {
+		if h.store.liveCount(id) > 0 {
+			continue
+		}
+		if dir := h.batchBackupPath(id); dir != "" {
+			_ = os.Remove(dir) // fails, and keeps the directory, when it is not empty
+		}
+	}
+}
@@ -1,14 +1,13 @@
 package history
 
 import (
-	"bufio"
-	"bytes"
 	"crypto/rand"
 	"encoding/hex"
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -29,25 +28,67 @@ func newBatchID(prefix string) string {
 	return prefix + "_" + hex.EncodeToString(b)
 }
 
+// newEntryID returns a random ID for an entry, unique within its batch.
+func newEntryID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
 // Entry represents a single file operation
 type Entry struct {
 	Source      string    `json:"source"`
 	Destination string    `json:"destination"`
 	Timestamp   time.Time `json:"timestamp"`
 	BatchID     string    `json:"batch_id"`
-	Action      string    `json:"action"`
-	Category    string    `json:"category"`
+	// ID tells apart the entries of one batch, which may record the same
+	// source twice: a watch session can see a file arrive under a name it
+	// already moved. AddBatch assigns it.
+	ID       string `json:"id,omitempty"`
+	Action   string `json:"action"`
+	Category string `json:"category"`
+	// Label describes the batch for people, such as the watch session that
+	// recorded it. Empty for one-shot batches.
+	Label string `json:"label,omitempty"`
+	// Members lists the files packed by an archive entry, so undo can extract
+	// them back. Empty for every other action, and for archive entries
+	// recorded before member tracking existed.
+	Members []ArchiveMember `json:"members,omitempty"`
+	// Replaced is the backup-store path of the file this entry's destination
+	// overwrote, when the conflict strategy replaced one and backups are on.
+	// Undo moves it back to Destination after restoring the entry.
+	Replaced string `json:"replaced,omitempty"`
+	// UndoneAt is when the entry was undone. It is only set on entries waiting
+	// on the redo stack.
+	UndoneAt time.Time `json:"undone_at,omitzero"`
+	// Size, ModTime and SHA256 fingerprint the file at Destination right after
+	// it was placed; see Stamp and Verify. They are empty for symlinks, and for
+	// entries recorded before fingerprints existed. SHA256 is only recorded
+	// with history.hash on.
+	Size    int64     `json:"size,omitempty"`
+	ModTime time.Time `json:"mtime,omitzero"`
+	SHA256  string    `json:"sha256,omitempty"`
 }
 
-// Recorder records file operations for undo. *History saves to disk on every
-// Add; Buffer collects entries in memory for a single save per batch.
+// ArchiveMember is one file inside an archive recorded in history: where it
+// came from, its name inside the archive, and its size when it was packed.
+type ArchiveMember struct {
+	Source string `json:"source"`
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+}
+
+// Recorder records file operations for undo. *History appends to disk on
+// every Add; Buffer collects entries in memory for a single append per batch.
 type Recorder interface {
 	Add(Entry) error
 }
 
 // Buffer is a Recorder that collects entries in memory. Flush writes them all
-// to a History in one save, turning one full-file rewrite per moved file into
-// one rewrite per batch. Not safe for concurrent use.
+// to a History in one append, turning one locked write per moved file into
+// one per batch. Not safe for concurrent use.
 type Buffer struct {
 	entries []Entry
 }
@@ -61,7 +102,7 @@ func (b *Buffer) Add(entry Entry) error {
 // Len returns the number of buffered entries.
 func (b *Buffer) Len() int { return len(b.entries) }
 
-// Flush writes the buffered entries to h in a single save and empties the buffer.
+// Flush writes the buffered entries to h in a single append and empties the buffer.
 func (b *Buffer) Flush(h *History) error {
 	entries := b.entries
 	b.entries = nil
@@ -70,26 +111,30 @@ func (b *Buffer) Flush(h *History) error {
 
 // History manages the log of file operations.
 //
+// Entries are kept in a segmented append-only log next to the configured
+// history file (see store.go): recording a file appends one line instead of
+// rewriting the whole history, and only an index of the batches is held in
+// memory. A history file from an earlier version is migrated into the log on
+// first load.
+//
 // The mutex only guards access within one process. Two movelooper processes
 // writing at the same time (e.g. a watch daemon plus a one-shot run) are
 // additionally serialized by an OS-level lock on a sidecar ".lock" file (see
-// lock.go): every mutating method reloads h.entries from disk while holding
-// that lock, so a write from the other process is never silently overwritten.
-// The save itself is also atomic (temp file + rename), so the file is never
-// corrupted, only potentially incomplete if a process is killed mid-write.
+// lock.go): every method syncs the store with disk while holding that lock,
+// so each process sees, and builds on, the other's writes.
 type History struct {
 	mu         sync.Mutex
-	entries    []Entry
-	batchDeque []string       // batch IDs in insertion order, no duplicates
-	batchCount map[string]int // number of entries per batch ID
+	store      store
 	path       string
 	lockPath   string
 	maxBatches int
+	backupDir  string // root of the replaced-file store; "" when off
 }
 
-// NewHistory creates a new History manager. path is the file where history is
-// persisted; limit controls the maximum number of batches retained (values
-// less than 1 fall back to defaultMaxBatches).
+// NewHistory creates a new History manager. path is the history file; the
+// log itself lives in a directory derived from it (movelooper.json keeps its
+// log in movelooper.d). limit controls the maximum number of batches retained
+// (values less than 1 fall back to defaultMaxBatches).
 func NewHistory(path string, limit int) (*History, error) {
 	if limit < 1 {
 		limit = defaultMaxBatches
@@ -100,68 +145,64 @@ func NewHistory(path string, limit int) (*History, error) {
 	}
 
 	h := &History{
+		store:      newLogStore(storeDir(path), path),
 		path:       path,
 		lockPath:   path + ".lock",
 		maxBatches: limit,
-		batchCount: make(map[string]int),
 	}
 
-	if err := h.load(); err != nil {
-		if !os.IsNotExist(err) {
-			return nil, err
-		}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.withFileLock(func() error { return nil }); err != nil {
+		return nil, err
 	}
-
 	return h, nil
 }
 
-// withFileLock acquires an OS-level exclusive lock on h.lockPath, reloads
-// h.entries from disk so this process sees any writes made by another
-// movelooper process since it last read the file, then runs fn (which mutates
-// h.entries and calls h.save()). Must be called with h.mu already held.
+// withFileLock acquires an OS-level exclusive lock on h.lockPath, syncs the
+// store so this process sees any records appended by another movelooper
+// process, then runs fn. Must be called with h.mu already held.
 //
 // If the lock file itself cannot be opened (e.g. a read-only filesystem),
-// locking is skipped and fn runs against whatever h.entries already holds —
-// a best-effort fallback rather than breaking history tracking entirely.
+// fn runs after an unlocked sync — a best-effort fallback rather than
+// breaking history tracking entirely.
 func (h *History) withFileLock(fn func() error) error {
 	lock, err := acquireFileLock(h.lockPath)
 	if err != nil {
+		if err := h.store.sync(); err != nil {
+			return err
+		}
 		return fn()
 	}
-	if err := h.load(); err != nil && !os.IsNotExist(err) {
-		_ = lock.release()
-		return err
+	fnErr := h.store.sync()
+	if fnErr == nil {
+		fnErr = fn()
 	}
-	fnErr := fn()
 	if relErr := lock.release(); relErr != nil && fnErr == nil {
 		return relErr
 	}
 	return fnErr
 }
 
-// rebuildIndex reconstructs batchDeque and batchCount from h.entries.
-// Must be called with h.mu held, or before the History is shared.
-func (h *History) rebuildIndex() {
-	h.batchDeque = nil
-	h.batchCount = make(map[string]int, len(h.entries))
-	for _, e := range h.entries {
-		if h.batchCount[e.BatchID] == 0 {
-			h.batchDeque = append(h.batchDeque, e.BatchID)
-		}
-		h.batchCount[e.BatchID]++
-	}
+// view runs fn against a store synced with disk, for methods that only read.
+// When the sync fails, fn does not run and the caller returns its zero result.
+func (h *History) view(fn func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_ = h.withFileLock(func() error {
+		fn()
+		return nil
+	})
 }
 
-// Add records a new entry: it updates the in-memory state, prunes old batches
-// past the limit, and rewrites the whole history file as an indented JSON array
-// via an atomic temp-file rename. When recording many entries in one operation,
-// prefer AddBatch (or a Buffer) to avoid one full rewrite per entry.
+// Add records a new entry and prunes old batches past the limit. When
+// recording many entries in one operation, prefer AddBatch (or a Buffer) to
+// take the file lock once rather than once per entry.
 func (h *History) Add(entry Entry) error {
 	return h.AddBatch([]Entry{entry})
 }
 
-// AddBatch records several entries with a single save, avoiding the quadratic I/O
-// of rewriting the whole history file once per moved file. A nil or empty slice
+// AddBatch records several entries with a single append. A nil or empty slice
 // is a no-op.
 func (h *History) AddBatch(entries []Entry) error {
 	if len(entries) == 0 {
@@ -171,44 +212,41 @@ func (h *History) AddBatch(entries []Entry) error {
 	defer h.mu.Unlock()
 
 	return h.withFileLock(func() error {
-		if h.batchCount == nil {
-			h.batchCount = make(map[string]int)
-		}
-		for _, entry := range entries {
-			h.entries = append(h.entries, entry)
-			if h.batchCount[entry.BatchID] == 0 {
-				h.batchDeque = append(h.batchDeque, entry.BatchID)
+		recs := make([]record, 0, len(entries))
+		for _, e := range entries {
+			if e.ID == "" {
+				e.ID = newEntryID()
 			}
-			h.batchCount[entry.BatchID]++
+			recs = append(recs, record{Op: opAdd, Entry: e})
 		}
-
-		h.prune()
-		return h.save()
+		if err := h.store.apply(recs); err != nil {
+			return err
+		}
+		return h.prune()
 	})
 }
 
-// prune removes the oldest batches, keeping at most maxBatches.
-// Uses batchDeque for an O(1) limit check; only scans entries when pruning.
-func (h *History) prune() {
-	if len(h.batchDeque) <= h.maxBatches {
-		return
+// prune drops the oldest batches, keeping at most maxBatches, deletes their
+// backups and compacts the log. Callers must hold h.mu and the file lock.
+func (h *History) prune() error {
+	batches := h.store.batches()
+	if len(batches) <= h.maxBatches {
+		return nil
 	}
 
-	excess := len(h.batchDeque) - h.maxBatches
-	toRemove := make(map[string]bool, excess)
-	for _, id := range h.batchDeque[:excess] {
-		toRemove[id] = true
-		delete(h.batchCount, id)
+	excess := batches[:len(batches)-h.maxBatches]
+	pruned := make([]string, 0, len(excess))
+	recs := make([]record, 0, len(excess))
+	for _, b := range excess {
+		pruned = append(pruned, b.BatchID)
+		recs = append(recs, record{Op: opDrop, Entry: Entry{BatchID: b.BatchID}})
 	}
-	h.batchDeque = h.batchDeque[excess:]
-
-	newEntries := make([]Entry, 0, len(h.entries))
-	for _, e := range h.entries {
-		if !toRemove[e.BatchID] {
-			newEntries = append(newEntries, e)
-		}
+	if err := h.store.apply(recs); err != nil {
+		return err
 	}
-	h.entries = newEntries
+	h.dropBackups(pruned)
+	_ = h.store.compact() // a failed compaction only leaves garbage for the next one
+	return nil
 }
 
 // BatchSummary holds a brief description of a batch for listing purposes
@@ -216,42 +254,28 @@ type BatchSummary struct {
 	BatchID   string
 	Count     int
 	Timestamp time.Time
+	// Label is the label of the batch's first labelled entry, if any.
+	Label string
+	// Categories lists the categories of the entries added to the batch, in
+	// the order they first appeared.
+	Categories []string
 }
 
 // GetAllBatches returns one summary per batch, ordered oldest → newest
 func (h *History) GetAllBatches() []BatchSummary {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	firstTimestamp := make(map[string]time.Time, len(h.batchDeque))
-	for _, e := range h.entries {
-		if _, ok := firstTimestamp[e.BatchID]; !ok {
-			firstTimestamp[e.BatchID] = e.Timestamp
-		}
-	}
-
-	summaries := make([]BatchSummary, 0, len(h.batchDeque))
-	for _, id := range h.batchDeque {
-		summaries = append(summaries, BatchSummary{
-			BatchID:   id,
-			Count:     h.batchCount[id],
-			Timestamp: firstTimestamp[id],
-		})
-	}
+	var summaries []BatchSummary
+	h.view(func() { summaries = h.store.batches() })
 	return summaries
 }
 
 // GetBatch returns all entries for a given batch ID
 func (h *History) GetBatch(batchID string) []Entry {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
 	batch := make([]Entry, 0)
-	for _, entry := range h.entries {
-		if entry.BatchID == batchID {
-			batch = append(batch, entry)
+	h.view(func() {
+		if live, _, err := h.store.read(batchID); err == nil && live != nil {
+			batch = live
 		}
-	}
+	})
 	return batch
 }
 
@@ -261,21 +285,13 @@ func (h *History) RemoveBatch(batchID string) error {
 	defer h.mu.Unlock()
 
 	return h.withFileLock(func() error {
-		newEntries := make([]Entry, 0, len(h.entries))
-		for _, entry := range h.entries {
-			if entry.BatchID != batchID {
-				newEntries = append(newEntries, entry)
+		if h.store.liveCount(batchID) > 0 {
+			if err := h.store.apply([]record{{Op: opDrop, Entry: Entry{BatchID: batchID}}}); err != nil {
+				return err
 			}
+			_ = h.store.compact()
 		}
-
-		original := h.entries
-		h.entries = newEntries
-		if err := h.save(); err != nil {
-			h.entries = original
-			h.rebuildIndex()
-			return err
-		}
-		h.rebuildIndex()
+		h.tidyBackups([]string{batchID})
 		return nil
 	})
 }
@@ -295,120 +311,93 @@ func (h *History) RemoveCategoryFromBatch(batchID string, categories []string) (
 
 	var removed int
 	err := h.withFileLock(func() error {
-		newEntries := make([]Entry, 0, len(h.entries))
-		for _, e := range h.entries {
-			if e.BatchID == batchID && e.Category != "" && catSet[e.Category] {
-				continue
+		live, _, err := h.store.read(batchID)
+		if err != nil {
+			return err
+		}
+		var recs []record
+		for _, e := range live {
+			if e.Category != "" && catSet[e.Category] {
+				recs = append(recs, keyRecord(opRemove, e))
 			}
-			newEntries = append(newEntries, e)
 		}
-
-		removed = len(h.entries) - len(newEntries)
-		original := h.entries
-		h.entries = newEntries
-		if err := h.save(); err != nil {
-			h.entries = original
-			h.rebuildIndex()
-			removed = 0
+		if err := h.store.apply(recs); err != nil {
 			return err
 		}
-		h.rebuildIndex()
+		removed = len(recs)
+		if removed > 0 {
+			_ = h.store.compact()
+		}
+		h.tidyBackups([]string{batchID})
 		return nil
 	})
 	return removed, err
 }
 
-// RemoveEntries removes specific entries from history, matched by BatchID and Source path.
+// RemoveEntries removes specific entries from history, matched by BatchID and
+// ID, or by source for entries without an ID.
 // Only successfully restored entries should be passed so failed restores remain in history.
 func (h *History) RemoveEntries(entries []Entry) error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	toRemove := make(map[string]bool, len(entries))
-	for _, e := range entries {
-		toRemove[e.BatchID+"\x00"+e.Source] = true
-	}
-
 	return h.withFileLock(func() error {
-		newEntries := make([]Entry, 0, len(h.entries))
-		for _, e := range h.entries {
-			if !toRemove[e.BatchID+"\x00"+e.Source] {
-				newEntries = append(newEntries, e)
-			}
+		recs, batches, err := h.keyedRecords(entries, opRemove, false)
+		if err != nil {
+			return err
 		}
-
-		original := h.entries
-		h.entries = newEntries
-		if err := h.save(); err != nil {
-			h.entries = original
-			h.rebuildIndex()
+		if err := h.store.apply(recs); err != nil {
 			return err
 		}
-		h.rebuildIndex()
+		if len(recs) > 0 {
+			_ = h.store.compact()
+		}
+		h.tidyBackups(batches)
 		return nil
 	})
 }
 
-// save writes h.entries to disk atomically using a temp file + rename, as an
-// indented JSON array (2-space). Callers must hold h.mu.
-func (h *History) save() error {
-	entries := h.entries
-	if entries == nil {
-		entries = []Entry{}
-	}
-	data, err := json.MarshalIndent(entries, "", "  ")
-	if err != nil {
-		return err
-	}
-	tmp := h.path + ".tmp"
-	if err := os.WriteFile(tmp, data, 0o600); err != nil { //#nosec G304 -- path is set by the application at startup from config, not from user input
-		return err
-	}
-	return os.Rename(tmp, h.path)
-}
-
-// load reads the history file into h.entries. It supports two formats:
-//   - JSON array (current): the whole file is an indented array, detected by a
-//     leading '['.
-//   - NDJSON (legacy): one JSON object per line, written by an earlier version;
-//     malformed lines are skipped to tolerate a partial write from a crash.
-//
-// Callers must hold h.mu or call before the History is shared.
-func (h *History) load() error {
-	data, err := os.ReadFile(h.path)
-	if err != nil {
-		return err
-	}
-
-	// current: whole-file JSON array
-	if content := bytes.TrimSpace(data); len(content) > 0 && content[0] == '[' {
-		var entries []Entry
-		if err := json.Unmarshal(data, &entries); err != nil {
-			return err
+// keyedRecords returns a record of op for each of entries still present in
+// its batch: among the live entries, or the undone ones when fromRedo is set.
+// An entry is matched by its ID or, without one, by its source. Entries
+// already gone are skipped, which keeps the store's counts exact. Also
+// returns the batches involved. Callers must hold h.mu and the file lock.
+func (h *History) keyedRecords(entries []Entry, op string, fromRedo bool) ([]record, []string, error) {
+	present := make(map[string][]Entry)
+	var batches []string
+	for _, e := range entries {
+		if _, ok := present[e.BatchID]; ok {
+			continue
 		}
-		h.entries = entries
-		h.rebuildIndex()
-		return nil
+		batches = append(batches, e.BatchID)
+		live, undone, err := h.store.read(e.BatchID)
+		if err != nil {
+			return nil, nil, err
+		}
+		list := live
+		if fromRedo {
+			list = undone
+		}
+		present[e.BatchID] = slices.Clip(list)
 	}
 
-	// legacy NDJSON: skip malformed lines (e.g. partial last line after a crash)
-	sc := bufio.NewScanner(bytes.NewReader(data))
-	var entries []Entry
-	for sc.Scan() {
-		line := bytes.TrimSpace(sc.Bytes())
-		if len(line) == 0 {
+	recs := make([]record, 0, len(entries))
+	for _, e := range entries {
+		i := slices.IndexFunc(present[e.BatchID], func(le Entry) bool {
+			if e.ID != "" {
+				return le.ID == e.ID
+			}
+			return le.Source == e.Source
+		})
+		if i < 0 {
 			continue
 		}
-		var e Entry
-		if err := json.Unmarshal(line, &e); err != nil {
-			continue
+		rec := keyRecord(op, present[e.BatchID][i])
+		present[e.BatchID] = slices.Delete(present[e.BatchID], i, i+1)
+		if op == opRedo {
+			rec.Timestamp = e.Timestamp
 		}
-		entries = append(entries, e)
+		recs = append(recs, rec)
 	}
-	if err := sc.Err(); err != nil {
-		return err
-	}
-	h.entries = entries
-	h.rebuildIndex()
-	return nil
+	return recs, batches, nil
 }
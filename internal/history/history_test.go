@@ -1,7 +1,6 @@
 package history
 
 import (
-	"bytes"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -18,11 +17,9 @@ func newTestHistory(t *testing.T, maxBatches int) *History {
 	if maxBatches < 1 {
 		maxBatches = defaultMaxBatches
 	}
-	return &History{
-		path:       filepath.Join(t.TempDir(), "movelooper.json"),
-		maxBatches: maxBatches,
-		batchCount: make(map[string]int),
-	}
+	h, err := NewHistory(filepath.Join(t.TempDir(), "movelooper.json"), maxBatches)
+	require.NoError(t, err)
+	return h
 }
 
 func makeEntry(batchID string) Entry {
@@ -68,7 +65,10 @@ func TestAdd(t *testing.T) {
 			t.Parallel()
 			h := newTestHistory(t, 10)
 			if tt.badPath {
-				h.path = t.TempDir()
+				// Replace the log directory with a file so nothing can be written.
+				dir := storeDir(h.path)
+				require.NoError(t, os.RemoveAll(dir))
+				require.NoError(t, os.WriteFile(dir, nil, 0o600))
 			}
 
 			var lastErr error
@@ -82,9 +82,9 @@ func TestAdd(t *testing.T) {
 			}
 			require.NoError(t, lastErr)
 
-			h2 := &History{path: h.path, maxBatches: h.maxBatches}
-			require.NoError(t, h2.load())
-			assert.Len(t, h2.entries, tt.wantLen)
+			h2, err := NewHistory(h.path, h.maxBatches)
+			require.NoError(t, err)
+			assert.Len(t, h2.Search(Query{}), tt.wantLen)
 		})
 	}
 }
@@ -184,9 +184,9 @@ var testRemoveBatchTestCases = []testRemoveBatch{
 			assert.Empty(t, h.GetBatch("batch_1"))
 			assert.Len(t, h.GetBatch("batch_2"), 1)
 
-			h2 := &History{path: h.path, maxBatches: h.maxBatches}
-			require.NoError(t, h2.load())
-			for _, e := range h2.entries {
+			h2, err := NewHistory(h.path, h.maxBatches)
+			require.NoError(t, err)
+			for _, e := range h2.Search(Query{}) {
 				assert.NotEqual(t, "batch_1", e.BatchID)
 			}
 		},
@@ -307,34 +307,48 @@ func TestBatchIDs(t *testing.T) {
 	}
 }
 
-// testLoad defines the structure for test cases of the load function,
-// containing setup logic, expected entry count, error expectation, and a non-existent path flag.
-type testLoad struct {
+// testReadLegacy defines the structure for test cases of the readLegacy function,
+// containing the file content, expected entry counts, error expectation, and a non-existent path flag.
+type testReadLegacy struct {
 	name     string
-	setup    func(t *testing.T, h *History)
+	content  func(t *testing.T) []byte
 	wantLen  int
+	wantRedo int
 	wantErr  bool
 	notExist bool
 }
 
-// testLoadTestCases defines a set of test cases for the load function,
-// covering valid JSON, corrupt JSON, and non-existent file scenarios.
-var testLoadTestCases = []testLoad{
+// testReadLegacyTestCases defines a set of test cases for the readLegacy function,
+// covering the JSON array, document and NDJSON formats and a non-existent file.
+var testReadLegacyTestCases = []testReadLegacy{
 	{
 		name: "valid json",
-		setup: func(t *testing.T, h *History) {
+		content: func(t *testing.T) []byte {
 			entries := []Entry{{Source: "/src/a.txt", Destination: "/dst/a.txt", Timestamp: time.Now(), BatchID: "batch_1"}}
 			data, err := json.MarshalIndent(entries, "", "  ")
 			require.NoError(t, err)
-			require.NoError(t, os.WriteFile(h.path, data, 0o644))
+			return data
 		},
 		wantLen: 1,
 	},
 	{
-		name: "corrupt ndjson lines are skipped gracefully",
-		setup: func(t *testing.T, h *History) {
-			require.NoError(t, os.WriteFile(h.path, []byte("not valid json {{{"), 0o644))
+		name: "document with redo stack",
+		content: func(*testing.T) []byte {
+			return []byte(`{"entries":[{"source":"/a","batch_id":"b1"}],"redo":[{"source":"/b","batch_id":"b2"}]}`)
 		},
+		wantLen:  1,
+		wantRedo: 1,
+	},
+	{
+		name: "ndjson with a torn last line",
+		content: func(*testing.T) []byte {
+			return []byte("{\"source\":\"/a\",\"batch_id\":\"b1\"}\n{\"source\":\"/b\",\"batch")
+		},
+		wantLen: 1,
+	},
+	{
+		name:    "corrupt ndjson lines are skipped gracefully",
+		content: func(*testing.T) []byte { return []byte("not valid json {{{") },
 		wantLen: 0,
 	},
 	{
@@ -344,75 +358,26 @@ var testLoadTestCases = []testLoad{
 	},
 }
 
-// TestLoad tests the load function to ensure it correctly reads and parses history files.
-func TestLoad(t *testing.T) {
+// TestReadLegacy tests the readLegacy function to ensure it correctly reads and parses history files
+// written before the segmented log.
+func TestReadLegacy(t *testing.T) {
 	t.Parallel()
-	for _, tt := range testLoadTestCases {
+	for _, tt := range testReadLegacyTestCases {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			h := newTestHistory(t, 10)
-			if tt.notExist {
-				h.path = filepath.Join(t.TempDir(), "nonexistent.json")
-			} else if tt.setup != nil {
-				tt.setup(t, h)
+			path := filepath.Join(t.TempDir(), "movelooper.json")
+			if !tt.notExist {
+				require.NoError(t, os.WriteFile(path, tt.content(t), 0o600))
 			}
 
-			err := h.load()
+			entries, redo, err := readLegacy(path)
 			if tt.wantErr {
 				assert.Error(t, err)
 				return
 			}
 			require.NoError(t, err)
-			assert.Len(t, h.entries, tt.wantLen)
-		})
-	}
-}
-
-// testSave defines the structure for test cases of the save function,
-// containing entries to persist and a check function for assertions on the written file.
-type testSave struct {
-	name    string
-	entries []Entry
-	check   func(t *testing.T, data []byte)
-}
-
-// testSaveTestCases defines a set of test cases for the save function,
-// covering entries with data and empty entry arrays.
-var testSaveTestCases = []testSave{
-	{
-		name:    "writes indented json array with entries",
-		entries: []Entry{{Source: "/src/b.txt", Destination: "/dst/b.txt", Timestamp: time.Now(), BatchID: "batch_save"}},
-		check: func(t *testing.T, data []byte) {
-			assert.Equal(t, byte('['), bytes.TrimSpace(data)[0], "file should be a JSON array")
-			assert.Contains(t, string(data), "\n  {", "array should be indented")
-			var loaded []Entry
-			require.NoError(t, json.Unmarshal(data, &loaded))
-			require.Len(t, loaded, 1)
-			assert.Equal(t, "batch_save", loaded[0].BatchID)
-		},
-	},
-	{
-		name:    "empty entries writes empty array",
-		entries: []Entry{},
-		check: func(t *testing.T, data []byte) {
-			assert.Equal(t, "[]", string(bytes.TrimSpace(data)))
-		},
-	},
-}
-
-// TestSave tests the save function to ensure it correctly serializes and writes history entries.
-func TestSave(t *testing.T) {
-	t.Parallel()
-	for _, tt := range testSaveTestCases {
-		t.Run(tt.name, func(t *testing.T) {
-			t.Parallel()
-			h := newTestHistory(t, 10)
-			h.entries = tt.entries
-			require.NoError(t, h.save())
-
-			data, err := os.ReadFile(h.path)
-			require.NoError(t, err)
-			tt.check(t, data)
+			assert.Len(t, entries, tt.wantLen)
+			assert.Len(t, redo, tt.wantRedo)
 		})
 	}
 }
@@ -467,14 +432,15 @@ func TestAddBatch_CrossProcessWritesAreNotLost(t *testing.T) {
 func TestHistory_LoadAndAddRoundTrip(t *testing.T) {
 	t.Parallel()
 	path := filepath.Join(t.TempDir(), "movelooper.json")
-	h := &History{path: path, maxBatches: 10}
+	h, err := NewHistory(path, 10)
+	require.NoError(t, err)
 
 	require.NoError(t, h.Add(makeEntry("batch_1")))
 	require.NoError(t, h.Add(makeEntry("batch_2")))
 
-	h2 := &History{path: path, maxBatches: 10}
-	require.NoError(t, h2.load())
-	assert.Len(t, h2.entries, 2)
+	h2, err := NewHistory(path, 10)
+	require.NoError(t, err)
+	assert.Len(t, h2.Search(Query{}), 2)
 }
 
 // testRemoveCategoryFromBatch defines the structure for test cases of the RemoveCategoryFromBatch function,
@@ -545,9 +511,10 @@ func TestRemoveCategoryFromBatch(t *testing.T) {
 			tt.setup(h)
 			_, err := h.RemoveCategoryFromBatch(tt.batchID, tt.categories)
 			require.NoError(t, err)
-			assert.Len(t, h.entries, tt.wantLen)
+			entries := h.Search(Query{})
+			assert.Len(t, entries, tt.wantLen)
 			hasBatch := false
-			for _, e := range h.entries {
+			for _, e := range entries {
 				if e.BatchID == tt.batchID {
 					hasBatch = true
 					break
@@ -602,3 +569,44 @@ func TestAddBatch_PrunesPastLimit(t *testing.T) {
 	assert.Equal(t, "batch_2", summaries[0].BatchID)
 	assert.Equal(t, "batch_3", summaries[1].BatchID)
 }
+
+// TestBackups_PrunedWithTheirBatch verifies that pruning a batch deletes its
+// backup directory together with its entries.
+func TestBackups_PrunedWithTheirBatch(t *testing.T) {
+	t.Parallel()
+	h := newTestHistory(t, 1)
+	root := t.TempDir()
+	h.SetBackupDir(root)
+	assert.Equal(t, root, h.BackupDir())
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "batch_1"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "batch_1", "f.txt"), []byte("x"), 0o600))
+
+	require.NoError(t, h.Add(Entry{Source: "/a", BatchID: "batch_1"}))
+	assert.DirExists(t, filepath.Join(root, "batch_1"))
+	require.NoError(t, h.Add(Entry{Source: "/b", BatchID: "batch_2"}))
+	assert.NoDirExists(t, filepath.Join(root, "batch_1"), "pruned batch loses its backups")
+}
+
+// TestBackups_RemoveEntriesKeepsUnrestored verifies that removing a batch's
+// last entries deletes its backup directory only when undo emptied it.
+func TestBackups_RemoveEntriesKeepsUnrestored(t *testing.T) {
+	t.Parallel()
+	h := newTestHistory(t, 10)
+	root := t.TempDir()
+	h.SetBackupDir(root)
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "batch_1"), 0o750))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "batch_2"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "batch_2", "f.txt"), []byte("x"), 0o600))
+	require.NoError(t, h.AddBatch([]Entry{{Source: "/a", BatchID: "batch_1"}, {Source: "/b", BatchID: "batch_2"}}))
+
+	require.NoError(t, h.RemoveEntries([]Entry{{Source: "/a", BatchID: "batch_1"}, {Source: "/b", BatchID: "batch_2"}}))
+	assert.NoDirExists(t, filepath.Join(root, "batch_1"))
+	assert.FileExists(t, filepath.Join(root, "batch_2", "f.txt"), "a backup undo did not restore survives")
+}
+
+func TestBackupDir_NilHistory(t *testing.T) {
+	t.Parallel()
+	var h *History
+	assert.Empty(t, h.BackupDir())
+	h.SetBackupDir("/tmp") // must not panic
+}
@@ -0,0 +1,95 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/lucasassuncao/movelooper/internal/tokens"
+)
+
+// Integrity is the outcome of comparing a destination with its fingerprint.
+type Integrity string
+
+const (
+	// IntegrityOK: the destination still matches what was recorded.
+	IntegrityOK Integrity = "ok"
+	// IntegrityMissing: nothing exists at the destination any more.
+	IntegrityMissing Integrity = "missing"
+	// IntegrityModified: the destination was edited or replaced since.
+	IntegrityModified Integrity = "modified"
+	// IntegrityUnverified: the entry has no fingerprint to compare against.
+	IntegrityUnverified Integrity = "unverified"
+)
+
+// Check is the result of Verify. Detail says what differs, for messages.
+type Check struct {
+	Status Integrity
+	Detail string
+}
+
+// Stamp records the size and modification time of the regular file at
+// e.Destination, and its SHA-256 when withHash is set. Symlinks and other
+// non-regular destinations are left without a fingerprint.
+func (e *Entry) Stamp(withHash bool) error {
+	info, err := os.Lstat(e.Destination)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	e.Size = info.Size()
+	e.ModTime = info.ModTime()
+	if withHash {
+		sum, err := tokens.FileSHA256(e.Destination)
+		if err != nil {
+			return err
+		}
+		e.SHA256 = sum
+	}
+	return nil
+}
+
+// Fingerprinted reports whether Stamp recorded anything for the entry.
+func (e Entry) Fingerprinted() bool { return !e.ModTime.IsZero() }
+
+// Verify compares the file at e.Destination with its fingerprint. Size and
+// mtime are compared first; the recorded hash, when there is one, settles an
+// mtime change (a touched but unchanged file is still OK), and deep hashes the
+// file even when size and mtime match.
+func (e Entry) Verify(deep bool) (Check, error) {
+	info, err := os.Lstat(e.Destination)
+	if os.IsNotExist(err) {
+		return Check{Status: IntegrityMissing, Detail: "destination no longer exists"}, nil
+	}
+	if err != nil {
+		return Check{}, err
+	}
+	if !e.Fingerprinted() {
+		return Check{Status: IntegrityUnverified, Detail: "no fingerprint recorded"}, nil
+	}
+	if !info.Mode().IsRegular() {
+		return Check{Status: IntegrityModified, Detail: "destination is no longer a regular file"}, nil
+	}
+	if info.Size() != e.Size {
+		return Check{Status: IntegrityModified, Detail: fmt.Sprintf("size is %d bytes, was %d", info.Size(), e.Size)}, nil
+	}
+
+	sameTime := info.ModTime().Equal(e.ModTime)
+	if e.SHA256 != "" && (deep || !sameTime) {
+		sum, err := tokens.FileSHA256(e.Destination)
+		if err != nil {
+			return Check{}, err
+		}
+		if sum != e.SHA256 {
+			return Check{Status: IntegrityModified, Detail: "content differs from the recorded hash"}, nil
+		}
+		return Check{Status: IntegrityOK}, nil
+	}
+	if !sameTime {
+		return Check{Status: IntegrityModified, Detail: fmt.Sprintf("modified %s, recorded %s",
+			info.ModTime().Format(time.DateTime), e.ModTime.Format(time.DateTime))}, nil
+	}
+	return Check{Status: IntegrityOK}, nil
+}
@@ -0,0 +1,76 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+	later := time.Now().Add(time.Hour)
+
+	cases := []struct {
+		name     string
+		withHash bool
+		deep     bool
+		change   func(t *testing.T, path string)
+		want     Integrity
+	}{
+		{"untouched", false, false, nil, IntegrityOK},
+		{"removed", false, false, func(t *testing.T, p string) { require.NoError(t, os.Remove(p)) }, IntegrityMissing},
+		{"size changed", false, false, func(t *testing.T, p string) {
+			require.NoError(t, os.WriteFile(p, []byte("longer content"), 0o600))
+		}, IntegrityModified},
+		{"touched without hash", false, false, func(t *testing.T, p string) {
+			require.NoError(t, os.Chtimes(p, later, later))
+		}, IntegrityModified},
+		{"touched with matching hash", true, false, func(t *testing.T, p string) {
+			require.NoError(t, os.Chtimes(p, later, later))
+		}, IntegrityOK},
+		{"same size and mtime, edited, deep", true, true, func(t *testing.T, p string) {
+			info, err := os.Stat(p)
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(p, []byte("XXXX"), 0o600))
+			require.NoError(t, os.Chtimes(p, info.ModTime(), info.ModTime()))
+		}, IntegrityModified},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "f.txt")
+			require.NoError(t, os.WriteFile(path, []byte("data"), 0o600))
+			e := Entry{Destination: path}
+			require.NoError(t, e.Stamp(tt.withHash))
+			assert.Equal(t, int64(4), e.Size)
+			assert.Equal(t, tt.withHash, e.SHA256 != "")
+
+			if tt.change != nil {
+				tt.change(t, path)
+			}
+			check, err := e.Verify(tt.deep)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, check.Status, check.Detail)
+		})
+	}
+}
+
+func TestVerify_Unfingerprinted(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	target := filepath.Join(dir, "f.txt")
+	link := filepath.Join(dir, "link")
+	require.NoError(t, os.WriteFile(target, []byte("x"), 0o600))
+	require.NoError(t, os.Symlink(target, link))
+
+	e := Entry{Destination: link}
+	require.NoError(t, e.Stamp(true))
+	assert.False(t, e.Fingerprinted(), "symlinks are not fingerprinted")
+	check, err := e.Verify(false)
+	require.NoError(t, err)
+	assert.Equal(t, IntegrityUnverified, check.Status)
+}
@@ -0,0 +1,136 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+)
+
+// document is the on-disk form of a single-file history that had a non-empty
+// redo stack. A history without one was written as a bare entry array.
+type document struct {
+	Entries []Entry `json:"entries"`
+	Redo    []Entry `json:"redo"`
+}
+
+// readLegacy reads a history file written before the segmented log. It
+// supports three formats:
+//   - JSON array: the whole file is an indented array, detected by a leading
+//     '['.
+//   - Document: an object with "entries" and "redo" arrays, written while the
+//     redo stack was not empty.
+//   - NDJSON: one JSON object per line, written by an earlier version;
+//     malformed lines are skipped to tolerate a partial write from a crash.
+func readLegacy(path string) (entries, redo []Entry, err error) {
+	data, err := os.ReadFile(path) //#nosec G304 -- path is set by the application at startup from config, not from user input
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if content := bytes.TrimSpace(data); len(content) > 0 && content[0] == '[' {
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, nil, err
+		}
+		return entries, nil, nil
+	}
+
+	if doc, ok := unmarshalDocument(data); ok {
+		return doc.Entries, doc.Redo, nil
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, err
+	}
+	return entries, nil, nil
+}
+
+// unmarshalDocument decodes the document form. ok is false when data is a
+// JSON object of another shape, such as the first line of a legacy NDJSON file.
+func unmarshalDocument(data []byte) (doc document, ok bool) {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return document{}, false
+	}
+	if _, hasEntries := probe["entries"]; !hasEntries {
+		return document{}, false
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return document{}, false
+	}
+	return doc, true
+}
+
+// migrate copies the legacy history file into a fresh log, then renames it to
+// path + ".migrated". Each entry gets an ID. Live entries keep their order and undone entries stay on
+// the redo stack. The index is saved only once everything is written, so a
+// migration cut short leaves no index and simply runs again on the next load.
+func (s *logStore) migrate() error {
+	entries, redo, err := readLegacy(s.legacyPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return err
+	}
+	if nums, err := s.segmentFiles(); err == nil {
+		for _, n := range nums {
+			_ = os.Remove(s.segmentPath(n))
+		}
+	}
+
+	for _, list := range [][]Entry{entries, redo} {
+		for i := range list {
+			list[i].ID = newEntryID()
+		}
+	}
+
+	s.idx = freshIndex(1)
+	s.deferIndex = true
+	defer func() { s.deferIndex = false }()
+
+	for _, group := range batchRuns(entries) {
+		recs := make([]record, 0, len(group))
+		for _, e := range group {
+			recs = append(recs, record{Op: opAdd, Entry: e})
+		}
+		if err := s.apply(recs); err != nil {
+			return err
+		}
+	}
+	for _, group := range batchRuns(redo) {
+		if err := s.apply(undoneRecords(group)); err != nil {
+			return err
+		}
+	}
+	if err := s.saveIndex(); err != nil {
+		return err
+	}
+	s.loaded = true
+	_ = os.Rename(s.legacyPath, s.legacyPath+".migrated")
+	return nil
+}
+
+// batchRuns splits entries into runs of consecutive entries of the same batch.
+func batchRuns(entries []Entry) [][]Entry {
+	var runs [][]Entry
+	for i, e := range entries {
+		if i == 0 || e.BatchID != entries[i-1].BatchID {
+			runs = append(runs, nil)
+		}
+		runs[len(runs)-1] = append(runs[len(runs)-1], e)
+	}
+	return runs
+}
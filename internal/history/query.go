@@ -0,0 +1,129 @@
+package history
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Query selects history entries by their fields. Zero-valued fields match
+// everything, so the zero Query matches every entry.
+type Query struct {
+	// Name is a filepath.Match glob tested, case-insensitively, against the
+	// base name of both the source and the destination.
+	Name string
+	// SourcePrefix and DestinationPrefix match the start of the cleaned paths.
+	SourcePrefix      string
+	DestinationPrefix string
+	// Path is a filepath.Match glob tested against the full source and
+	// destination paths. A pattern without wildcards matches that path and
+	// everything beneath it.
+	Path string
+	// Destinations matches entries whose cleaned destination is one of these.
+	Destinations []string
+	Categories   []string
+	Actions      []string
+	// Since and Until bound the entry timestamp: Since is inclusive, Until is
+	// exclusive.
+	Since time.Time
+	Until time.Time
+}
+
+// Match reports whether e satisfies every set field of q. A malformed Name
+// pattern matches nothing; validate it with filepath.Match beforehand.
+func (q Query) Match(e Entry) bool {
+	if q.Name != "" && !matchName(q.Name, e.Source) && !matchName(q.Name, e.Destination) {
+		return false
+	}
+	if q.SourcePrefix != "" && !hasPathPrefix(e.Source, q.SourcePrefix) {
+		return false
+	}
+	if q.DestinationPrefix != "" && !hasPathPrefix(e.Destination, q.DestinationPrefix) {
+		return false
+	}
+	if q.Path != "" && !matchPath(q.Path, e.Source) && !matchPath(q.Path, e.Destination) {
+		return false
+	}
+	if len(q.Destinations) > 0 && !slices.Contains(q.Destinations, filepath.Clean(e.Destination)) {
+		return false
+	}
+	if len(q.Categories) > 0 && !containsFold(q.Categories, e.Category) {
+		return false
+	}
+	if len(q.Actions) > 0 && !containsFold(q.Actions, e.EffectiveAction()) {
+		return false
+	}
+	if !q.Since.IsZero() && e.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !e.Timestamp.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// Search returns the entries matching q, batch by batch, oldest first. Each
+// batch is read from disk in turn; only the matches are kept.
+func (h *History) Search(q Query) []Entry {
+	var out []Entry
+	h.view(func() {
+		for _, b := range h.store.batches() {
+			live, _, err := h.store.read(b.BatchID)
+			if err != nil {
+				continue
+			}
+			for _, e := range live {
+				if q.Match(e) {
+					out = append(out, e)
+				}
+			}
+		}
+	})
+	return out
+}
+
+// EffectiveAction returns the entry's action, treating entries recorded
+// before actions were tracked as moves.
+func (e Entry) EffectiveAction() string {
+	if e.Action == "" {
+		return "move"
+	}
+	return e.Action
+}
+
+func matchName(pattern, path string) bool {
+	ok, _ := filepath.Match(strings.ToLower(pattern), strings.ToLower(filepath.Base(path)))
+	return ok
+}
+
+func matchPath(pattern, path string) bool {
+	pattern, path = filepath.Clean(pattern), filepath.Clean(path)
+	if !strings.ContainsAny(pattern, "*?[") {
+		return hasPathPrefix(path, pattern)
+	}
+	ok, _ := filepath.Match(pattern, path)
+	return ok
+}
+
+// hasPathPrefix reports whether path is dir or lies below it, comparing whole
+// path elements so that /a/Documents does not match /a/Documents-old.
+func hasPathPrefix(path, dir string) bool {
+	path, dir = filepath.Clean(path), filepath.Clean(dir)
+	if path == dir {
+		return true
+	}
+	if !strings.HasSuffix(dir, string(filepath.Separator)) {
+		dir += string(filepath.Separator)
+	}
+	return strings.HasPrefix(path, dir)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
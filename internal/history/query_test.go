@@ -0,0 +1,65 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryMatch(t *testing.T) {
+	t.Parallel()
+	day := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	e := Entry{
+		Source:      "/home/u/Downloads/Tax_2024.PDF",
+		Destination: "/home/u/Documents/pdf/Tax_2024.PDF",
+		Timestamp:   day,
+		Category:    "docs",
+	}
+
+	cases := []struct {
+		name string
+		q    Query
+		want bool
+	}{
+		{"zero query matches", Query{}, true},
+		{"name glob is case-insensitive", Query{Name: "tax*.pdf"}, true},
+		{"name glob miss", Query{Name: "*.jpg"}, false},
+		{"source prefix", Query{SourcePrefix: "/home/u/Downloads/"}, true},
+		{"destination prefix miss", Query{DestinationPrefix: "/home/u/Pictures"}, false},
+		{"destination prefix", Query{DestinationPrefix: "/home/u/Documents"}, true},
+		{"sibling sharing a source prefix", Query{SourcePrefix: "/home/u/Down"}, false},
+		{"sibling sharing a destination prefix", Query{DestinationPrefix: "/home/u/Doc"}, false},
+		{"category", Query{Categories: []string{"images", "DOCS"}}, true},
+		{"legacy entry counts as move", Query{Actions: []string{"move"}}, true},
+		{"action miss", Query{Actions: []string{"copy"}}, false},
+		{"since is inclusive", Query{Since: day}, true},
+		{"until is exclusive", Query{Until: day}, false},
+		{"path glob on the full path", Query{Path: "/home/u/Documents/*/*.PDF"}, true},
+		{"path without wildcards matches beneath it", Query{Path: "/home/u/Downloads"}, true},
+		{"path is not a bare prefix", Query{Path: "/home/u/Down"}, false},
+		{"exact destination", Query{Destinations: []string{"/home/u/Documents/pdf/Tax_2024.PDF"}}, true},
+		{"destination miss", Query{Destinations: []string{"/home/u/Documents/pdf"}}, false},
+		{"inside range", Query{Since: day.Add(-time.Hour), Until: day.Add(time.Hour)}, true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, tt.q.Match(e))
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+	h := newTestHistory(t, 10)
+	require.NoError(t, h.AddBatch([]Entry{
+		{Source: "/a/report.pdf", BatchID: "batch_1", Category: "docs"},
+		{Source: "/a/photo.jpg", BatchID: "batch_1", Category: "images"},
+		{Source: "/b/notes.pdf", BatchID: "batch_2", Category: "docs"},
+	}))
+	got := h.Search(Query{Name: "*.pdf"})
+	assert.Len(t, got, 2)
+	assert.Empty(t, h.Search(Query{Categories: []string{"music"}}))
+}
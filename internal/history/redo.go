@@ -0,0 +1,125 @@
+package history
+
+import "time"
+
+// entryKey identifies an entry within history by its batch and ID. An entry
+// without an ID is identified by its source instead.
+func entryKey(e Entry) string {
+	if e.ID == "" {
+		return e.BatchID + "\x00\x00" + e.Source
+	}
+	return e.BatchID + "\x00" + e.ID
+}
+
+// keyRecord returns a record of op that acts on e, carrying only e's key.
+func keyRecord(op string, e Entry) record {
+	return record{Op: op, Entry: Entry{BatchID: e.BatchID, ID: e.ID, Source: e.Source}}
+}
+
+// MoveToRedo removes the given entries from history, like RemoveEntries, and
+// pushes them onto the redo stack so `movelooper redo` can replay them. The
+// entries of one batch stay together on the stack: a batch undone in several
+// partial steps moves to the top as a whole. The stack holds at most as many
+// batches as history itself; older ones fall off, and their backups with them.
+func (h *History) MoveToRedo(entries []Entry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.withFileLock(func() error {
+		recs, batches, err := h.keyedRecords(entries, opUndo, false)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for i := range recs {
+			recs[i].UndoneAt = now
+		}
+		if err := h.store.apply(recs); err != nil {
+			return err
+		}
+		dropped, err := h.pruneRedo()
+		if err != nil {
+			return err
+		}
+		_ = h.store.compact()
+		h.tidyBackups(batches)
+		h.dropRedoBackups(dropped)
+		return nil
+	})
+}
+
+// pruneRedo drops the oldest batches from the redo stack past maxBatches and
+// returns their IDs. Callers must hold h.mu and the file lock.
+func (h *History) pruneRedo() ([]string, error) {
+	batches := h.store.redoBatches()
+	if len(batches) <= h.maxBatches {
+		return nil, nil
+	}
+	excess := batches[:len(batches)-h.maxBatches]
+	dropped := make([]string, 0, len(excess))
+	recs := make([]record, 0, len(excess))
+	for _, b := range excess {
+		dropped = append(dropped, b.BatchID)
+		recs = append(recs, record{Op: opForget, Entry: Entry{BatchID: b.BatchID}})
+	}
+	if err := h.store.apply(recs); err != nil {
+		return nil, err
+	}
+	return dropped, nil
+}
+
+// dropRedoBackups deletes the backups of batches that fell off the redo stack,
+// unless some of their entries are still live in history.
+// Callers must hold h.mu.
+func (h *History) dropRedoBackups(batchIDs []string) {
+	var gone []string
+	for _, id := range batchIDs {
+		if h.store.liveCount(id) == 0 {
+			gone = append(gone, id)
+		}
+	}
+	h.dropBackups(gone)
+}
+
+// GetRedoBatches returns one summary per batch on the redo stack, ordered from
+// the oldest undo to the most recent. Timestamp is when the batch was undone.
+func (h *History) GetRedoBatches() []BatchSummary {
+	var summaries []BatchSummary
+	h.view(func() { summaries = h.store.redoBatches() })
+	return summaries
+}
+
+// GetRedoBatch returns the entries of batchID on the redo stack, in the order
+// they were originally recorded.
+func (h *History) GetRedoBatch(batchID string) []Entry {
+	batch := make([]Entry, 0)
+	h.view(func() {
+		if _, undone, err := h.store.read(batchID); err == nil && undone != nil {
+			batch = undone
+		}
+	})
+	return batch
+}
+
+// CompleteRedo takes replayed entries off the redo stack and records them in
+// history again under their original batch ID, so they can be undone once
+// more. Entries that failed to replay should not be passed; they stay on the
+// stack for a retry.
+func (h *History) CompleteRedo(entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.withFileLock(func() error {
+		recs, _, err := h.keyedRecords(entries, opRedo, true)
+		if err != nil {
+			return err
+		}
+		if err := h.store.apply(recs); err != nil {
+			return err
+		}
+		return h.prune()
+	})
+}
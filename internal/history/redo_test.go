@@ -0,0 +1,116 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMoveToRedo verifies that undone entries leave history for the redo
+// stack, survive a reload, and come back on CompleteRedo.
+func TestMoveToRedo(t *testing.T) {
+	t.Parallel()
+	h := newTestHistory(t, 10)
+	require.NoError(t, h.AddBatch([]Entry{
+		{Source: "/a", BatchID: "batch_1"},
+		{Source: "/b", BatchID: "batch_1"},
+		{Source: "/c", BatchID: "batch_2"},
+	}))
+
+	require.NoError(t, h.MoveToRedo([]Entry{{Source: "/a", BatchID: "batch_1"}}))
+	assert.Len(t, h.GetBatch("batch_1"), 1)
+	redo := h.GetRedoBatch("batch_1")
+	require.Len(t, redo, 1)
+	assert.False(t, redo[0].UndoneAt.IsZero())
+
+	reloaded, err := NewHistory(h.path, 10)
+	require.NoError(t, err)
+	assert.Len(t, reloaded.Search(Query{}), 2)
+	assert.Len(t, reloaded.GetRedoBatch("batch_1"), 1)
+
+	require.NoError(t, h.CompleteRedo(redo))
+	assert.Len(t, h.GetBatch("batch_1"), 2)
+	assert.Empty(t, h.GetRedoBatches())
+	assert.True(t, h.GetBatch("batch_1")[1].UndoneAt.IsZero(), "redone entries are live again")
+}
+
+// TestMoveToRedo_KeepsBatchesContiguous verifies that a batch undone in two
+// partial steps moves to the top of the stack as a whole.
+func TestMoveToRedo_KeepsBatchesContiguous(t *testing.T) {
+	t.Parallel()
+	h := newTestHistory(t, 10)
+	require.NoError(t, h.AddBatch([]Entry{
+		{Source: "/a", BatchID: "batch_1", Category: "images"},
+		{Source: "/b", BatchID: "batch_1", Category: "docs"},
+		{Source: "/c", BatchID: "batch_2"},
+	}))
+
+	require.NoError(t, h.MoveToRedo([]Entry{{Source: "/a", BatchID: "batch_1"}}))
+	require.NoError(t, h.MoveToRedo([]Entry{{Source: "/c", BatchID: "batch_2"}}))
+	require.NoError(t, h.MoveToRedo([]Entry{{Source: "/b", BatchID: "batch_1"}}))
+
+	batches := h.GetRedoBatches()
+	require.Len(t, batches, 2)
+	assert.Equal(t, "batch_2", batches[0].BatchID)
+	assert.Equal(t, "batch_1", batches[1].BatchID)
+	assert.Equal(t, 2, batches[1].Count)
+	assert.Empty(t, h.GetAllBatches())
+}
+
+// TestMoveToRedo_SameSourceTwice verifies that when a batch records the same
+// source twice, undoing one entry acts on that entry and not on the other.
+func TestMoveToRedo_SameSourceTwice(t *testing.T) {
+	t.Parallel()
+	h := newTestHistory(t, 10)
+	require.NoError(t, h.AddBatch([]Entry{
+		{Source: "/dl/report.pdf", Destination: "/docs/report.pdf", BatchID: "watch_1"},
+		{Source: "/dl/report.pdf", Destination: "/docs/report (1).pdf", BatchID: "watch_1"},
+	}))
+	batch := h.GetBatch("watch_1")
+	require.Len(t, batch, 2)
+	require.NotEqual(t, batch[0].ID, batch[1].ID)
+
+	require.NoError(t, h.MoveToRedo(batch[1:]))
+	live := h.GetBatch("watch_1")
+	require.Len(t, live, 1)
+	assert.Equal(t, "/docs/report.pdf", live[0].Destination)
+
+	reloaded, err := NewHistory(h.path, 10)
+	require.NoError(t, err)
+	redo := reloaded.GetRedoBatch("watch_1")
+	require.Len(t, redo, 1)
+	assert.Equal(t, "/docs/report (1).pdf", redo[0].Destination)
+
+	require.NoError(t, reloaded.RemoveEntries(reloaded.GetBatch("watch_1")))
+	require.NoError(t, reloaded.CompleteRedo(redo))
+	live = reloaded.GetBatch("watch_1")
+	require.Len(t, live, 1)
+	assert.Equal(t, "/docs/report (1).pdf", live[0].Destination)
+}
+
+// TestMoveToRedo_PrunesPastLimit verifies that the redo stack holds at most
+// maxBatches batches.
+func TestMoveToRedo_PrunesPastLimit(t *testing.T) {
+	t.Parallel()
+	h := newTestHistory(t, 2)
+	for _, id := range []string{"batch_1", "batch_2", "batch_3"} {
+		require.NoError(t, h.Add(Entry{Source: "/" + id, BatchID: id}))
+		require.NoError(t, h.MoveToRedo([]Entry{{Source: "/" + id, BatchID: id}}))
+	}
+	batches := h.GetRedoBatches()
+	require.Len(t, batches, 2)
+	assert.Equal(t, "batch_2", batches[0].BatchID)
+	assert.Equal(t, "batch_3", batches[1].BatchID)
+}
+
+// TestUnmarshalDocument_RejectsLegacyLine verifies that a single NDJSON entry
+// is not mistaken for the document form.
+func TestUnmarshalDocument_RejectsLegacyLine(t *testing.T) {
+	t.Parallel()
+	_, ok := unmarshalDocument([]byte(`{"source":"/a","batch_id":"b"}`))
+	assert.False(t, ok)
+	doc, ok := unmarshalDocument([]byte(`{"entries":[{"source":"/a"}],"redo":[]}`))
+	require.True(t, ok)
+	assert.Len(t, doc.Entries, 1)
+}
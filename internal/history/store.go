@@ -0,0 +1,758 @@
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// indexVersion is bumped whenever batchState gains a field that must be
+	// backfilled; an index of another version is rebuilt from the segments.
+	indexVersion = 2
+	indexFile    = "index.json"
+
+	// defaultBatchesPerSegment is how many batches start in one segment before
+	// the log rolls over to a new one.
+	defaultBatchesPerSegment = 10
+	// defaultMinGarbage is how many bytes of dead records a log must hold
+	// before compaction rewrites it; below that, rewriting costs more than it
+	// saves.
+	defaultMinGarbage = 64 << 10
+)
+
+// store is the storage backend behind History. History keeps the locking,
+// the retention policy and the backups; the store only persists records and
+// answers questions about batches. Every method runs with h.mu and the
+// history file lock held.
+type store interface {
+	// sync brings the store up to date with the files on disk, picking up
+	// records appended by other movelooper processes. It loads the store on
+	// first use.
+	sync() error
+	// apply appends records to the log and folds them into the index.
+	apply(recs []record) error
+	// compact reclaims space held by records of batches that left history.
+	compact() error
+	// batches returns the batches with live entries, oldest first.
+	batches() []BatchSummary
+	// redoBatches returns the batches with undone entries, oldest undo first.
+	redoBatches() []BatchSummary
+	// liveCount returns the number of live entries of a batch.
+	liveCount(batchID string) int
+	// read returns the live and undone entries of a batch, in the order they
+	// were recorded.
+	read(batchID string) (live, undone []Entry, err error)
+}
+
+// Record operations. An add carries a whole entry; the others carry only the
+// key of the entry they act on (batch ID, entry ID and source), or just the batch ID
+// for drop and forget. Each of remove, undo and redo acts on the first entry
+// matching its key.
+const (
+	opAdd    = ""       // record an entry; legacy NDJSON lines have no op and read as adds
+	opRemove = "remove" // delete a live entry
+	opUndo   = "undo"   // move a live entry to the redo stack
+	opRedo   = "redo"   // move an undone entry back to the end of the live entries
+	opDrop   = "drop"   // delete every live entry of a batch
+	opForget = "forget" // delete every undone entry of a batch
+)
+
+// record is one line of the log.
+type record struct {
+	Op string `json:"op,omitempty"`
+	Entry
+}
+
+// MarshalJSON writes adds as plain entries and every other op in a short
+// form holding just the fields it needs.
+func (r record) MarshalJSON() ([]byte, error) {
+	if r.Op == opAdd {
+		return json.Marshal(r.Entry)
+	}
+	return json.Marshal(struct {
+		Op        string    `json:"op"`
+		BatchID   string    `json:"batch_id"`
+		ID        string    `json:"id,omitempty"`
+		Source    string    `json:"source,omitempty"`
+		Timestamp time.Time `json:"timestamp,omitzero"`
+		UndoneAt  time.Time `json:"undone_at,omitzero"`
+	}{r.Op, r.BatchID, r.ID, r.Source, r.Timestamp, r.UndoneAt})
+}
+
+// span is a run of consecutive records of one batch within a segment.
+type span struct {
+	Segment int   `json:"segment"`
+	Offset  int64 `json:"offset"`
+	Length  int64 `json:"length"`
+}
+
+// batchState is what the index knows about one batch without reading it.
+type batchState struct {
+	Spans    []span    `json:"spans"`
+	Live     int       `json:"live"`
+	Undone   int       `json:"undone,omitempty"`
+	First    time.Time `json:"first"`
+	UndoneAt time.Time `json:"undone_at,omitzero"`
+	// LiveSeq orders batches in history: it is taken when the batch gains its
+	// first live entry. RedoSeq orders the redo stack: it is taken on every
+	// undo, so the most recently undone batch is on top.
+	LiveSeq uint64 `json:"live_seq"`
+	RedoSeq uint64 `json:"redo_seq,omitempty"`
+	// Label and Categories feed BatchSummary. Categories only grows: remove
+	// records do not say which category they take away.
+	Label      string   `json:"label,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+}
+
+// segmentInfo describes one segment file of the log.
+type segmentInfo struct {
+	Number int `json:"number"`
+	// Size is how many bytes of the segment are folded into the index.
+	Size int64 `json:"size"`
+	// Batches counts the batches that started in the segment; the log rolls
+	// over once the last segment reaches batchesPerSegment.
+	Batches int `json:"batches"`
+}
+
+// index is the in-memory state of the log, also saved as index.json so a
+// process can start without scanning every segment.
+type index struct {
+	Version int `json:"version"`
+	// Generation changes whenever segments are deleted or rewritten; a process
+	// holding another generation must reload.
+	Generation  int64                  `json:"generation"`
+	Segments    []segmentInfo          `json:"segments"`
+	NextSegment int                    `json:"next_segment"`
+	Seq         uint64                 `json:"seq"`
+	Batches     map[string]*batchState `json:"batches"`
+}
+
+// errStale reports that the segments on disk no longer match the index, so it
+// must be rebuilt from them.
+var errStale = errors.New("history index is out of date")
+
+// logStore is an append-only log of records in NDJSON segment files. Only the
+// index lives in memory; entries are read from disk on demand through the
+// spans of their batch.
+//
+// Appends never rewrite earlier bytes, so a crash can at worst leave a torn
+// last line, which the next scan truncates away. The index is saved when the
+// log rolls over to a new segment and when it is compacted; records appended
+// after the last save are recovered by scanning the segment tails on load.
+type logStore struct {
+	dir               string
+	legacyPath        string // the pre-log history file, migrated on first load
+	batchesPerSegment int
+	minGarbage        int64
+	idx               index
+	loaded            bool
+	deferIndex        bool // set while migrating or rewriting: the index is saved once at the end
+}
+
+func newLogStore(dir, legacyPath string) *logStore {
+	return &logStore{
+		dir:               dir,
+		legacyPath:        legacyPath,
+		batchesPerSegment: defaultBatchesPerSegment,
+		minGarbage:        defaultMinGarbage,
+	}
+}
+
+// storeDir returns the directory of the log kept for the history file path:
+// the path without its extension plus ".d", e.g. movelooper.d next to
+// movelooper.json.
+func storeDir(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".d"
+}
+
+func freshIndex(generation int64) index {
+	return index{Version: indexVersion, Generation: generation, NextSegment: 1, Batches: make(map[string]*batchState)}
+}
+
+func (s *logStore) indexPath() string { return filepath.Join(s.dir, indexFile) }
+
+func (s *logStore) segmentPath(n int) string {
+	return filepath.Join(s.dir, fmt.Sprintf("segment-%06d.ndjson", n))
+}
+
+// segmentFiles returns the numbers of the segment files in the store
+// directory, ascending.
+func (s *logStore) segmentFiles() ([]int, error) {
+	matches, err := filepath.Glob(filepath.Join(s.dir, "segment-*.ndjson"))
+	if err != nil {
+		return nil, err
+	}
+	var nums []int
+	for _, m := range matches {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(m), "segment-"), ".ndjson")
+		if n, err := strconv.Atoi(name); err == nil {
+			nums = append(nums, n)
+		}
+	}
+	slices.Sort(nums)
+	return nums, nil
+}
+
+func (s *logStore) sync() error {
+	if !s.loaded {
+		return s.load()
+	}
+	data, err := os.ReadFile(s.indexPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.loaded = false
+			return s.load()
+		}
+		return err
+	}
+	var disk struct {
+		Generation  int64         `json:"generation"`
+		Segments    []segmentInfo `json:"segments"`
+		NextSegment int           `json:"next_segment"`
+	}
+	if err := json.Unmarshal(data, &disk); err != nil || disk.Generation != s.idx.Generation {
+		s.loaded = false
+		return s.load()
+	}
+	// Segments another process rolled over to since our last sync.
+	for _, d := range disk.Segments {
+		if !slices.ContainsFunc(s.idx.Segments, func(si segmentInfo) bool { return si.Number == d.Number }) {
+			s.idx.Segments = append(s.idx.Segments, segmentInfo{Number: d.Number})
+		}
+	}
+	s.idx.NextSegment = max(s.idx.NextSegment, disk.NextSegment)
+	return s.scanTails()
+}
+
+// load reads index.json and scans the segment tails past it. Without an
+// index, a legacy history file is migrated or, failing that, the index is
+// rebuilt from whatever segments exist.
+func (s *logStore) load() error {
+	data, err := os.ReadFile(s.indexPath())
+	if os.IsNotExist(err) {
+		if _, lerr := os.Stat(s.legacyPath); lerr == nil {
+			return s.migrate()
+		}
+		return s.rebuild()
+	}
+	if err != nil {
+		return err
+	}
+
+	var idx index
+	if err := json.Unmarshal(data, &idx); err != nil || idx.Version != indexVersion {
+		return s.rebuild()
+	}
+	if idx.Batches == nil {
+		idx.Batches = make(map[string]*batchState)
+	}
+	s.idx = idx
+	s.removeOrphans()
+	if err := s.scanTails(); err != nil {
+		if errors.Is(err, errStale) {
+			return s.rebuild()
+		}
+		return err
+	}
+	s.loaded = true
+	return nil
+}
+
+// rebuild recreates the index by scanning every segment file from the start.
+func (s *logStore) rebuild() error {
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return err
+	}
+	nums, err := s.segmentFiles()
+	if err != nil {
+		return err
+	}
+	s.idx = freshIndex(time.Now().UnixNano())
+	for _, n := range nums {
+		s.idx.Segments = append(s.idx.Segments, segmentInfo{Number: n})
+		s.idx.NextSegment = n + 1
+	}
+	if err := s.scanTails(); err != nil {
+		return err
+	}
+	if err := s.saveIndex(); err != nil {
+		return err
+	}
+	s.loaded = true
+	return nil
+}
+
+// removeOrphans deletes segment files the index does not list: leftovers of a
+// compaction interrupted before or after it swapped the index.
+func (s *logStore) removeOrphans() {
+	nums, err := s.segmentFiles()
+	if err != nil {
+		return
+	}
+	for _, n := range nums {
+		if !slices.ContainsFunc(s.idx.Segments, func(si segmentInfo) bool { return si.Number == n }) {
+			_ = os.Remove(s.segmentPath(n))
+		}
+	}
+}
+
+// scanTails folds the records past the indexed size of every segment.
+func (s *logStore) scanTails() error {
+	for i := range s.idx.Segments {
+		if err := s.scanSegment(&s.idx.Segments[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanSegment folds the records appended to seg since it was last scanned.
+// A torn last line (no trailing newline) can only come from a writer that
+// died mid-append, since appends happen under the file lock; it is truncated
+// so the next append starts on a clean line. Unparsable complete lines are
+// skipped.
+func (s *logStore) scanSegment(seg *segmentInfo) error {
+	path := s.segmentPath(seg.Number)
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errStale
+		}
+		return err
+	}
+	if info.Size() < seg.Size {
+		return errStale
+	}
+	if info.Size() == seg.Size {
+		return nil
+	}
+
+	f, err := os.Open(path) //#nosec G304 -- path is inside the history directory, set by the application at startup
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, info.Size()-seg.Size)
+	_, err = f.ReadAt(buf, seg.Size)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	off := seg.Size
+	for len(buf) > 0 {
+		n := bytes.IndexByte(buf, '\n')
+		if n < 0 {
+			if err := os.Truncate(path, off); err != nil {
+				return fmt.Errorf("could not truncate torn history record: %w", err)
+			}
+			break
+		}
+		line := buf[:n+1]
+		var rec record
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 && json.Unmarshal(trimmed, &rec) == nil {
+			s.fold(rec, span{Segment: seg.Number, Offset: off, Length: int64(len(line))}, seg)
+		}
+		off += int64(len(line))
+		buf = buf[n+1:]
+	}
+	seg.Size = off
+	return nil
+}
+
+// fold applies one record, found at sp, to the index. Counts trust the
+// record: History only emits remove, undo and redo for entries it has just
+// read from the batch.
+func (s *logStore) fold(rec record, sp span, seg *segmentInfo) {
+	st := s.idx.Batches[rec.BatchID]
+	if st == nil {
+		st = &batchState{}
+		s.idx.Batches[rec.BatchID] = st
+		seg.Batches++
+	}
+	if n := len(st.Spans); n > 0 && st.Spans[n-1].Segment == sp.Segment && st.Spans[n-1].Offset+st.Spans[n-1].Length == sp.Offset {
+		st.Spans[n-1].Length += sp.Length
+	} else {
+		st.Spans = append(st.Spans, sp)
+	}
+
+	gainLive := func(ts time.Time) {
+		if st.Live == 0 {
+			s.idx.Seq++
+			st.LiveSeq = s.idx.Seq
+			st.First = ts
+		}
+		st.Live++
+	}
+	switch rec.Op {
+	case opAdd:
+		gainLive(rec.Timestamp)
+		if st.Label == "" {
+			st.Label = rec.Label
+		}
+		if rec.Category != "" && !slices.Contains(st.Categories, rec.Category) {
+			st.Categories = append(st.Categories, rec.Category)
+		}
+	case opRemove:
+		st.Live = max(st.Live-1, 0)
+	case opUndo:
+		st.Live = max(st.Live-1, 0)
+		st.Undone++
+		s.idx.Seq++
+		st.RedoSeq = s.idx.Seq
+		if rec.UndoneAt.After(st.UndoneAt) {
+			st.UndoneAt = rec.UndoneAt
+		}
+	case opRedo:
+		st.Undone = max(st.Undone-1, 0)
+		gainLive(rec.Timestamp)
+	case opDrop:
+		st.Live = 0
+	case opForget:
+		st.Undone = 0
+	}
+	if st.Live == 0 && st.Undone == 0 {
+		// Nothing left to read: the batch's records are garbage now, and a
+		// later record with the same ID starts it afresh.
+		delete(s.idx.Batches, rec.BatchID)
+	}
+}
+
+func (s *logStore) apply(recs []record) error {
+	if len(recs) == 0 {
+		return nil
+	}
+	if n := len(s.idx.Segments); n == 0 || s.idx.Segments[n-1].Batches >= s.batchesPerSegment {
+		if err := s.roll(); err != nil {
+			return err
+		}
+	}
+	seg := &s.idx.Segments[len(s.idx.Segments)-1]
+
+	var buf bytes.Buffer
+	lengths := make([]int64, len(recs))
+	for i, rec := range recs {
+		before := buf.Len()
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+		lengths[i] = int64(buf.Len() - before)
+	}
+
+	path := s.segmentPath(seg.Number)
+	f, err := os.OpenFile(path, os.O_WRONLY, 0o600) //#nosec G304 -- path is inside the history directory, set by the application at startup
+	if err != nil {
+		return err
+	}
+	_, werr := f.WriteAt(buf.Bytes(), seg.Size)
+	if werr != nil {
+		// Leave no partial line behind for the next append to build on.
+		_ = f.Truncate(seg.Size)
+	}
+	if cerr := f.Close(); werr == nil && cerr != nil {
+		werr = cerr
+	}
+	if werr != nil {
+		return werr
+	}
+
+	off := seg.Size
+	for i, rec := range recs {
+		s.fold(rec, span{Segment: seg.Number, Offset: off, Length: lengths[i]}, seg)
+		off += lengths[i]
+	}
+	seg.Size = off
+	return nil
+}
+
+// roll starts a new segment. The index is saved first, so the new segment is
+// listed before anything is written to it and other processes pick it up.
+func (s *logStore) roll() error {
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return err
+	}
+	n := s.idx.NextSegment
+	s.idx.NextSegment++
+	s.idx.Segments = append(s.idx.Segments, segmentInfo{Number: n})
+	if !s.deferIndex {
+		if err := s.saveIndex(); err != nil {
+			s.idx.Segments = s.idx.Segments[:len(s.idx.Segments)-1]
+			return err
+		}
+	}
+	f, err := os.OpenFile(s.segmentPath(n), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600) //#nosec G304 -- path is inside the history directory, set by the application at startup
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// saveIndex writes index.json atomically using a temp file + rename.
+func (s *logStore) saveIndex() error {
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return err
+	}
+	data, err := json.Marshal(s.idx)
+	if err != nil {
+		return err
+	}
+	tmp := s.indexPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.indexPath())
+}
+
+// compact deletes segments no batch reads from any more. When dead records
+// still outweigh live ones after that, and by more than minGarbage, the
+// surviving batches are rewritten into fresh segments.
+func (s *logStore) compact() error {
+	used := make(map[int]bool)
+	var liveBytes, totalBytes int64
+	for _, st := range s.idx.Batches {
+		for _, sp := range st.Spans {
+			used[sp.Segment] = true
+			liveBytes += sp.Length
+		}
+	}
+	for _, seg := range s.idx.Segments {
+		totalBytes += seg.Size
+	}
+	if garbage := totalBytes - liveBytes; garbage > liveBytes && garbage >= s.minGarbage {
+		return s.rewrite()
+	}
+
+	var kept, unused []segmentInfo
+	for i, seg := range s.idx.Segments {
+		if used[seg.Number] || i == len(s.idx.Segments)-1 {
+			kept = append(kept, seg)
+		} else {
+			unused = append(unused, seg)
+		}
+	}
+	if len(unused) == 0 {
+		return nil
+	}
+	orig := s.idx
+	s.idx.Segments = kept
+	s.idx.Generation++
+	if err := s.saveIndex(); err != nil {
+		s.idx = orig
+		return err
+	}
+	for _, seg := range unused {
+		_ = os.Remove(s.segmentPath(seg.Number))
+	}
+	return nil
+}
+
+// rewrite copies the surviving batches into new segments: live entries as
+// adds in history order, then undone entries as add+undo pairs in redo stack
+// order, which reproduces both orders. Saving the new index is the commit
+// point; the old segments are only deleted after it, and a crash on either
+// side leaves orphans that the next load removes.
+func (s *logStore) rewrite() error {
+	type batch struct {
+		id           string
+		live, undone []Entry
+	}
+	var liveIDs, redoIDs []string
+	for _, b := range s.batches() {
+		liveIDs = append(liveIDs, b.BatchID)
+	}
+	for _, b := range s.redoBatches() {
+		redoIDs = append(redoIDs, b.BatchID)
+	}
+	contents := make(map[string]batch)
+	for id := range s.idx.Batches {
+		live, undone, err := s.read(id)
+		if err != nil {
+			return err
+		}
+		contents[id] = batch{id, live, undone}
+	}
+
+	ns := &logStore{
+		dir:               s.dir,
+		batchesPerSegment: s.batchesPerSegment,
+		minGarbage:        s.minGarbage,
+		idx:               freshIndex(s.idx.Generation + 1),
+		deferIndex:        true,
+	}
+	ns.idx.NextSegment = s.idx.NextSegment
+	err := func() error {
+		for _, id := range liveIDs {
+			recs := make([]record, 0, len(contents[id].live))
+			for _, e := range contents[id].live {
+				recs = append(recs, record{Op: opAdd, Entry: e})
+			}
+			if err := ns.apply(recs); err != nil {
+				return err
+			}
+		}
+		for _, id := range redoIDs {
+			if err := ns.apply(undoneRecords(contents[id].undone)); err != nil {
+				return err
+			}
+		}
+		return ns.saveIndex()
+	}()
+	if err != nil {
+		for _, seg := range ns.idx.Segments {
+			_ = os.Remove(s.segmentPath(seg.Number))
+		}
+		return fmt.Errorf("could not compact history: %w", err)
+	}
+
+	old := s.idx.Segments
+	s.idx = ns.idx
+	for _, seg := range old {
+		_ = os.Remove(s.segmentPath(seg.Number))
+	}
+	return nil
+}
+
+// undoneRecords returns the records that recreate entries waiting on the redo
+// stack: each is added, then undone at its original time.
+func undoneRecords(entries []Entry) []record {
+	recs := make([]record, 0, 2*len(entries))
+	for _, e := range entries {
+		added := e
+		added.UndoneAt = time.Time{}
+		undo := keyRecord(opUndo, e)
+		undo.UndoneAt = e.UndoneAt
+		recs = append(recs, record{Op: opAdd, Entry: added}, undo)
+	}
+	return recs
+}
+
+func (s *logStore) batches() []BatchSummary {
+	return s.summaries(
+		func(st *batchState) bool { return st.Live > 0 },
+		func(st *batchState) uint64 { return st.LiveSeq },
+		func(id string, st *batchState) BatchSummary {
+			return BatchSummary{BatchID: id, Count: st.Live, Timestamp: st.First, Label: st.Label, Categories: slices.Clone(st.Categories)}
+		})
+}
+
+func (s *logStore) redoBatches() []BatchSummary {
+	return s.summaries(
+		func(st *batchState) bool { return st.Undone > 0 },
+		func(st *batchState) uint64 { return st.RedoSeq },
+		func(id string, st *batchState) BatchSummary {
+			return BatchSummary{BatchID: id, Count: st.Undone, Timestamp: st.UndoneAt, Label: st.Label, Categories: slices.Clone(st.Categories)}
+		})
+}
+
+func (s *logStore) summaries(keep func(*batchState) bool, seq func(*batchState) uint64, summarize func(string, *batchState) BatchSummary) []BatchSummary {
+	ids := make([]string, 0, len(s.idx.Batches))
+	for id, st := range s.idx.Batches {
+		if keep(st) {
+			ids = append(ids, id)
+		}
+	}
+	slices.SortFunc(ids, func(a, b string) int {
+		sa, sb := seq(s.idx.Batches[a]), seq(s.idx.Batches[b])
+		switch {
+		case sa < sb:
+			return -1
+		case sa > sb:
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+	out := make([]BatchSummary, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, summarize(id, s.idx.Batches[id]))
+	}
+	return out
+}
+
+func (s *logStore) liveCount(batchID string) int {
+	if st := s.idx.Batches[batchID]; st != nil {
+		return st.Live
+	}
+	return 0
+}
+
+// read replays the records of batchID from its spans.
+func (s *logStore) read(batchID string) (live, undone []Entry, err error) {
+	st := s.idx.Batches[batchID]
+	if st == nil {
+		return nil, nil, nil
+	}
+	files := make(map[int]*os.File)
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	for _, sp := range st.Spans {
+		f := files[sp.Segment]
+		if f == nil {
+			if f, err = os.Open(s.segmentPath(sp.Segment)); err != nil { //#nosec G304 -- path is inside the history directory, set by the application at startup
+				return nil, nil, err
+			}
+			files[sp.Segment] = f
+		}
+		buf := make([]byte, sp.Length)
+		if _, err := f.ReadAt(buf, sp.Offset); err != nil {
+			return nil, nil, fmt.Errorf("could not read batch %s: %w", batchID, err)
+		}
+		for line := range bytes.Lines(buf) {
+			var rec record
+			if trimmed := bytes.TrimSpace(line); len(trimmed) == 0 || json.Unmarshal(trimmed, &rec) != nil {
+				continue
+			}
+			live, undone = replay(rec, live, undone)
+		}
+	}
+	return live, undone, nil
+}
+
+// replay applies one record to the entries of its batch.
+func replay(rec record, live, undone []Entry) ([]Entry, []Entry) {
+	key := entryKey(rec.Entry)
+	switch rec.Op {
+	case opAdd:
+		live = append(live, rec.Entry)
+	case opRemove:
+		live, _, _ = takeEntry(live, key)
+	case opUndo:
+		if rest, e, ok := takeEntry(live, key); ok {
+			e.UndoneAt = rec.UndoneAt
+			live, undone = rest, append(undone, e)
+		}
+	case opRedo:
+		if rest, e, ok := takeEntry(undone, key); ok {
+			e.UndoneAt = time.Time{}
+			undone, live = rest, append(live, e)
+		}
+	case opDrop:
+		live = nil
+	case opForget:
+		undone = nil
+	}
+	return live, undone
+}
+
+// takeEntry removes the first entry with the given key from list.
+func takeEntry(list []Entry, key string) ([]Entry, Entry, bool) {
+	for i, e := range list {
+		if entryKey(e) == key {
+			return slices.Delete(list, i, i+1), e, true
+		}
+	}
+	return list, Entry{}, false
+}
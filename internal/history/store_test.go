@@ -0,0 +1,243 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logOf returns the log store behind h, for tests that tune or inspect it.
+func logOf(h *History) *logStore { return h.store.(*logStore) }
+
+func batchIDs(summaries []BatchSummary) []string {
+	ids := make([]string, 0, len(summaries))
+	for _, b := range summaries {
+		ids = append(ids, b.BatchID)
+	}
+	return ids
+}
+
+// TestLogStore_AppendsAndReopens verifies that entries are appended to a
+// segment and that a second instance rebuilds the same state from the index
+// and the segment tail.
+func TestLogStore_AppendsAndReopens(t *testing.T) {
+	t.Parallel()
+	h := newTestHistory(t, 10)
+	require.NoError(t, h.Add(Entry{Source: "/a", BatchID: "batch_1"}))
+	require.NoError(t, h.Add(Entry{Source: "/b", BatchID: "batch_1"}))
+	require.NoError(t, h.Add(Entry{Source: "/c", BatchID: "batch_2"}))
+
+	dir := storeDir(h.path)
+	assert.FileExists(t, filepath.Join(dir, indexFile))
+	data, err := os.ReadFile(filepath.Join(dir, "segment-000001.ndjson"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, countLines(data), "one line per entry, nothing rewritten")
+
+	h2, err := NewHistory(h.path, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"batch_1", "batch_2"}, batchIDs(h2.GetAllBatches()))
+	assert.Len(t, h2.GetBatch("batch_1"), 2)
+}
+
+// TestLogStore_RollsSegments verifies that the log starts a new segment every
+// batchesPerSegment batches and reads batches back across segments.
+func TestLogStore_RollsSegments(t *testing.T) {
+	t.Parallel()
+	h := newTestHistory(t, 10)
+	logOf(h).batchesPerSegment = 2
+	for _, id := range []string{"b1", "b2", "b3", "b4", "b5"} {
+		require.NoError(t, h.Add(Entry{Source: "/" + id, BatchID: id}))
+	}
+	require.NoError(t, h.Add(Entry{Source: "/late", BatchID: "b1"}))
+
+	nums, err := logOf(h).segmentFiles()
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, nums)
+
+	h2, err := NewHistory(h.path, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b1", "b2", "b3", "b4", "b5"}, batchIDs(h2.GetAllBatches()))
+	assert.Len(t, h2.GetBatch("b1"), 2, "a batch spanning segments is read whole")
+}
+
+// TestLogStore_RecoversTornTail verifies that a partial last line, left by a
+// process killed mid-append, is truncated and that malformed complete lines
+// are skipped.
+func TestLogStore_RecoversTornTail(t *testing.T) {
+	t.Parallel()
+	h := newTestHistory(t, 10)
+	require.NoError(t, h.Add(Entry{Source: "/a", BatchID: "batch_1"}))
+
+	seg := filepath.Join(storeDir(h.path), "segment-000001.ndjson")
+	f, err := os.OpenFile(seg, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString("not json\n{\"source\":\"/b\",\"batch_id\":\"batch_1\"}\n{\"source\":\"/c\",\"bat")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	h2, err := NewHistory(h.path, 10)
+	require.NoError(t, err)
+	assert.Len(t, h2.GetBatch("batch_1"), 2)
+
+	data, err := os.ReadFile(seg)
+	require.NoError(t, err)
+	assert.Equal(t, byte('\n'), data[len(data)-1], "torn line is truncated")
+
+	require.NoError(t, h2.Add(Entry{Source: "/d", BatchID: "batch_1"}))
+	h3, err := NewHistory(h.path, 10)
+	require.NoError(t, err)
+	assert.Len(t, h3.GetBatch("batch_1"), 3)
+}
+
+// TestLogStore_DeletesPrunedSegments verifies that segments holding only
+// pruned batches are deleted.
+func TestLogStore_DeletesPrunedSegments(t *testing.T) {
+	t.Parallel()
+	h := newTestHistory(t, 2)
+	logOf(h).batchesPerSegment = 1
+	for _, id := range []string{"b1", "b2", "b3", "b4"} {
+		require.NoError(t, h.Add(Entry{Source: "/" + id, BatchID: id}))
+	}
+
+	nums, err := logOf(h).segmentFiles()
+	require.NoError(t, err)
+	assert.NotContains(t, nums, 1)
+	assert.NotContains(t, nums, 2)
+
+	h2, err := NewHistory(h.path, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b3", "b4"}, batchIDs(h2.GetAllBatches()))
+}
+
+// TestLogStore_RewritesWhenMostlyGarbage verifies that compaction rewrites a
+// log dominated by dead records, keeping the order of history and of the redo
+// stack.
+func TestLogStore_RewritesWhenMostlyGarbage(t *testing.T) {
+	t.Parallel()
+	h := newTestHistory(t, 2)
+	s := logOf(h)
+	s.minGarbage = 0
+
+	require.NoError(t, h.AddBatch([]Entry{{Source: "/u1", BatchID: "undone_1"}, {Source: "/u2", BatchID: "undone_2"}}))
+	require.NoError(t, h.MoveToRedo([]Entry{{Source: "/u2", BatchID: "undone_2"}}))
+	require.NoError(t, h.MoveToRedo([]Entry{{Source: "/u1", BatchID: "undone_1"}}))
+	generation := s.idx.Generation
+	var batch []Entry
+	for i := range 20 {
+		batch = append(batch, Entry{Source: fmt.Sprintf("/big%d", i), BatchID: "big"})
+	}
+	batch = append(batch, Entry{Source: "/k1", BatchID: "keep_1"}, Entry{Source: "/k2", BatchID: "keep_2"})
+	require.NoError(t, h.AddBatch(batch))
+
+	assert.Greater(t, s.idx.Generation, generation, "pruning big left mostly garbage and triggered a rewrite")
+	nums, err := s.segmentFiles()
+	require.NoError(t, err)
+	assert.NotContains(t, nums, 1, "the old segment is gone")
+
+	h2, err := NewHistory(h.path, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"keep_1", "keep_2"}, batchIDs(h2.GetAllBatches()))
+	assert.Equal(t, []string{"undone_2", "undone_1"}, batchIDs(h2.GetRedoBatches()))
+	redo := h2.GetRedoBatch("undone_1")
+	require.Len(t, redo, 1)
+	assert.False(t, redo[0].UndoneAt.IsZero())
+}
+
+// TestLogStore_MigratesLegacyFile verifies that a single-file history from an
+// earlier version is moved into the log on first load, redo stack included.
+func TestLogStore_MigratesLegacyFile(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "movelooper.json")
+	undoneAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	doc := document{
+		Entries: []Entry{
+			{Source: "/a", BatchID: "batch_1"},
+			{Source: "/b", BatchID: "batch_2"},
+			{Source: "/c", BatchID: "batch_1"},
+		},
+		Redo: []Entry{{Source: "/d", BatchID: "batch_3", UndoneAt: undoneAt}},
+	}
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	h, err := NewHistory(path, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"batch_1", "batch_2"}, batchIDs(h.GetAllBatches()))
+	assert.Len(t, h.GetBatch("batch_1"), 2)
+	redo := h.GetRedoBatch("batch_3")
+	require.Len(t, redo, 1)
+	assert.True(t, undoneAt.Equal(redo[0].UndoneAt))
+
+	assert.NoFileExists(t, path)
+	assert.FileExists(t, path+".migrated")
+
+	h2, err := NewHistory(path, 10)
+	require.NoError(t, err)
+	assert.Len(t, h2.Search(Query{}), 3, "a second load does not migrate again")
+}
+
+// TestLogStore_SyncsAcrossProcesses verifies that an instance picks up
+// segments rolled over, and compactions made, by another instance.
+func TestLogStore_SyncsAcrossProcesses(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "movelooper.json")
+	h1, err := NewHistory(path, 10)
+	require.NoError(t, err)
+	h2, err := NewHistory(path, 10)
+	require.NoError(t, err)
+	logOf(h1).batchesPerSegment = 1
+	logOf(h2).batchesPerSegment = 1
+
+	require.NoError(t, h1.Add(Entry{Source: "/a", BatchID: "b1"}))
+	require.NoError(t, h2.Add(Entry{Source: "/b", BatchID: "b2"}))
+	require.NoError(t, h1.Add(Entry{Source: "/c", BatchID: "b3"}))
+	assert.Equal(t, []string{"b1", "b2", "b3"}, batchIDs(h2.GetAllBatches()))
+
+	// h3 keeps a single batch: its prune deletes the segments h1 still knows.
+	h3, err := NewHistory(path, 1)
+	require.NoError(t, err)
+	require.NoError(t, h3.Add(Entry{Source: "/d", BatchID: "b4"}))
+	assert.Equal(t, []string{"b4"}, batchIDs(h1.GetAllBatches()))
+	require.NoError(t, h1.Add(Entry{Source: "/e", BatchID: "b5"}))
+	assert.Equal(t, []string{"b4", "b5"}, batchIDs(h2.GetAllBatches()))
+}
+
+func countLines(data []byte) int {
+	n := 0
+	for _, b := range data {
+		if b == '\n' {
+			n++
+		}
+	}
+	return n
+}
+
+// TestLogStore_SummarizesLabelAndCategories verifies that batch summaries
+// carry the batch label and the categories added to it, also after a rebuild.
+func TestLogStore_SummarizesLabelAndCategories(t *testing.T) {
+	t.Parallel()
+	h := newTestHistory(t, 10)
+	require.NoError(t, h.Add(Entry{Source: "/a", BatchID: "watch_1", Category: "images", Label: "watch burst"}))
+	require.NoError(t, h.Add(Entry{Source: "/b", BatchID: "watch_1", Category: "docs", Label: "watch burst"}))
+	require.NoError(t, h.Add(Entry{Source: "/c", BatchID: "watch_1", Category: "images", Label: "watch burst"}))
+
+	check := func(h *History) {
+		b := h.GetAllBatches()
+		require.Len(t, b, 1)
+		assert.Equal(t, "watch burst", b[0].Label)
+		assert.Equal(t, []string{"images", "docs"}, b[0].Categories)
+	}
+	check(h)
+
+	require.NoError(t, os.Remove(filepath.Join(storeDir(h.path), indexFile)))
+	h2, err := NewHistory(h.path, 10)
+	require.NoError(t, err)
+	check(h2)
+}
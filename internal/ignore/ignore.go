@@ -0,0 +1,251 @@
+// Package ignore reads .mlignore files, which protect files from every
+// category with gitignore syntax: comments, negation, anchored and
+// directory-only patterns, ** wildcards, and rules inherited by subfolders,
+// where a deeper file's rules take precedence.
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	gpath "path"
+	"path/filepath"
+	"strings"
+)
+
+// FileName is the name of an ignore file.
+const FileName = ".mlignore"
+
+// Rule is one pattern of an ignore file.
+type Rule struct {
+	File    string // path of the ignore file; empty for the built-in rule
+	Line    int    // 1-based line number in File
+	Pattern string // the pattern as written, "!" included
+
+	negate   bool
+	dirOnly  bool
+	base     string   // slash-separated directory of File relative to the root; "" at the root
+	segments []string // the pattern split on "/", "**" prepended when it is not anchored
+}
+
+// String describes the rule for diagnostics, e.g.
+// "/home/me/Downloads/.mlignore:3: *.part".
+func (r *Rule) String() string {
+	if r.File == "" {
+		return "built-in: " + r.Pattern
+	}
+	return fmt.Sprintf("%s:%d: %s", r.File, r.Line, r.Pattern)
+}
+
+// selfRule keeps the ignore files themselves in place.
+var selfRule = &Rule{Pattern: FileName}
+
+// Matcher holds the rules that apply inside one directory of a tree: those of
+// its own ignore file and of every ignore file above it up to the root.
+type Matcher struct {
+	root  string
+	rules []*Rule // in file order, parents first; the last rule that matches wins
+}
+
+// NewMatcher returns a Matcher for the tree at root without any rules. Call
+// Enter with root to read the root's own ignore file.
+func NewMatcher(root string) *Matcher {
+	return &Matcher{root: root}
+}
+
+// Enter returns the Matcher for dir, a directory of the tree: m's rules
+// followed by those of dir's ignore file. Without such a file m itself is
+// returned.
+func (m *Matcher) Enter(dir string) (*Matcher, error) {
+	file := filepath.Join(dir, FileName)
+	data, err := os.ReadFile(file) //#nosec G304 -- reading the ignore file of a configured source tree is the point
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return nil, fmt.Errorf("reading %s: %w", file, err)
+	}
+	base := ""
+	if rel, err := filepath.Rel(m.root, dir); err == nil && rel != "." {
+		base = filepath.ToSlash(rel)
+	}
+	rules := parse(data, file, base)
+	if len(rules) == 0 {
+		return m, nil
+	}
+	return &Matcher{root: m.root, rules: append(m.rules[:len(m.rules):len(m.rules)], rules...)}, nil
+}
+
+// Match returns the rule that ignores path, a file or (isDir) a directory
+// inside the tree, or nil when path is not ignored: no rule matches it, or
+// the last rule that does is a negation. The ignore files themselves always
+// match. Match does not look at path's parent directories, which a walk has
+// already pruned; use Check for a lone path.
+func (m *Matcher) Match(path string, isDir bool) *Rule {
+	if !isDir && filepath.Base(path) == FileName {
+		return selfRule
+	}
+	rel, err := filepath.Rel(m.root, path)
+	if err != nil {
+		return nil
+	}
+	rel = filepath.ToSlash(rel)
+	for i := len(m.rules) - 1; i >= 0; i-- {
+		r := m.rules[i]
+		if !r.matches(rel, isDir) {
+			continue
+		}
+		if r.negate {
+			return nil
+		}
+		return r
+	}
+	return nil
+}
+
+// Check reports the rule that ignores path, a file or (isDir) a directory
+// under root, reading the ignore files from root down to path's directory. A
+// path inside an ignored directory is ignored by that directory's rule, which
+// no negation further down can undo, as in git. nil means path is not
+// ignored; a path outside root never is.
+func Check(root, path string, isDir bool) (*Rule, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, nil
+	}
+	m, err := NewMatcher(root).Enter(root)
+	if err != nil {
+		return nil, err
+	}
+	dir := root
+	parts := strings.Split(rel, string(filepath.Separator))
+	for _, part := range parts[:len(parts)-1] {
+		dir = filepath.Join(dir, part)
+		if r := m.Match(dir, true); r != nil {
+			return r, nil
+		}
+		if m, err = m.Enter(dir); err != nil {
+			return nil, err
+		}
+	}
+	return m.Match(path, isDir), nil
+}
+
+// parse reads the rules of an ignore file. file is its path, for
+// diagnostics, and base its directory relative to the tree's root with
+// forward slashes, "" for the root itself. Blank lines, comments and
+// malformed patterns are skipped, as git does.
+func parse(data []byte, file, base string) []*Rule {
+	var rules []*Rule
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		if r := parseLine(sc.Text(), n); r != nil {
+			r.File, r.base = file, base
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// parseLine parses one line of an ignore file, returning nil for blank
+// lines, comments and malformed patterns.
+func parseLine(line string, n int) *Rule {
+	line = strings.TrimSuffix(line, "\r")
+	if n == 1 {
+		line = strings.TrimPrefix(line, "\ufeff") // byte order mark
+	}
+	line = trimTrailingSpaces(line)
+	if line == "" || line[0] == '#' {
+		return nil
+	}
+	r := &Rule{Line: n, Pattern: line}
+	if line[0] == '!' {
+		r.negate = true
+		line = line[1:]
+	}
+	if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return nil
+	}
+	// A slash at the start or in the middle anchors the pattern to the ignore
+	// file's directory; otherwise it matches a name at any depth below it.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	r.segments = strings.Split(line, "/")
+	if !anchored {
+		r.segments = append([]string{"**"}, r.segments...)
+	}
+	for _, seg := range r.segments {
+		if _, err := gpath.Match(seg, ""); err != nil {
+			return nil
+		}
+	}
+	return r
+}
+
+// trimTrailingSpaces drops the unescaped spaces at the end of line; a space
+// after a backslash is kept.
+func trimTrailingSpaces(line string) string {
+	end := len(line)
+	for end > 0 && line[end-1] == ' ' {
+		end--
+	}
+	if end < len(line) && end > 0 && line[end-1] == '\\' {
+		end++
+	}
+	return line[:end]
+}
+
+// matches reports whether rel, a path relative to the root with forward
+// slashes, matches the rule's pattern.
+func (r *Rule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.base != "" {
+		var ok bool
+		if rel, ok = strings.CutPrefix(rel, r.base+"/"); !ok {
+			return false
+		}
+	}
+	return matchSegments(r.segments, strings.Split(rel, "/"))
+}
+
+// matchSegments matches path segments against pattern segments. A ** segment
+// matches any number of path segments, except at the end of the pattern,
+// where it must match at least one: "logs/**" ignores what is inside logs/
+// but not logs/ itself, so a later negation can still re-include a file in
+// it.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for len(pattern) > 1 && pattern[1] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return len(name) > 0
+			}
+			for i := range len(name) + 1 {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := gpath.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
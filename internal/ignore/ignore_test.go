@@ -0,0 +1,147 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tree writes files, keyed by slash-separated path relative to root, and
+// returns root.
+func tree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for rel, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return root
+}
+
+// ignored reports whether rel is ignored under root, failing on read errors.
+func ignored(t *testing.T, root, rel string, isDir bool) bool {
+	t.Helper()
+	r, err := Check(root, filepath.Join(root, filepath.FromSlash(rel)), isDir)
+	require.NoError(t, err)
+	return r != nil
+}
+
+func TestCheck_Syntax(t *testing.T) {
+	t.Parallel()
+	root := tree(t, map[string]string{FileName: `
+# comment
+*.part
+!keep.part
+/top.txt
+build/
+docs/*.pdf
+**/cache/**
+!**/cache/important.dat
+\#hash.txt
+trailing.txt   
+`})
+	cases := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"movie.part", false, true},
+		{"sub/deep/movie.part", false, true},
+		{"keep.part", false, false},
+		{"sub/keep.part", false, false},
+		{"top.txt", false, true},
+		{"sub/top.txt", false, false},
+		{"build", true, true},
+		{"sub/build", true, true},
+		{"build", false, false},
+		{"docs/a.pdf", false, true},
+		{"docs/sub/a.pdf", false, false},
+		{"x/cache/a.dat", false, true},
+		{"x/cache", true, false},
+		{"x/cache/important.dat", false, false},
+		{"#hash.txt", false, true},
+		{"trailing.txt", false, true},
+		{"comment", false, false},
+		{FileName, false, true},
+		{"sub/" + FileName, false, true},
+		{"other.txt", false, false},
+	}
+	for _, tt := range cases {
+		assert.Equal(t, tt.want, ignored(t, root, tt.rel, tt.isDir), tt.rel)
+	}
+}
+
+func TestCheck_Inheritance(t *testing.T) {
+	t.Parallel()
+	root := tree(t, map[string]string{
+		FileName:                  "*.tmp\nprivate/\n",
+		"photos/" + FileName:      "!*.tmp\n/raw\n",
+		"photos/2024/" + FileName: "*.jpg\n",
+		"private/" + FileName:     "!secret.txt\n",
+		"photos/2024/a.jpg":       "",
+		"photos/raw/x.cr2":        "",
+		"private/secret.txt":      "",
+		"other/x.tmp":             "",
+		"photos/x.tmp":            "",
+		"photos/2024/x.tmp":       "",
+		"photos/sub/raw/y.cr2":    "",
+		"photos/2024/notes/a.jpg": "",
+		"photos/2025/keep/a.jpg":  "",
+	})
+	assert.True(t, ignored(t, root, "other/x.tmp", false), "root rules apply to every subfolder")
+	assert.False(t, ignored(t, root, "photos/x.tmp", false), "a deeper file's negation wins")
+	assert.False(t, ignored(t, root, "photos/2024/x.tmp", false), "and is inherited in turn")
+	assert.True(t, ignored(t, root, "photos/raw/x.cr2", false), "anchored to the folder of its ignore file")
+	assert.False(t, ignored(t, root, "photos/sub/raw/y.cr2", false))
+	assert.True(t, ignored(t, root, "photos/2024/notes/a.jpg", false))
+	assert.False(t, ignored(t, root, "photos/2025/keep/a.jpg", false), "rules do not reach sibling folders")
+
+	r, err := Check(root, filepath.Join(root, "private", "secret.txt"), false)
+	require.NoError(t, err)
+	require.NotNil(t, r, "a file in an ignored folder cannot be re-included")
+	assert.Equal(t, filepath.Join(root, FileName)+":2: private/", r.String())
+}
+
+func TestCheck_OutsideRoot(t *testing.T) {
+	t.Parallel()
+	root := tree(t, map[string]string{FileName: "*\n"})
+	r, err := Check(filepath.Join(root, "src"), filepath.Join(root, "a.txt"), false)
+	require.NoError(t, err)
+	assert.Nil(t, r)
+}
+
+func TestMatcher_Rule(t *testing.T) {
+	t.Parallel()
+	root := tree(t, map[string]string{FileName: "# downloads in progress\n*.crdownload\n"})
+	m, err := NewMatcher(root).Enter(root)
+	require.NoError(t, err)
+	r := m.Match(filepath.Join(root, "setup.exe.crdownload"), false)
+	require.NotNil(t, r)
+	assert.Equal(t, 2, r.Line)
+	assert.Equal(t, "*.crdownload", r.Pattern)
+	assert.Equal(t, filepath.Join(root, FileName), r.File)
+	assert.Equal(t, "built-in: "+FileName, m.Match(filepath.Join(root, FileName), false).String())
+
+	same, err := m.Enter(filepath.Join(root, "missing"))
+	require.NoError(t, err)
+	assert.Same(t, m, same, "a folder without an ignore file adds no rules")
+}
+
+func TestParseLine(t *testing.T) {
+	t.Parallel()
+	assert.Nil(t, parseLine("", 1))
+	assert.Nil(t, parseLine("   ", 1))
+	assert.Nil(t, parseLine("# note", 1))
+	assert.Nil(t, parseLine("/", 1))
+	assert.Nil(t, parseLine("a[", 1), "malformed patterns are skipped")
+	assert.Equal(t, []string{"**", "a.txt"}, parseLine("\ufeffa.txt", 1).segments)
+	assert.Equal(t, []string{"a", "b"}, parseLine("/a/b", 1).segments)
+	assert.Equal(t, []string{"a", "b"}, parseLine("a/b/", 1).segments)
+	assert.True(t, parseLine("a/b/", 1).dirOnly)
+	assert.Equal(t, `a\ `, parseLine(`a\ `+"  ", 1).segments[1])
+	assert.Equal(t, "!keep", parseLine("!keep", 3).Pattern)
+}
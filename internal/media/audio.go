@@ -0,0 +1,544 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// ErrNoTags is returned when a file is not in a supported audio format or
+// carries no tags.
+var ErrNoTags = errors.New("no audio tags")
+
+// maxTagSize bounds how much of a file is read as one tag block.
+const maxTagSize = 16 << 20
+
+// Tags are the audio tags read from a file. Fields the file does not record
+// are left zero.
+type Tags struct {
+	Title       string
+	Artist      string
+	Album       string
+	AlbumArtist string
+	Genre       string
+	Year        string // the year part of the recording date, e.g. "1997"
+	Track       int
+	Disc        int
+}
+
+// empty reports whether no tag is set.
+func (t Tags) empty() bool { return t == Tags{} }
+
+// merge fills the fields t lacks from o.
+func (t *Tags) merge(o Tags) {
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fill(&t.Title, o.Title)
+	fill(&t.Artist, o.Artist)
+	fill(&t.Album, o.Album)
+	fill(&t.AlbumArtist, o.AlbumArtist)
+	fill(&t.Genre, o.Genre)
+	fill(&t.Year, o.Year)
+	if t.Track == 0 {
+		t.Track = o.Track
+	}
+	if t.Disc == 0 {
+		t.Disc = o.Disc
+	}
+}
+
+// ReadTags opens the file at path and reads its audio tags.
+func ReadTags(path string) (Tags, error) {
+	f, err := os.Open(path) //#nosec G304 -- path comes from the scanned source directory
+	if err != nil {
+		return Tags{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return Tags{}, err
+	}
+	return DecodeTags(f, info.Size())
+}
+
+// DecodeTags reads audio tags from r, a file of the given size. It reads an
+// ID3v2 tag at the start of the file, FLAC Vorbis comments, MP4 (M4A) ilst
+// atoms, and completes what they lack from an ID3v1 tag at the end.
+func DecodeTags(r io.ReaderAt, size int64) (Tags, error) {
+	head := make([]byte, 12)
+	n, err := r.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return Tags{}, err
+	}
+	head = head[:n]
+
+	var tags Tags
+	start := int64(0)
+	if bytes.HasPrefix(head, []byte("ID3")) {
+		var tagLen int64
+		tags, tagLen = readID3v2(r)
+		start = tagLen
+		if _, err := r.ReadAt(head[:4], start); err == nil {
+			head = head[:4]
+		}
+	}
+	switch {
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		tags.merge(readFLAC(r, start+4, size))
+	case len(head) >= 8 && string(head[4:8]) == "ftyp":
+		tags.merge(readMP4Tags(r, size))
+	}
+	tags.merge(readID3v1(r, size))
+	if tags.empty() {
+		return Tags{}, ErrNoTags
+	}
+	return tags, nil
+}
+
+// readID3v2 reads the ID3v2 tag at the start of r and returns it with the
+// tag's total length.
+func readID3v2(r io.ReaderAt) (Tags, int64) {
+	hdr := make([]byte, 10)
+	if _, err := r.ReadAt(hdr, 0); err != nil {
+		return Tags{}, 0
+	}
+	version, flags := hdr[3], hdr[5]
+	size := int64(syncsafe(hdr[6:10]))
+	total := 10 + size
+	if flags&0x10 != 0 {
+		total += 10 // footer
+	}
+	if version < 2 || version > 4 || size > maxTagSize {
+		return Tags{}, total
+	}
+	data := make([]byte, size)
+	if _, err := r.ReadAt(data, 10); err != nil && !errors.Is(err, io.EOF) {
+		return Tags{}, total
+	}
+	if version < 4 && flags&0x80 != 0 {
+		data = unsynchronise(data)
+	}
+	if flags&0x40 != 0 && version >= 3 && len(data) >= 4 {
+		// Skip the extended header: v2.4 counts its own size, v2.3 does not.
+		ext := int(binary.BigEndian.Uint32(data))
+		if version == 4 {
+			ext = int(syncsafe(data[:4]))
+		} else {
+			ext += 4
+		}
+		if ext > len(data) {
+			return Tags{}, total
+		}
+		data = data[ext:]
+	}
+
+	var tags Tags
+	idLen, headLen := 4, 10
+	if version == 2 {
+		idLen, headLen = 3, 6
+	}
+	for len(data) >= headLen && data[0] != 0 {
+		id := string(data[:idLen])
+		var frameLen int
+		var formatFlags byte
+		switch version {
+		case 2:
+			frameLen = int(data[3])<<16 | int(data[4])<<8 | int(data[5])
+		case 3:
+			frameLen = int(binary.BigEndian.Uint32(data[4:8]))
+			formatFlags = data[9]
+		case 4:
+			frameLen = int(syncsafe(data[4:8]))
+			formatFlags = data[9]
+		}
+		if frameLen < 0 || headLen+frameLen > len(data) {
+			break
+		}
+		body := data[headLen : headLen+frameLen]
+		data = data[headLen+frameLen:]
+
+		if version == 3 && formatFlags&0xC0 != 0 {
+			continue // compressed or encrypted
+		}
+		if version == 4 {
+			if formatFlags&0x0C != 0 {
+				continue // compressed or encrypted
+			}
+			if formatFlags&0x01 != 0 && len(body) >= 4 {
+				body = body[4:] // data length indicator
+			}
+			if formatFlags&0x02 != 0 {
+				body = unsynchronise(body)
+			}
+		}
+		setID3Frame(&tags, id, body)
+	}
+	return tags, total
+}
+
+// setID3Frame stores the text frame id, in its v2.2 or v2.3/v2.4 name.
+func setID3Frame(t *Tags, id string, body []byte) {
+	if len(id) == 0 || id[0] != 'T' {
+		return
+	}
+	value := id3Text(body)
+	switch id {
+	case "TIT2", "TT2":
+		t.Title = value
+	case "TPE1", "TP1":
+		t.Artist = value
+	case "TPE2", "TP2":
+		t.AlbumArtist = value
+	case "TALB", "TAL":
+		t.Album = value
+	case "TCON", "TCO":
+		t.Genre = id3Genre(value)
+	case "TRCK", "TRK":
+		t.Track = leadingNumber(value)
+	case "TPOS", "TPA":
+		t.Disc = leadingNumber(value)
+	case "TDRC", "TYER", "TYE":
+		if t.Year == "" {
+			t.Year = yearOf(value)
+		}
+	}
+}
+
+// id3Text decodes a text frame body: an encoding byte then the text. Only the
+// first of several NUL-separated values is kept.
+func id3Text(body []byte) string {
+	if len(body) < 1 {
+		return ""
+	}
+	enc, text := body[0], body[1:]
+	var s string
+	switch enc {
+	case 1, 2:
+		s = decodeUTF16(text, enc == 2)
+	case 3:
+		s = string(text)
+	default:
+		s = latin1(text)
+	}
+	if i := strings.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+// decodeUTF16 decodes UTF-16 text. Without a byte order mark it is read as
+// little-endian unless bigEndian is set.
+func decodeUTF16(b []byte, bigEndian bool) string {
+	var order binary.ByteOrder = binary.LittleEndian
+	if bigEndian {
+		order = binary.BigEndian
+	}
+	if len(b) >= 2 {
+		switch {
+		case b[0] == 0xFE && b[1] == 0xFF:
+			order, b = binary.BigEndian, b[2:]
+		case b[0] == 0xFF && b[1] == 0xFE:
+			order, b = binary.LittleEndian, b[2:]
+		}
+	}
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u := order.Uint16(b[i:])
+		if u == 0 {
+			break
+		}
+		units = append(units, u)
+	}
+	return string(utf16.Decode(units))
+}
+
+func latin1(b []byte) string {
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return string(r)
+}
+
+// syncsafe decodes a 28-bit ID3 syncsafe integer.
+func syncsafe(b []byte) uint32 {
+	return uint32(b[0]&0x7F)<<21 | uint32(b[1]&0x7F)<<14 | uint32(b[2]&0x7F)<<7 | uint32(b[3]&0x7F)
+}
+
+// unsynchronise undoes ID3 unsynchronisation: a 0x00 after 0xFF is dropped.
+func unsynchronise(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		out = append(out, b[i])
+		if b[i] == 0xFF && i+1 < len(b) && b[i+1] == 0x00 {
+			i++
+		}
+	}
+	return out
+}
+
+// readID3v1 reads the 128-byte ID3v1 tag at the end of r, if any.
+func readID3v1(r io.ReaderAt, size int64) Tags {
+	if size < 128 {
+		return Tags{}
+	}
+	b := make([]byte, 128)
+	if _, err := r.ReadAt(b, size-128); err != nil || string(b[:3]) != "TAG" {
+		return Tags{}
+	}
+	field := func(s []byte) string {
+		if i := bytes.IndexByte(s, 0); i >= 0 {
+			s = s[:i]
+		}
+		return strings.TrimSpace(latin1(s))
+	}
+	t := Tags{
+		Title:  field(b[3:33]),
+		Artist: field(b[33:63]),
+		Album:  field(b[63:93]),
+		Year:   yearOf(field(b[93:97])),
+	}
+	if b[125] == 0 && b[126] != 0 {
+		t.Track = int(b[126]) // ID3v1.1
+	}
+	if int(b[127]) < len(id3Genres) {
+		t.Genre = id3Genres[b[127]]
+	}
+	return t
+}
+
+// readFLAC reads the Vorbis comment block of a FLAC stream whose metadata
+// blocks start at off.
+func readFLAC(r io.ReaderAt, off, size int64) Tags {
+	var hdr [4]byte
+	for range maxBoxes {
+		if off+4 > size {
+			break
+		}
+		if _, err := r.ReadAt(hdr[:], off); err != nil {
+			break
+		}
+		last, typ := hdr[0]&0x80 != 0, hdr[0]&0x7F
+		length := int64(hdr[1])<<16 | int64(hdr[2])<<8 | int64(hdr[3])
+		if typ == 4 && length <= maxTagSize {
+			block := make([]byte, length)
+			if _, err := r.ReadAt(block, off+4); err != nil {
+				break
+			}
+			return vorbisComments(block)
+		}
+		if last {
+			break
+		}
+		off += 4 + length
+	}
+	return Tags{}
+}
+
+// vorbisComments decodes a Vorbis comment block: a vendor string, then
+// little-endian length-prefixed KEY=value comments.
+func vorbisComments(b []byte) Tags {
+	next := func() (string, bool) {
+		if len(b) < 4 {
+			return "", false
+		}
+		n := binary.LittleEndian.Uint32(b)
+		if uint64(n) > uint64(len(b)-4) {
+			return "", false
+		}
+		s := string(b[4 : 4+n])
+		b = b[4+n:]
+		return s, true
+	}
+	if _, ok := next(); !ok { // vendor
+		return Tags{}
+	}
+	if len(b) < 4 {
+		return Tags{}
+	}
+	count := binary.LittleEndian.Uint32(b)
+	b = b[4:]
+	var t Tags
+	for range min(count, maxBoxes) {
+		c, ok := next()
+		if !ok {
+			break
+		}
+		key, value, ok := strings.Cut(c, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		set := func(dst *string) {
+			if *dst == "" {
+				*dst = value
+			}
+		}
+		switch strings.ToUpper(key) {
+		case "TITLE":
+			set(&t.Title)
+		case "ARTIST":
+			set(&t.Artist)
+		case "ALBUM":
+			set(&t.Album)
+		case "ALBUMARTIST", "ALBUM ARTIST":
+			set(&t.AlbumArtist)
+		case "GENRE":
+			set(&t.Genre)
+		case "DATE", "YEAR":
+			if t.Year == "" {
+				t.Year = yearOf(value)
+			}
+		case "TRACKNUMBER":
+			if t.Track == 0 {
+				t.Track = leadingNumber(value)
+			}
+		case "DISCNUMBER":
+			if t.Disc == 0 {
+				t.Disc = leadingNumber(value)
+			}
+		}
+	}
+	return t
+}
+
+// readMP4Tags reads the iTunes-style tags in moov/udta/meta/ilst.
+func readMP4Tags(r io.ReaderAt, size int64) Tags {
+	find := func(boxes []box, typ string) (box, bool) {
+		for _, b := range boxes {
+			if b.typ == typ {
+				return b, true
+			}
+		}
+		return box{}, false
+	}
+	moov, ok := find(readBoxes(r, 0, size), "moov")
+	if !ok {
+		return Tags{}
+	}
+	udta, ok := find(readBoxes(r, moov.start, moov.start+moov.size), "udta")
+	if !ok {
+		return Tags{}
+	}
+	meta, ok := find(readBoxes(r, udta.start, udta.start+udta.size), "meta")
+	if !ok {
+		return Tags{}
+	}
+	// meta is a full box in MP4 files but a plain one in QuickTime files:
+	// skip version and flags only when they are there.
+	first := meta.start
+	var probe [8]byte
+	if _, err := r.ReadAt(probe[:], meta.start); err == nil && binary.BigEndian.Uint32(probe[:4]) == 0 {
+		first += 4
+	}
+	ilst, ok := find(readBoxes(r, first, meta.start+meta.size), "ilst")
+	if !ok {
+		return Tags{}
+	}
+
+	var t Tags
+	for _, item := range readBoxes(r, ilst.start, ilst.start+ilst.size) {
+		data, ok := find(readBoxes(r, item.start, item.start+item.size), "data")
+		if !ok || data.size < 8 || data.size > maxTagSize {
+			continue
+		}
+		payload := make([]byte, data.size)
+		if _, err := r.ReadAt(payload, data.start); err != nil {
+			continue
+		}
+		value := payload[8:] // type indicator and locale
+		text := strings.TrimSpace(string(value))
+		switch item.typ {
+		case "\xa9nam":
+			t.Title = text
+		case "\xa9ART":
+			t.Artist = text
+		case "aART":
+			t.AlbumArtist = text
+		case "\xa9alb":
+			t.Album = text
+		case "\xa9gen":
+			t.Genre = text
+		case "gnre":
+			if len(value) >= 2 {
+				if g := int(binary.BigEndian.Uint16(value)) - 1; g >= 0 && g < len(id3Genres) {
+					t.Genre = id3Genres[g]
+				}
+			}
+		case "\xa9day":
+			t.Year = yearOf(text)
+		case "trkn":
+			if len(value) >= 4 {
+				t.Track = int(binary.BigEndian.Uint16(value[2:]))
+			}
+		case "disk":
+			if len(value) >= 4 {
+				t.Disc = int(binary.BigEndian.Uint16(value[2:]))
+			}
+		}
+	}
+	return t
+}
+
+// leadingNumber parses the number at the start of s, as in "3/12".
+func leadingNumber(s string) int {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(s[:end])
+	return n
+}
+
+// yearOf returns the year of a tag date such as "1997", "1997-05-12" or
+// "1997-05-12T10:00:00Z", or "" when s does not start with one.
+func yearOf(s string) string {
+	if len(s) < 4 {
+		return ""
+	}
+	for _, c := range s[:4] {
+		if c < '0' || c > '9' {
+			return ""
+		}
+	}
+	return s[:4]
+}
+
+// id3Genre resolves a TCON value that refers to an ID3v1 genre by number,
+// as in "17" or "(17)" or "(17)Rock", to its name.
+func id3Genre(s string) string {
+	ref := strings.TrimPrefix(s, "(")
+	if n := leadingNumber(ref); ref != "" && ref[0] >= '0' && ref[0] <= '9' {
+		rest := strings.TrimLeft(ref, "0123456789")
+		rest = strings.TrimPrefix(rest, ")")
+		if rest != "" {
+			return rest
+		}
+		if n < len(id3Genres) {
+			return id3Genres[n]
+		}
+	}
+	return s
+}
+
+// id3Genres are the genres ID3v1 numbers 0-79.
+var id3Genres = []string{
+	"Blues", "Classic Rock", "Country", "Dance", "Disco", "Funk", "Grunge", "Hip-Hop",
+	"Jazz", "Metal", "New Age", "Oldies", "Other", "Pop", "R&B", "Rap",
+	"Reggae", "Rock", "Techno", "Industrial", "Alternative", "Ska", "Death Metal", "Pranks",
+	"Soundtrack", "Euro-Techno", "Ambient", "Trip-Hop", "Vocal", "Jazz+Funk", "Fusion", "Trance",
+	"Classical", "Instrumental", "Acid", "House", "Game", "Sound Clip", "Gospel", "Noise",
+	"AlternRock", "Bass", "Soul", "Punk", "Space", "Meditative", "Instrumental Pop", "Instrumental Rock",
+	"Ethnic", "Gothic", "Darkwave", "Techno-Industrial", "Electronic", "Pop-Folk", "Eurodance", "Dream",
+	"Southern Rock", "Comedy", "Cult", "Gangsta", "Top 40", "Christian Rap", "Pop/Funk", "Jungle",
+	"Native American", "Cabaret", "New Wave", "Psychedelic", "Rave", "Showtunes", "Trailer", "Lo-Fi",
+	"Tribal", "Acid Punk", "Acid Jazz", "Polka", "Retro", "Musical", "Rock & Roll", "Hard Rock",
+}
@@ -0,0 +1,190 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func syncsafeBytes(n int) []byte {
+	return []byte{byte(n >> 21 & 0x7F), byte(n >> 14 & 0x7F), byte(n >> 7 & 0x7F), byte(n & 0x7F)}
+}
+
+// id3v2 builds an ID3v2 tag of the given major version (3 or 4) from text
+// frames, each encoded as UTF-8 (v2.4) or ISO-8859-1 (v2.3).
+func id3v2(version byte, frames ...[2]string) []byte {
+	var body bytes.Buffer
+	for _, f := range frames {
+		enc := byte(0)
+		if version == 4 {
+			enc = 3
+		}
+		data := append([]byte{enc}, f[1]...)
+		body.WriteString(f[0])
+		if version == 4 {
+			body.Write(syncsafeBytes(len(data)))
+		} else {
+			_ = binary.Write(&body, binary.BigEndian, uint32(len(data)))
+		}
+		body.Write([]byte{0, 0})
+		body.Write(data)
+	}
+	body.Write(make([]byte, 16)) // padding
+	out := append([]byte{'I', 'D', '3', version, 0, 0}, syncsafeBytes(body.Len())...)
+	return append(out, body.Bytes()...)
+}
+
+func id3v1(title, artist, album, year string, track, genre byte) []byte {
+	b := make([]byte, 128)
+	copy(b, "TAG")
+	copy(b[3:33], title)
+	copy(b[33:63], artist)
+	copy(b[63:93], album)
+	copy(b[93:97], year)
+	b[126] = track
+	b[127] = genre
+	return b
+}
+
+func flacWith(comments ...string) []byte {
+	var vc bytes.Buffer
+	str := func(s string) {
+		_ = binary.Write(&vc, binary.LittleEndian, uint32(len(s)))
+		vc.WriteString(s)
+	}
+	str("reference libFLAC 1.4.3")
+	_ = binary.Write(&vc, binary.LittleEndian, uint32(len(comments)))
+	for _, c := range comments {
+		str(c)
+	}
+	var b bytes.Buffer
+	b.WriteString("fLaC")
+	b.Write([]byte{0x00, 0, 0, 34}) // STREAMINFO
+	b.Write(make([]byte, 34))
+	n := vc.Len()
+	b.Write([]byte{0x80 | 4, byte(n >> 16), byte(n >> 8), byte(n)})
+	b.Write(vc.Bytes())
+	return b.Bytes()
+}
+
+func ilstItem(typ string, dataType uint32, value []byte) []byte {
+	return isoBox(typ, isoBox("data", binary.BigEndian.AppendUint32(nil, dataType), make([]byte, 4), value))
+}
+
+func m4aWith(items ...[]byte) []byte {
+	hdlr := isoBox("hdlr", make([]byte, 8), []byte("mdirappl"), make([]byte, 9))
+	meta := isoBox("meta", []byte{0, 0, 0, 0}, hdlr, isoBox("ilst", items...))
+	moov := isoBox("moov", isoBox("mvhd", make([]byte, 100)), isoBox("udta", meta))
+	return append(isoBox("ftyp", []byte("M4A \x00\x00\x00\x00M4A isom")), moov...)
+}
+
+func TestDecodeTags(t *testing.T) {
+	t.Parallel()
+	want := Tags{
+		Title:       "So What",
+		Artist:      "Miles Davis",
+		Album:       "Kind of Blue",
+		AlbumArtist: "Miles Davis",
+		Genre:       "Jazz",
+		Year:        "1959",
+		Track:       1,
+		Disc:        1,
+	}
+	frames := [][2]string{
+		{"TIT2", "So What"}, {"TPE1", "Miles Davis"}, {"TALB", "Kind of Blue"}, {"TPE2", "Miles Davis"},
+		{"TCON", "(8)"}, {"TRCK", "1/5"}, {"TPOS", "1/1"},
+	}
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{"id3v2.3", append(id3v2(3, append(frames, [2]string{"TYER", "1959"})...), make([]byte, 64)...)},
+		{"id3v2.4", append(id3v2(4, append(frames, [2]string{"TDRC", "1959-08-17"})...), make([]byte, 64)...)},
+		{"flac", flacWith("TITLE=So What", "ARTIST=Miles Davis", "album=Kind of Blue", "ALBUMARTIST=Miles Davis",
+			"GENRE=Jazz", "DATE=1959", "TRACKNUMBER=01", "DISCNUMBER=1")},
+		{"m4a", m4aWith(
+			ilstItem("\xa9nam", 1, []byte("So What")),
+			ilstItem("\xa9ART", 1, []byte("Miles Davis")),
+			ilstItem("\xa9alb", 1, []byte("Kind of Blue")),
+			ilstItem("aART", 1, []byte("Miles Davis")),
+			ilstItem("gnre", 0, []byte{0, 9}),
+			ilstItem("\xa9day", 1, []byte("1959-08-17T07:00:00Z")),
+			ilstItem("trkn", 0, []byte{0, 0, 0, 1, 0, 5, 0, 0}),
+			ilstItem("disk", 0, []byte{0, 0, 0, 1, 0, 1}),
+		)},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := DecodeTags(bytes.NewReader(tt.data), int64(len(tt.data)))
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+// TestDecodeTags_ID3v1 verifies that an ID3v1 tag is read on its own and
+// fills the gaps of an ID3v2 tag.
+func TestDecodeTags_ID3v1(t *testing.T) {
+	t.Parallel()
+	v1 := id3v1("Title v1", "Artist v1", "Album v1", "2001", 7, 17)
+	data := append(make([]byte, 300), v1...)
+	got, err := DecodeTags(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	assert.Equal(t, Tags{Title: "Title v1", Artist: "Artist v1", Album: "Album v1", Genre: "Rock", Year: "2001", Track: 7}, got)
+
+	data = append(append(id3v2(3, [2]string{"TIT2", "Title v2"}), make([]byte, 100)...), v1...)
+	got, err = DecodeTags(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	assert.Equal(t, "Title v2", got.Title, "ID3v2 wins")
+	assert.Equal(t, "Artist v1", got.Artist, "ID3v1 fills the gaps")
+}
+
+func TestID3Text_Encodings(t *testing.T) {
+	t.Parallel()
+	utf16le := []byte{1, 0xFF, 0xFE}
+	for _, u := range utf16.Encode([]rune("Björk")) {
+		utf16le = binary.LittleEndian.AppendUint16(utf16le, u)
+	}
+	assert.Equal(t, "Björk", id3Text(utf16le))
+	assert.Equal(t, "Björk", id3Text([]byte{0, 'B', 'j', 0xF6, 'r', 'k'}), "ISO-8859-1")
+	assert.Equal(t, "AC/DC", id3Text(append([]byte{3}, "AC/DC\x00Other"...)), "first of several values")
+}
+
+func TestID3Genre(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "Rock", id3Genre("17"))
+	assert.Equal(t, "Rock", id3Genre("(17)"))
+	assert.Equal(t, "Heavy Rock", id3Genre("(17)Heavy Rock"))
+	assert.Equal(t, "Shoegaze", id3Genre("Shoegaze"))
+}
+
+func TestDecodeTags_NoTags(t *testing.T) {
+	t.Parallel()
+	for name, data := range map[string][]byte{
+		"text":       []byte("hello, world"),
+		"flac empty": flacWith(),
+		"mp3 bare":   append([]byte{0xFF, 0xFB, 0x90, 0x00}, make([]byte, 400)...),
+	} {
+		_, err := DecodeTags(bytes.NewReader(data), int64(len(data)))
+		assert.ErrorIs(t, err, ErrNoTags, name)
+	}
+}
+
+func TestReadTags(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "01.flac")
+	require.NoError(t, os.WriteFile(path, flacWith("ARTIST=Nina Simone"), 0o600))
+	tags, err := ReadTags(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Nina Simone", tags.Artist)
+
+	_, err = ReadTags(filepath.Join(t.TempDir(), "missing.mp3"))
+	assert.Error(t, err)
+}
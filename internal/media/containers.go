@@ -0,0 +1,269 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// maxBoxes bounds how many segments, chunks or boxes a container walk visits.
+const maxBoxes = 4096
+
+// findJPEGExif returns the position of the TIFF block in the APP1 "Exif"
+// segment of a JPEG file. It stops at the start of the image data.
+func findJPEGExif(r io.ReaderAt, size int64) (base, limit int64, err error) {
+	off := int64(2)
+	var hdr [4]byte
+	for range maxBoxes {
+		if off+4 > size {
+			break
+		}
+		if _, err := r.ReadAt(hdr[:], off); err != nil {
+			return 0, 0, ErrNoMetadata
+		}
+		if hdr[0] != 0xFF {
+			break
+		}
+		marker := hdr[1]
+		if marker == 0xD8 || marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			off += 2 // markers without a length
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			break // start of scan or end of image: no metadata past this point
+		}
+		length := int64(binary.BigEndian.Uint16(hdr[2:]))
+		if marker == 0xE1 && length >= 8 {
+			var id [6]byte
+			if _, err := r.ReadAt(id[:], off+4); err == nil && string(id[:]) == "Exif\x00\x00" {
+				return off + 10, length - 8, nil
+			}
+		}
+		off += 2 + length
+	}
+	return 0, 0, ErrNoMetadata
+}
+
+// findPNGExif returns the position of the eXIf chunk of a PNG file.
+func findPNGExif(r io.ReaderAt, size int64) (base, limit int64, err error) {
+	off := int64(8)
+	var hdr [8]byte
+	for range maxBoxes {
+		if off+8 > size {
+			break
+		}
+		if _, err := r.ReadAt(hdr[:], off); err != nil {
+			break
+		}
+		length := int64(binary.BigEndian.Uint32(hdr[:4]))
+		switch string(hdr[4:]) {
+		case "eXIf":
+			return off + 8, length, nil
+		case "IEND":
+			return 0, 0, ErrNoMetadata
+		}
+		off += 12 + length // length, type, data and CRC
+	}
+	return 0, 0, ErrNoMetadata
+}
+
+// box is one ISO base media file format box: its type and where its payload
+// lies.
+type box struct {
+	typ         string
+	start, size int64
+}
+
+// readBoxes lists the boxes in r between off and end.
+func readBoxes(r io.ReaderAt, off, end int64) []box {
+	var boxes []box
+	var hdr [16]byte
+	for range maxBoxes {
+		if off+8 > end {
+			break
+		}
+		if _, err := r.ReadAt(hdr[:8], off); err != nil {
+			break
+		}
+		size := int64(binary.BigEndian.Uint32(hdr[:4]))
+		typ := string(hdr[4:8])
+		headerLen := int64(8)
+		switch size {
+		case 0:
+			size = end - off
+		case 1:
+			if _, err := r.ReadAt(hdr[8:16], off+8); err != nil {
+				return boxes
+			}
+			size = int64(binary.BigEndian.Uint64(hdr[8:16])) //#nosec G115 -- checked against end below
+			headerLen = 16
+		}
+		if size < headerLen || off+size > end {
+			break
+		}
+		boxes = append(boxes, box{typ: typ, start: off + headerLen, size: size - headerLen})
+		off += size
+	}
+	return boxes
+}
+
+// findISOBMFFExif returns the position of the TIFF block of the "Exif" item
+// of a HEIC, HEIF or AVIF file: the item is named in the iinf box and located
+// by the iloc box of the top-level meta box.
+func findISOBMFFExif(r io.ReaderAt, size int64) (base, limit int64, err error) {
+	for _, top := range readBoxes(r, 0, size) {
+		if top.typ != "meta" {
+			continue
+		}
+		// meta is a full box: skip its version and flags.
+		children := readBoxes(r, top.start+4, top.start+top.size)
+		var itemID uint32
+		var found bool
+		for _, b := range children {
+			if b.typ == "iinf" {
+				itemID, found = exifItemID(r, b)
+			}
+		}
+		if !found {
+			return 0, 0, ErrNoMetadata
+		}
+		for _, b := range children {
+			if b.typ != "iloc" {
+				continue
+			}
+			off, length, err := locateItem(r, b, itemID)
+			if err != nil {
+				return 0, 0, err
+			}
+			// The item starts with the offset from its payload to the TIFF header.
+			var skip [4]byte
+			if _, err := r.ReadAt(skip[:], off); err != nil {
+				return 0, 0, ErrNoMetadata
+			}
+			headerOff := 4 + int64(binary.BigEndian.Uint32(skip[:]))
+			if headerOff >= length {
+				return 0, 0, ErrNoMetadata
+			}
+			return off + headerOff, length - headerOff, nil
+		}
+	}
+	return 0, 0, ErrNoMetadata
+}
+
+// exifItemID returns the ID of the item of type "Exif" listed in iinf.
+func exifItemID(r io.ReaderAt, iinf box) (uint32, bool) {
+	var vf [4]byte
+	if _, err := r.ReadAt(vf[:], iinf.start); err != nil {
+		return 0, false
+	}
+	first := iinf.start + 6 // version/flags and a 16-bit entry count
+	if vf[0] != 0 {
+		first += 2 // a 32-bit entry count
+	}
+	for _, infe := range readBoxes(r, first, iinf.start+iinf.size) {
+		if infe.typ != "infe" || infe.size < 12 {
+			continue
+		}
+		buf := make([]byte, min(infe.size, 16))
+		if _, err := r.ReadAt(buf, infe.start); err != nil {
+			continue
+		}
+		var id uint32
+		var itemType []byte
+		switch buf[0] {
+		case 2:
+			id = uint32(binary.BigEndian.Uint16(buf[4:]))
+			itemType = buf[8:12]
+		case 3:
+			if len(buf) < 14 {
+				continue
+			}
+			id = binary.BigEndian.Uint32(buf[4:])
+			itemType = buf[10:14]
+		default:
+			continue
+		}
+		if bytes.Equal(itemType, []byte("Exif")) {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// locateItem returns the file offset and length of the first extent of item
+// id, as recorded in iloc. Items stored in another file or by reference to
+// other items are not supported.
+func locateItem(r io.ReaderAt, iloc box, id uint32) (int64, int64, error) {
+	buf := make([]byte, min(iloc.size, 1<<16))
+	if _, err := r.ReadAt(buf, iloc.start); err != nil && !errors.Is(err, io.EOF) {
+		return 0, 0, ErrNoMetadata
+	}
+	p := ilocParser{buf: buf}
+	version := p.uint(1)
+	p.uint(3) // flags
+	sizes := p.uint(2)
+	offsetSize, lengthSize := int(sizes>>12&0xF), int(sizes>>8&0xF)
+	baseSize, indexSize := int(sizes>>4&0xF), int(sizes&0xF)
+	if version == 0 {
+		indexSize = 0
+	}
+	count := p.uint(2)
+	if version == 2 {
+		count = p.uint(4)
+	}
+	for range min(count, maxBoxes) {
+		var itemID uint64
+		if version == 2 {
+			itemID = p.uint(4)
+		} else {
+			itemID = p.uint(2)
+		}
+		method := uint64(0)
+		if version == 1 || version == 2 {
+			method = p.uint(2) & 0xF
+		}
+		p.uint(2) // data reference index
+		baseOffset := p.uint(baseSize)
+		extents := p.uint(2)
+		var extOffset, extLength uint64
+		for e := range min(extents, maxBoxes) {
+			p.uint(indexSize)
+			o, l := p.uint(offsetSize), p.uint(lengthSize)
+			if e == 0 {
+				extOffset, extLength = o, l
+			}
+		}
+		if p.err {
+			break
+		}
+		if uint32(itemID) == id { //#nosec G115 -- item IDs are at most 32 bits
+			if method != 0 || extents == 0 {
+				return 0, 0, ErrNoMetadata
+			}
+			return int64(baseOffset + extOffset), int64(extLength), nil //#nosec G115 -- offsets are bounded by the file size in practice; bad values fail the reads
+		}
+	}
+	return 0, 0, ErrNoMetadata
+}
+
+// ilocParser reads the variable-width big-endian integers of an iloc box.
+// Reading past the end sets err and yields zeros.
+type ilocParser struct {
+	buf []byte
+	off int
+	err bool
+}
+
+func (p *ilocParser) uint(n int) uint64 {
+	if p.off+n > len(p.buf) {
+		p.err = true
+		return 0
+	}
+	var v uint64
+	for _, b := range p.buf[p.off : p.off+n] {
+		v = v<<8 | uint64(b)
+	}
+	p.off += n
+	return v
+}
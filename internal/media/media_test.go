@@ -0,0 +1,229 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tag is one IFD entry for buildTIFF. A LONG tag with a nil value and an id
+// of tagExifIFD or tagGPSIFD is filled with the offset of that sub-IFD.
+type tag struct {
+	id, typ uint16
+	count   uint32
+	value   []byte
+}
+
+func ascii(id uint16, s string) tag {
+	return tag{id: id, typ: 2, count: uint32(len(s) + 1), value: append([]byte(s), 0)}
+}
+
+func short(order binary.ByteOrder, id, v uint16) tag {
+	b := make([]byte, 2)
+	order.PutUint16(b, v)
+	return tag{id: id, typ: 3, count: 1, value: b}
+}
+
+// buildTIFF lays out a TIFF block: the header, IFD0, the Exif and GPS IFDs
+// when they have entries, then the out-of-line values.
+func buildTIFF(order binary.ByteOrder, ifd0, exif, gps []tag) []byte {
+	if len(exif) > 0 {
+		ifd0 = append(ifd0, tag{id: tagExifIFD, typ: 4, count: 1})
+	}
+	if len(gps) > 0 {
+		ifd0 = append(ifd0, tag{id: tagGPSIFD, typ: 4, count: 1})
+	}
+	ifds := [][]tag{ifd0, exif, gps}
+	offsets := make([]int, len(ifds))
+	pos := 8
+	for i, ifd := range ifds {
+		if len(ifd) > 0 {
+			offsets[i] = pos
+			pos += 2 + 12*len(ifd) + 4
+		}
+	}
+	data := pos
+
+	var out bytes.Buffer
+	if order == binary.LittleEndian {
+		out.WriteString("II*\x00")
+	} else {
+		out.WriteString("MM\x00*")
+	}
+	_ = binary.Write(&out, order, uint32(8))
+	var extra bytes.Buffer
+	for _, ifd := range ifds {
+		if len(ifd) == 0 {
+			continue
+		}
+		_ = binary.Write(&out, order, uint16(len(ifd)))
+		for _, t := range ifd {
+			_ = binary.Write(&out, order, t.id)
+			_ = binary.Write(&out, order, t.typ)
+			_ = binary.Write(&out, order, t.count)
+			value := make([]byte, 4)
+			switch {
+			case t.id == tagExifIFD && t.value == nil:
+				order.PutUint32(value, uint32(offsets[1]))
+			case t.id == tagGPSIFD && t.value == nil:
+				order.PutUint32(value, uint32(offsets[2]))
+			case len(t.value) <= 4:
+				copy(value, t.value)
+			default:
+				order.PutUint32(value, uint32(data+extra.Len()))
+				extra.Write(t.value)
+			}
+			out.Write(value)
+		}
+		_ = binary.Write(&out, order, uint32(0))
+	}
+	out.Write(extra.Bytes())
+	return out.Bytes()
+}
+
+func sampleTIFF(order binary.ByteOrder) []byte {
+	return buildTIFF(order,
+		[]tag{ascii(tagMake, "Canon"), ascii(tagModel, "Canon EOS R6"), ascii(tagDateTime, "2024:01:01 00:00:00")},
+		[]tag{
+			ascii(tagDateTimeOriginal, "2023:07:14 18:30:05"),
+			ascii(tagOffsetTimeOrig, "+02:00"),
+			short(order, tagISO, 400),
+			ascii(tagLensModel, "RF24-105mm F4 L IS USM"),
+		},
+		[]tag{{id: tagGPSLatitude, typ: 5, count: 3, value: make([]byte, 24)}},
+	)
+}
+
+func jpegWith(tiff []byte) []byte {
+	var b bytes.Buffer
+	b.Write([]byte{0xFF, 0xD8})
+	b.Write([]byte{0xFF, 0xE0, 0x00, 0x04, 0x00, 0x00}) // APP0 before APP1
+	b.Write([]byte{0xFF, 0xE1})
+	_ = binary.Write(&b, binary.BigEndian, uint16(len(tiff)+8))
+	b.WriteString("Exif\x00\x00")
+	b.Write(tiff)
+	b.Write([]byte{0xFF, 0xDA, 0x00, 0x02, 0xFF, 0xD9})
+	return b.Bytes()
+}
+
+func pngWith(tiff []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("\x89PNG\r\n\x1a\n")
+	chunk := func(typ string, data []byte) {
+		_ = binary.Write(&b, binary.BigEndian, uint32(len(data)))
+		b.WriteString(typ)
+		b.Write(data)
+		b.Write(make([]byte, 4)) // CRC, not checked
+	}
+	chunk("IHDR", make([]byte, 13))
+	chunk("eXIf", tiff)
+	chunk("IEND", nil)
+	return b.Bytes()
+}
+
+func isoBox(typ string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	out := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(out, typ...), body...)
+}
+
+// heicWith wraps tiff as the Exif item of a minimal HEIC file: ftyp, then a
+// meta box with iinf and a version 1 iloc pointing at an mdat payload.
+func heicWith(tiff []byte) []byte {
+	ftyp := isoBox("ftyp", []byte("heic\x00\x00\x00\x00mif1heic"))
+	infe := isoBox("infe", []byte{2, 0, 0, 0}, []byte{0, 7, 0, 0}, []byte("Exif"), []byte{0})
+	iinf := isoBox("iinf", []byte{0, 0, 0, 0, 0, 1}, infe)
+	item := append([]byte{0, 0, 0, 0}, tiff...) // zero offset to the TIFF header
+
+	build := func(mdatStart int) []byte {
+		iloc := isoBox("iloc",
+			[]byte{1, 0, 0, 0}, // version 1
+			[]byte{0x44, 0x00}, // offset and length sizes 4, base offset and index sizes 0
+			[]byte{0, 1},       // one item
+			[]byte{0, 7},       // item ID
+			[]byte{0, 0},       // construction method 0
+			[]byte{0, 0},       // data reference index
+			[]byte{0, 1},       // one extent
+			binary.BigEndian.AppendUint32(nil, uint32(mdatStart+8)),
+			binary.BigEndian.AppendUint32(nil, uint32(len(item))),
+		)
+		meta := isoBox("meta", []byte{0, 0, 0, 0}, iinf, iloc)
+		return append(ftyp, meta...)
+	}
+	head := build(0)
+	head = build(len(head))
+	return append(head, isoBox("mdat", item)...)
+}
+
+// TestDecode verifies that every supported container yields the same
+// metadata from the same EXIF block.
+func TestDecode(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{"tiff little-endian", sampleTIFF(binary.LittleEndian)},
+		{"tiff big-endian", sampleTIFF(binary.BigEndian)},
+		{"jpeg", jpegWith(sampleTIFF(binary.BigEndian))},
+		{"png", pngWith(sampleTIFF(binary.LittleEndian))},
+		{"heic", heicWith(sampleTIFF(binary.BigEndian))},
+	}
+	want := time.Date(2023, 7, 14, 18, 30, 5, 0, time.FixedZone("+02:00", 2*3600))
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			md, err := Decode(bytes.NewReader(tt.data), int64(len(tt.data)))
+			require.NoError(t, err)
+			assert.Equal(t, "Canon", md.Make)
+			assert.Equal(t, "Canon EOS R6", md.Model)
+			assert.Equal(t, "RF24-105mm F4 L IS USM", md.Lens)
+			assert.Equal(t, 400, md.ISO)
+			assert.True(t, md.HasGPS)
+			assert.True(t, want.Equal(md.Taken), "got %v", md.Taken)
+		})
+	}
+}
+
+// TestDecode_TakenFallsBack verifies that without DateTimeOriginal the IFD0
+// DateTime is used, read as local time.
+func TestDecode_TakenFallsBack(t *testing.T) {
+	t.Parallel()
+	data := buildTIFF(binary.LittleEndian, []tag{ascii(tagModel, "X100V"), ascii(tagDateTime, "2022:12:31 23:59:59")}, nil, nil)
+	md, err := Decode(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	assert.Equal(t, "X100V", md.Model)
+	assert.Equal(t, time.Date(2022, 12, 31, 23, 59, 59, 0, time.Local), md.Taken)
+	assert.False(t, md.HasGPS)
+}
+
+func TestDecode_NoMetadata(t *testing.T) {
+	t.Parallel()
+	for name, data := range map[string][]byte{
+		"text":          []byte("hello, world"),
+		"jpeg without":  {0xFF, 0xD8, 0xFF, 0xDA, 0x00, 0x02, 0xFF, 0xD9},
+		"png without":   pngWith(nil)[:33],
+		"truncated tif": sampleTIFF(binary.LittleEndian)[:10],
+	} {
+		_, err := Decode(bytes.NewReader(data), int64(len(data)))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "IMG_0001.jpg")
+	require.NoError(t, os.WriteFile(path, jpegWith(sampleTIFF(binary.BigEndian)), 0o600))
+	md, err := Read(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Canon EOS R6", md.Model)
+
+	_, err = Read(filepath.Join(t.TempDir(), "missing.jpg"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,183 @@
+package media
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// TIFF tags read by parseTIFF. IFD0 holds the camera and the pointers to the
+// Exif and GPS sub-IFDs; the Exif IFD holds the capture details.
+const (
+	tagMake              = 0x010F
+	tagModel             = 0x0110
+	tagDateTime          = 0x0132
+	tagExifIFD           = 0x8769
+	tagGPSIFD            = 0x8825
+	tagISO               = 0x8827
+	tagDateTimeOriginal  = 0x9003
+	tagDateTimeDigitized = 0x9004
+	tagOffsetTimeOrig    = 0x9011
+	tagOffsetTimeDigit   = 0x9012
+	tagLensModel         = 0xA434
+	tagGPSLatitude       = 0x0002
+)
+
+// maxIFDEntries bounds the entries read from one IFD, so a corrupt count
+// cannot make the reader allocate or loop without end.
+const maxIFDEntries = 1024
+
+// maxStringLen bounds how many bytes of an ASCII value are read.
+const maxStringLen = 256
+
+// exifTimeLayout is how EXIF records dates and times.
+const exifTimeLayout = "2006:01:02 15:04:05"
+
+// tiffReader reads IFD entries from a TIFF block.
+type tiffReader struct {
+	r     io.ReaderAt
+	order binary.ByteOrder
+}
+
+// ifdEntry is one tag of an IFD, with its value still encoded.
+type ifdEntry struct {
+	tag, typ uint16
+	count    uint32
+	// value holds the four value/offset bytes of the entry.
+	value [4]byte
+}
+
+// typeSizes is the byte size of one value of each TIFF field type.
+var typeSizes = map[uint16]uint32{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8}
+
+// parseTIFF reads the metadata from a TIFF block, as found in a TIFF file or
+// embedded in another container.
+func parseTIFF(r io.ReaderAt) (Metadata, error) {
+	header := make([]byte, 8)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return Metadata{}, ErrNoMetadata
+	}
+	t := tiffReader{r: r}
+	switch string(header[:4]) {
+	case "II*\x00":
+		t.order = binary.LittleEndian
+	case "MM\x00*":
+		t.order = binary.BigEndian
+	default:
+		return Metadata{}, ErrNoMetadata
+	}
+
+	ifd0, err := t.readIFD(int64(t.order.Uint32(header[4:])))
+	if err != nil {
+		return Metadata{}, err
+	}
+	var md Metadata
+	md.Make = t.str(ifd0[tagMake])
+	md.Model = t.str(ifd0[tagModel])
+	dateTime := t.str(ifd0[tagDateTime])
+
+	var original, digitized, offOriginal, offDigitized string
+	if e, ok := ifd0[tagExifIFD]; ok {
+		if exif, err := t.readIFD(int64(t.uint(e))); err == nil {
+			original = t.str(exif[tagDateTimeOriginal])
+			digitized = t.str(exif[tagDateTimeDigitized])
+			offOriginal = t.str(exif[tagOffsetTimeOrig])
+			offDigitized = t.str(exif[tagOffsetTimeDigit])
+			md.Lens = t.str(exif[tagLensModel])
+			if iso, ok := exif[tagISO]; ok {
+				md.ISO = int(t.uint(iso))
+			}
+		}
+	}
+	if e, ok := ifd0[tagGPSIFD]; ok {
+		if gps, err := t.readIFD(int64(t.uint(e))); err == nil {
+			_, md.HasGPS = gps[tagGPSLatitude]
+		}
+	}
+
+	switch {
+	case original != "":
+		md.Taken = parseExifTime(original, offOriginal)
+	case digitized != "":
+		md.Taken = parseExifTime(digitized, offDigitized)
+	case dateTime != "":
+		md.Taken = parseExifTime(dateTime, "")
+	}
+	return md, nil
+}
+
+// readIFD reads the entries of the IFD at off, keyed by tag.
+func (t tiffReader) readIFD(off int64) (map[uint16]ifdEntry, error) {
+	if off <= 0 {
+		return nil, fmt.Errorf("invalid IFD offset %d", off)
+	}
+	var countBuf [2]byte
+	if _, err := t.r.ReadAt(countBuf[:], off); err != nil {
+		return nil, err
+	}
+	count := min(int(t.order.Uint16(countBuf[:])), maxIFDEntries)
+	buf := make([]byte, count*12)
+	if _, err := t.r.ReadAt(buf, off+2); err != nil {
+		return nil, err
+	}
+	entries := make(map[uint16]ifdEntry, count)
+	for i := range count {
+		b := buf[i*12:]
+		e := ifdEntry{tag: t.order.Uint16(b), typ: t.order.Uint16(b[2:]), count: t.order.Uint32(b[4:])}
+		copy(e.value[:], b[8:12])
+		entries[e.tag] = e
+	}
+	return entries, nil
+}
+
+// str decodes an ASCII entry, trimming the NUL terminator and padding.
+func (t tiffReader) str(e ifdEntry) string {
+	if e.typ != 2 || e.count == 0 {
+		return ""
+	}
+	n := min(e.count, maxStringLen)
+	var b []byte
+	if e.count <= 4 {
+		b = e.value[:n]
+	} else {
+		b = make([]byte, n)
+		if _, err := t.r.ReadAt(b, int64(t.order.Uint32(e.value[:]))); err != nil {
+			return ""
+		}
+	}
+	if i := strings.IndexByte(string(b), 0); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// uint decodes the first value of a SHORT or LONG entry.
+func (t tiffReader) uint(e ifdEntry) uint32 {
+	switch e.typ {
+	case 3:
+		return uint32(t.order.Uint16(e.value[:]))
+	case 4:
+		return t.order.Uint32(e.value[:])
+	}
+	return 0
+}
+
+// parseExifTime parses an EXIF date and time, in the zone given by offset
+// ("+02:00") when it is set and in local time otherwise. A malformed or
+// blank ("0000:00:00 00:00:00") value gives the zero time.
+func parseExifTime(value, offset string) time.Time {
+	loc := time.Local
+	if offset != "" {
+		if z, err := time.Parse("-07:00", offset); err == nil {
+			_, secs := z.Zone()
+			loc = time.FixedZone(offset, secs)
+		}
+	}
+	ts, err := time.ParseInLocation(exifTimeLayout, value, loc)
+	if err != nil {
+		return time.Time{}
+	}
+	return ts
+}
@@ -0,0 +1,339 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// ErrNoVideo is returned when a file is not an MP4, MOV or Matroska (MKV,
+// WebM) container or records neither a duration nor a video track.
+var ErrNoVideo = errors.New("no video metadata")
+
+// mp4Epoch and mkvEpoch are the origins of MP4 and Matroska timestamps.
+var (
+	mp4Epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	mkvEpoch = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// Video is the container metadata read from a video file. Fields the file
+// does not record are left zero.
+type Video struct {
+	// Created is when the recording was made, in UTC as the container
+	// stores it: the mvhd creation time, or the Matroska DateUTC.
+	Created  time.Time
+	Duration time.Duration
+	// Width and Height are the display size of the first video track, with
+	// an MP4 rotation of 90 or 270 degrees applied, so portrait phone videos
+	// are taller than wide.
+	Width  int
+	Height int
+}
+
+// ReadVideo opens the file at path and reads its video metadata.
+func ReadVideo(path string) (Video, error) {
+	f, err := os.Open(path) //#nosec G304 -- path comes from the scanned source directory
+	if err != nil {
+		return Video{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return Video{}, err
+	}
+	return DecodeVideo(f, info.Size())
+}
+
+// DecodeVideo reads video metadata from r, a file of the given size. MP4 and
+// QuickTime files are read from the moov box (mvhd and tkhd), Matroska and
+// WebM files from the segment's Info and Tracks elements.
+func DecodeVideo(r io.ReaderAt, size int64) (Video, error) {
+	head := make([]byte, 8)
+	n, err := r.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return Video{}, err
+	}
+	head = head[:n]
+
+	var v Video
+	switch {
+	case bytes.HasPrefix(head, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		v = readMatroska(r, size)
+	case len(head) == 8 && isQuickTimeBox(string(head[4:8])):
+		v = readMP4Video(r, size)
+	default:
+		return Video{}, ErrNoVideo
+	}
+	if v.Duration == 0 && v.Height == 0 {
+		return Video{}, ErrNoVideo
+	}
+	return v, nil
+}
+
+// isQuickTimeBox reports whether typ may open an MP4 or QuickTime file: MP4
+// files start with ftyp, older MOV files with any of the others.
+func isQuickTimeBox(typ string) bool {
+	switch typ {
+	case "ftyp", "moov", "mdat", "wide", "free", "skip":
+		return true
+	}
+	return false
+}
+
+// readMP4Video reads the movie header and the first video track header.
+func readMP4Video(r io.ReaderAt, size int64) Video {
+	var v Video
+	for _, top := range readBoxes(r, 0, size) {
+		if top.typ != "moov" {
+			continue
+		}
+		for _, b := range readBoxes(r, top.start, top.start+top.size) {
+			switch b.typ {
+			case "mvhd":
+				readMvhd(r, b, &v)
+			case "trak":
+				if v.Height != 0 {
+					continue
+				}
+				for _, t := range readBoxes(r, b.start, b.start+b.size) {
+					if t.typ == "tkhd" {
+						v.Width, v.Height = readTkhd(r, t)
+					}
+				}
+			}
+		}
+		break
+	}
+	return v
+}
+
+// readMvhd reads the creation time and duration of a movie header box.
+func readMvhd(r io.ReaderAt, b box, v *Video) {
+	buf := make([]byte, min(b.size, 32))
+	if _, err := r.ReadAt(buf, b.start); err != nil || len(buf) < 20 {
+		return
+	}
+	var created, timescale, duration uint64
+	if buf[0] == 1 {
+		if len(buf) < 32 {
+			return
+		}
+		created = binary.BigEndian.Uint64(buf[4:])
+		timescale = uint64(binary.BigEndian.Uint32(buf[20:]))
+		duration = binary.BigEndian.Uint64(buf[24:])
+	} else {
+		created = uint64(binary.BigEndian.Uint32(buf[4:]))
+		timescale = uint64(binary.BigEndian.Uint32(buf[12:]))
+		duration = uint64(binary.BigEndian.Uint32(buf[16:]))
+	}
+	if created != 0 && created < math.MaxInt64/uint64(time.Second) {
+		v.Created = mp4Epoch.Add(time.Duration(created) * time.Second) //#nosec G115 -- bounded above
+	}
+	if timescale != 0 && duration != math.MaxUint32 && duration != math.MaxUint64 {
+		v.Duration = time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+	}
+}
+
+// readTkhd returns the display size a track header records, or zeros for a
+// track without one (audio, subtitles). A 90 or 270 degree rotation in the
+// transformation matrix swaps the two.
+func readTkhd(r io.ReaderAt, b box) (width, height int) {
+	buf := make([]byte, min(b.size, 96))
+	if _, err := r.ReadAt(buf, b.start); err != nil || len(buf) == 0 {
+		return 0, 0
+	}
+	base := 4 + 20 // version and flags; times, track ID, reserved, duration (v0)
+	if buf[0] == 1 {
+		base = 4 + 32
+	}
+	if len(buf) < base+60 {
+		return 0, 0
+	}
+	matrixA := int32(binary.BigEndian.Uint32(buf[base+16:])) //#nosec G115 -- signed 16.16 fixed point
+	matrixB := int32(binary.BigEndian.Uint32(buf[base+20:])) //#nosec G115 -- signed 16.16 fixed point
+	width = int(binary.BigEndian.Uint32(buf[base+52:]) >> 16)
+	height = int(binary.BigEndian.Uint32(buf[base+56:]) >> 16)
+	if matrixA == 0 && matrixB != 0 {
+		width, height = height, width
+	}
+	return width, height
+}
+
+// Matroska element IDs, with their length marker bits.
+const (
+	mkvSegment       = 0x18538067
+	mkvInfo          = 0x1549A966
+	mkvTimecodeScale = 0x2AD7B1
+	mkvDuration      = 0x4489
+	mkvDateUTC       = 0x4461
+	mkvTracks        = 0x1654AE6B
+	mkvTrackEntry    = 0xAE
+	mkvTrackType     = 0x83
+	mkvVideo         = 0xE0
+	mkvPixelWidth    = 0xB0
+	mkvPixelHeight   = 0xBA
+	mkvCluster       = 0x1F43B675
+)
+
+// ebmlElement is one EBML element: its ID and where its data lies.
+type ebmlElement struct {
+	id          int64
+	start, size int64
+}
+
+// readEBML lists the elements between off and end. An element of unknown
+// size runs to end.
+func readEBML(r io.ReaderAt, off, end int64) []ebmlElement {
+	var elems []ebmlElement
+	for range maxBoxes {
+		if off >= end {
+			break
+		}
+		id, idLen, ok := readVint(r, off, false)
+		if !ok {
+			break
+		}
+		size, sizeLen, ok := readVint(r, off+int64(idLen), true)
+		if !ok {
+			break
+		}
+		start := off + int64(idLen+sizeLen)
+		if size < 0 || start+size > end {
+			size = end - start
+		}
+		elems = append(elems, ebmlElement{id: id, start: start, size: size})
+		if id == mkvCluster {
+			break // media data: no header elements past this point
+		}
+		off = start + size
+	}
+	return elems
+}
+
+// readVint reads an EBML variable-length integer at off. IDs keep their
+// length marker; sizes drop it, and an all-ones size (unknown) is -1.
+func readVint(r io.ReaderAt, off int64, isSize bool) (int64, int, bool) {
+	var buf [8]byte
+	if _, err := r.ReadAt(buf[:1], off); err != nil || buf[0] == 0 {
+		return 0, 0, false
+	}
+	n := 1
+	for buf[0]&(0x80>>(n-1)) == 0 {
+		n++
+	}
+	if n > 1 {
+		if _, err := r.ReadAt(buf[1:n], off+1); err != nil {
+			return 0, 0, false
+		}
+	}
+	v := uint64(buf[0])
+	if isSize {
+		v &= uint64(0xFF >> n)
+	}
+	allOnes := v == uint64(0xFF>>n)
+	for _, b := range buf[1:n] {
+		v = v<<8 | uint64(b)
+		allOnes = allOnes && b == 0xFF
+	}
+	if isSize && allOnes {
+		return -1, n, true
+	}
+	return int64(v), n, true //#nosec G115 -- at most 56 bits
+}
+
+// readMatroska reads the Info and Tracks elements of the first segment.
+func readMatroska(r io.ReaderAt, size int64) Video {
+	var v Video
+	for _, top := range readEBML(r, 0, size) {
+		if top.id != mkvSegment {
+			continue
+		}
+		scale := int64(1_000_000) // default TimecodeScale: 1ms
+		var duration float64
+		for _, e := range readEBML(r, top.start, top.start+top.size) {
+			switch e.id {
+			case mkvInfo:
+				for _, f := range readEBML(r, e.start, e.start+e.size) {
+					switch f.id {
+					case mkvTimecodeScale:
+						if s := int64(ebmlUint(r, f)); s > 0 { //#nosec G115 -- at most 8 bytes
+							scale = s
+						}
+					case mkvDuration:
+						duration = ebmlFloat(r, f)
+					case mkvDateUTC:
+						v.Created = mkvEpoch.Add(time.Duration(int64(ebmlUint(r, f)))) //#nosec G115 -- signed nanoseconds
+					}
+				}
+			case mkvTracks:
+				if v.Height == 0 {
+					v.Width, v.Height = mkvVideoSize(r, e)
+				}
+			}
+		}
+		v.Duration = time.Duration(duration * float64(scale))
+		break
+	}
+	return v
+}
+
+// mkvVideoSize returns the pixel size of the first video track.
+func mkvVideoSize(r io.ReaderAt, tracks ebmlElement) (width, height int) {
+	for _, entry := range readEBML(r, tracks.start, tracks.start+tracks.size) {
+		if entry.id != mkvTrackEntry {
+			continue
+		}
+		var isVideo bool
+		var w, h int
+		for _, f := range readEBML(r, entry.start, entry.start+entry.size) {
+			switch f.id {
+			case mkvTrackType:
+				isVideo = ebmlUint(r, f) == 1
+			case mkvVideo:
+				for _, g := range readEBML(r, f.start, f.start+f.size) {
+					switch g.id {
+					case mkvPixelWidth:
+						w = int(ebmlUint(r, g)) //#nosec G115 -- pixel counts
+					case mkvPixelHeight:
+						h = int(ebmlUint(r, g)) //#nosec G115 -- pixel counts
+					}
+				}
+			}
+		}
+		if isVideo && h > 0 {
+			return w, h
+		}
+	}
+	return 0, 0
+}
+
+// ebmlUint reads an unsigned integer element of up to 8 bytes.
+func ebmlUint(r io.ReaderAt, e ebmlElement) uint64 {
+	if e.size < 1 || e.size > 8 {
+		return 0
+	}
+	buf := make([]byte, e.size)
+	if _, err := r.ReadAt(buf, e.start); err != nil {
+		return 0
+	}
+	var v uint64
+	for _, b := range buf {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
+
+// ebmlFloat reads a 4 or 8 byte float element.
+func ebmlFloat(r io.ReaderAt, e ebmlElement) float64 {
+	switch e.size {
+	case 4:
+		return float64(math.Float32frombits(uint32(ebmlUint(r, e)))) //#nosec G115 -- 4 bytes
+	case 8:
+		return math.Float64frombits(ebmlUint(r, e))
+	}
+	return 0
+}
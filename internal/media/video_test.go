@@ -0,0 +1,169 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mvhd builds a version 0 movie header.
+func mvhd(created time.Time, timescale, duration uint32) []byte {
+	b := make([]byte, 100)
+	binary.BigEndian.PutUint32(b[4:], uint32(created.Sub(mp4Epoch)/time.Second))
+	binary.BigEndian.PutUint32(b[12:], timescale)
+	binary.BigEndian.PutUint32(b[16:], duration)
+	return isoBox("mvhd", b)
+}
+
+// tkhd builds a version 0 track header, rotated by 90 degrees when rotate is
+// set.
+func tkhd(width, height uint32, rotate bool) []byte {
+	b := make([]byte, 84)
+	matrix := b[40:]
+	if rotate {
+		binary.BigEndian.PutUint32(matrix[4:], 0x10000)
+		binary.BigEndian.PutUint32(matrix[12:], 0xFFFF0000)
+	} else {
+		binary.BigEndian.PutUint32(matrix[0:], 0x10000)
+		binary.BigEndian.PutUint32(matrix[16:], 0x10000)
+	}
+	binary.BigEndian.PutUint32(matrix[32:], 0x40000000)
+	binary.BigEndian.PutUint32(b[76:], width<<16)
+	binary.BigEndian.PutUint32(b[80:], height<<16)
+	return isoBox("tkhd", b)
+}
+
+func movWith(brand string, rotate bool) []byte {
+	created := time.Date(2024, 8, 3, 17, 4, 5, 0, time.UTC)
+	moov := isoBox("moov",
+		mvhd(created, 600, 600*95),
+		isoBox("trak", tkhd(0, 0, false)), // audio track first
+		isoBox("trak", tkhd(1920, 1080, rotate)),
+	)
+	if brand == "" {
+		return append(isoBox("wide"), moov...)
+	}
+	return append(isoBox("ftyp", []byte(brand+"\x00\x00\x00\x00isom")), moov...)
+}
+
+// ebml builds one EBML element with a one-byte size when it fits.
+func ebml(id uint32, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	idBytes := binary.BigEndian.AppendUint32(nil, id)
+	for len(idBytes) > 1 && idBytes[0] == 0 {
+		idBytes = idBytes[1:]
+	}
+	var size []byte
+	if len(body) < 0x7F {
+		size = []byte{0x80 | byte(len(body))}
+	} else {
+		size = []byte{0x40 | byte(len(body)>>8), byte(len(body))}
+	}
+	return append(append(idBytes, size...), body...)
+}
+
+func mkvWith(unknownSize bool) []byte {
+	date := time.Date(2023, 12, 24, 20, 0, 0, 0, time.UTC)
+	info := ebml(mkvInfo,
+		ebml(mkvTimecodeScale, []byte{0x0F, 0x42, 0x40}),
+		ebml(mkvDuration, binary.BigEndian.AppendUint64(nil, math.Float64bits(754_500))),
+		ebml(mkvDateUTC, binary.BigEndian.AppendUint64(nil, uint64(date.Sub(mkvEpoch)))),
+	)
+	tracks := ebml(mkvTracks,
+		ebml(mkvTrackEntry, ebml(mkvTrackType, []byte{2})),
+		ebml(mkvTrackEntry, ebml(mkvTrackType, []byte{1}), ebml(mkvVideo,
+			ebml(mkvPixelWidth, []byte{0x0F, 0x00}),
+			ebml(mkvPixelHeight, []byte{0x08, 0x70}),
+		)),
+	)
+	segment := ebml(mkvSegment, info, tracks, ebml(mkvCluster, make([]byte, 16)))
+	if unknownSize {
+		body := append(append(info, tracks...), ebml(mkvCluster, make([]byte, 16))...)
+		segment = append([]byte{0x18, 0x53, 0x80, 0x67, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, body...)
+	}
+	return append(ebml(0x1A45DFA3, ebml(0x4282, []byte("matroska"))), segment...)
+}
+
+func TestDecodeVideo_MP4(t *testing.T) {
+	t.Parallel()
+	for name, data := range map[string][]byte{
+		"mp4":       movWith("mp42", false),
+		"quicktime": movWith("", false),
+	} {
+		v, err := DecodeVideo(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err, name)
+		assert.Equal(t, time.Date(2024, 8, 3, 17, 4, 5, 0, time.UTC), v.Created, name)
+		assert.Equal(t, 95*time.Second, v.Duration, name)
+		assert.Equal(t, [2]int{1920, 1080}, [2]int{v.Width, v.Height}, name)
+	}
+
+	data := movWith("qt  ", true)
+	v, err := DecodeVideo(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	assert.Equal(t, [2]int{1080, 1920}, [2]int{v.Width, v.Height}, "rotation swaps width and height")
+}
+
+func TestDecodeVideo_Matroska(t *testing.T) {
+	t.Parallel()
+	for name, data := range map[string][]byte{
+		"sized":        mkvWith(false),
+		"unknown size": mkvWith(true),
+	} {
+		v, err := DecodeVideo(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err, name)
+		assert.Equal(t, time.Date(2023, 12, 24, 20, 0, 0, 0, time.UTC), v.Created.UTC(), name)
+		assert.Equal(t, 754500*time.Millisecond, v.Duration, name)
+		assert.Equal(t, [2]int{3840, 2160}, [2]int{v.Width, v.Height}, name)
+	}
+}
+
+func TestDecodeVideo_NoVideo(t *testing.T) {
+	t.Parallel()
+	for name, data := range map[string][]byte{
+		"text":      []byte("hello, world"),
+		"photo":     heicWith(sampleTIFF(binary.BigEndian)),
+		"m4a tags":  m4aWith(ilstItem("\xa9nam", 1, []byte("x")))[:40],
+		"truncated": movWith("mp42", false)[:30],
+	} {
+		_, err := DecodeVideo(bytes.NewReader(data), int64(len(data)))
+		assert.ErrorIs(t, err, ErrNoVideo, name)
+	}
+}
+
+// TestDecodeVideo_MalformedBoxes verifies that track headers too short for
+// what they claim are skipped rather than read past their end.
+func TestDecodeVideo_MalformedBoxes(t *testing.T) {
+	t.Parallel()
+	created := time.Date(2024, 8, 3, 17, 4, 5, 0, time.UTC)
+	for name, header := range map[string][]byte{
+		"empty tkhd":    isoBox("tkhd"),
+		"short tkhd":    isoBox("tkhd", []byte{0, 0, 0, 0, 1, 2}),
+		"short v1 tkhd": isoBox("tkhd", append([]byte{1}, make([]byte, 90)...)),
+	} {
+		data := append(isoBox("ftyp", []byte("mp42\x00\x00\x00\x00isom")),
+			isoBox("moov", isoBox("trak", header), mvhd(created, 600, 600*95))...)
+		v, err := DecodeVideo(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err, name)
+		assert.Equal(t, 95*time.Second, v.Duration, name)
+		assert.Zero(t, v.Height, name)
+	}
+}
+
+func TestReadVideo(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "clip.mkv")
+	require.NoError(t, os.WriteFile(path, mkvWith(false), 0o600))
+	v, err := ReadVideo(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2160, v.Height)
+
+	_, err = ReadVideo(filepath.Join(t.TempDir(), "missing.mp4"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,128 @@
+package metrics
+
+import "time"
+
+// Collector is the fixed set of movelooper metrics. Every method is safe to
+// call on a nil *Collector and does nothing, so the move pipeline can record
+// unconditionally and only pays for metrics when an endpoint is enabled.
+type Collector struct {
+	reg *Registry
+
+	filesMoved     *CounterVec
+	filesSkipped   *CounterVec
+	filesFailed    *CounterVec
+	bytesMoved     *CounterVec
+	conflicts      *CounterVec
+	hookFailures   *CounterVec
+	watchRetries   *CounterVec
+	watchExhausted *CounterVec
+}
+
+// Conflict decisions recorded by ConflictDecision.
+const (
+	DecisionResolved = "resolved" // the strategy picked a destination and the file proceeds
+	DecisionSkipped  = "skipped"  // the strategy left the file in place
+	DecisionError    = "error"    // the strategy failed; the file is skipped
+)
+
+// NewCollector registers the movelooper metric families on a fresh registry.
+func NewCollector() *Collector {
+	reg := NewRegistry()
+	return &Collector{
+		reg:            reg,
+		filesMoved:     reg.NewCounterVec("movelooper_files_moved_total", "Files successfully processed, by category and action.", "category", "action"),
+		filesSkipped:   reg.NewCounterVec("movelooper_files_skipped_total", "Files skipped by a conflict strategy, by category and action.", "category", "action"),
+		filesFailed:    reg.NewCounterVec("movelooper_files_failed_total", "Files that could not be processed, by category and action.", "category", "action"),
+		bytesMoved:     reg.NewCounterVec("movelooper_bytes_moved_total", "Bytes of successfully processed files, by category and action.", "category", "action"),
+		conflicts:      reg.NewCounterVec("movelooper_conflict_decisions_total", "Conflict-strategy outcomes when a destination already existed.", "strategy", "decision"),
+		hookFailures:   reg.NewCounterVec("movelooper_hook_failures_total", "Category hooks that failed, by category and hook (before/after).", "category", "hook"),
+		watchRetries:   reg.NewCounterVec("movelooper_watch_move_retries_total", "Failed watch-mode moves that were requeued for another attempt."),
+		watchExhausted: reg.NewCounterVec("movelooper_watch_retries_exhausted_total", "Watch-mode files abandoned after exhausting their move retries."),
+	}
+}
+
+// Registry returns the underlying registry, e.g. to register extra gauges or
+// to serve it over HTTP. It returns nil on a nil Collector.
+func (c *Collector) Registry() *Registry {
+	if c == nil {
+		return nil
+	}
+	return c.reg
+}
+
+// FileMoved records one successfully processed file of size bytes.
+func (c *Collector) FileMoved(category, action string, bytes int64) {
+	if c == nil {
+		return
+	}
+	c.filesMoved.Inc(category, action)
+	c.bytesMoved.Add(float64(bytes), category, action)
+}
+
+// FileSkipped records one file skipped by the conflict strategy.
+func (c *Collector) FileSkipped(category, action string) {
+	if c == nil {
+		return
+	}
+	c.filesSkipped.Inc(category, action)
+}
+
+// FileFailed records one file that could not be processed.
+func (c *Collector) FileFailed(category, action string) {
+	if c == nil {
+		return
+	}
+	c.filesFailed.Inc(category, action)
+}
+
+// ConflictDecision records the outcome of a conflict strategy for a file
+// whose destination already existed.
+func (c *Collector) ConflictDecision(strategy, decision string) {
+	if c == nil {
+		return
+	}
+	c.conflicts.Inc(strategy, decision)
+}
+
+// HookFailed records a failed before/after hook.
+func (c *Collector) HookFailed(category, hook string) {
+	if c == nil {
+		return
+	}
+	c.hookFailures.Inc(category, hook)
+}
+
+// WatchRetry records a failed watch-mode move that was requeued.
+func (c *Collector) WatchRetry() {
+	if c == nil {
+		return
+	}
+	c.watchRetries.Inc()
+}
+
+// WatchRetriesExhausted records a watch-mode file abandoned after its last retry.
+func (c *Collector) WatchRetriesExhausted() {
+	if c == nil {
+		return
+	}
+	c.watchExhausted.Inc()
+}
+
+// RegisterQueue exposes the watch queue's length and the age of its oldest
+// pending file as gauges. length and oldest are sampled on every scrape;
+// oldest reports ok=false when the queue is empty, which renders as 0.
+func (c *Collector) RegisterQueue(length func() int, oldest func() (time.Time, bool)) {
+	if c == nil {
+		return
+	}
+	c.reg.NewGaugeFunc("movelooper_watch_queue_length", "Files detected by watch mode and waiting to become stable.",
+		func() float64 { return float64(length()) })
+	c.reg.NewGaugeFunc("movelooper_watch_oldest_pending_seconds", "Age of the oldest pending file's most recent event, in seconds.",
+		func() float64 {
+			at, ok := oldest()
+			if !ok {
+				return 0
+			}
+			return time.Since(at).Seconds()
+		})
+}
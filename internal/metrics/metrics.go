@@ -0,0 +1,219 @@
+// Package metrics exposes movelooper's runtime counters and gauges in the
+// Prometheus/OpenMetrics text exposition format. It is deliberately
+// dependency-free: a small registry of counter families and gauge callbacks,
+// rendered on demand by WriteText, is all the long-running modes need.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry holds metric families and renders them as text. Safe for
+// concurrent use: counters are updated from the move pipeline while the HTTP
+// handler renders a snapshot.
+type Registry struct {
+	mu       sync.Mutex
+	counters map[string]*CounterVec
+	gauges   map[string]*gaugeFunc
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		counters: make(map[string]*CounterVec),
+		gauges:   make(map[string]*gaugeFunc),
+	}
+}
+
+// CounterVec is a family of monotonically increasing counters partitioned by
+// a fixed set of label names.
+type CounterVec struct {
+	reg    *Registry
+	name   string
+	help   string
+	labels []string
+	series map[string]*series
+}
+
+// series is one labelled time series of a family.
+type series struct {
+	values []string
+	value  float64
+}
+
+// gaugeFunc is a gauge whose value is sampled from fn at render time, so
+// callers never have to push updates for state they already own (e.g. the
+// watch tracker's queue length).
+type gaugeFunc struct {
+	name string
+	help string
+	fn   func() float64
+}
+
+// NewCounterVec registers a counter family. Registering the same name twice
+// returns the existing family, so independent components can share a counter.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.counters[name]; ok {
+		return c
+	}
+	c := &CounterVec{reg: r, name: name, help: help, labels: labels, series: make(map[string]*series)}
+	r.counters[name] = c
+	return c
+}
+
+// NewGaugeFunc registers a gauge sampled from fn each time the registry is
+// rendered. A later registration under the same name replaces the earlier one.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.gauges[name] = &gaugeFunc{name: name, help: help, fn: fn}
+}
+
+// Add increments the series identified by values (one per label name, in
+// registration order) by delta. Negative deltas are ignored: counters only go up.
+func (c *CounterVec) Add(delta float64, values ...string) {
+	if delta < 0 || math.IsNaN(delta) {
+		return
+	}
+	if len(values) != len(c.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", c.name, len(c.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	c.reg.mu.Lock()
+	defer c.reg.mu.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		c.series[key] = s
+	}
+	s.value += delta
+}
+
+// Inc increments the series identified by values by one.
+func (c *CounterVec) Inc(values ...string) { c.Add(1, values...) }
+
+// Value returns the current value of the series identified by values, or 0
+// when it has never been incremented.
+func (c *CounterVec) Value(values ...string) float64 {
+	c.reg.mu.Lock()
+	defer c.reg.mu.Unlock()
+	if s, ok := c.series[strings.Join(values, "\xff")]; ok {
+		return s.value
+	}
+	return 0
+}
+
+// WriteText renders every family in the Prometheus text exposition format
+// (version 0.0.4), sorted by name and then by label values so the output is
+// stable between scrapes.
+func (r *Registry) WriteText(w io.Writer) error {
+	// Sample gauges outside the registry lock: their callbacks take other
+	// components' locks (e.g. the tracker mutex) and must not nest inside ours.
+	r.mu.Lock()
+	gauges := make([]*gaugeFunc, 0, len(r.gauges))
+	for _, g := range r.gauges {
+		gauges = append(gauges, g)
+	}
+	r.mu.Unlock()
+
+	var b strings.Builder
+	type sampled struct {
+		g *gaugeFunc
+		v float64
+	}
+	samples := make([]sampled, 0, len(gauges))
+	for _, g := range gauges {
+		samples = append(samples, sampled{g: g, v: g.fn()})
+	}
+
+	r.mu.Lock()
+	names := make([]string, 0, len(r.counters))
+	for name := range r.counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeCounter(&b, r.counters[name])
+	}
+	r.mu.Unlock()
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i].g.name < samples[j].g.name })
+	for _, s := range samples {
+		writeHeader(&b, s.g.name, s.g.help, "gauge")
+		fmt.Fprintf(&b, "%s %s\n", s.g.name, formatValue(s.v))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeCounter renders one counter family. Callers must hold the registry lock.
+func writeCounter(b *strings.Builder, c *CounterVec) {
+	writeHeader(b, c.name, c.help, "counter")
+	keys := make([]string, 0, len(c.series))
+	for k := range c.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := c.series[k]
+		b.WriteString(c.name)
+		writeLabels(b, c.labels, s.values)
+		b.WriteByte(' ')
+		b.WriteString(formatValue(s.value))
+		b.WriteByte('\n')
+	}
+}
+
+func writeHeader(b *strings.Builder, name, help, kind string) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, escapeHelp(help))
+	fmt.Fprintf(b, "# TYPE %s %s\n", name, kind)
+}
+
+func writeLabels(b *strings.Builder, names, values []string) {
+	if len(names) == 0 {
+		return
+	}
+	b.WriteByte('{')
+	for i, n := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(n)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(values[i]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+}
+
+// escapeLabelValue escapes backslash, double quote, and line feed, the three
+// characters the exposition format reserves inside label values.
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// escapeHelp escapes backslash and line feed in HELP text.
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func render(t *testing.T, reg *Registry) string {
+	t.Helper()
+	var sb strings.Builder
+	require.NoError(t, reg.WriteText(&sb))
+	return sb.String()
+}
+
+func TestRegistry_WriteText(t *testing.T) {
+	t.Parallel()
+	reg := NewRegistry()
+	c := reg.NewCounterVec("test_total", "A test counter.", "category", "action")
+	c.Inc("images", "move")
+	c.Add(2, "images", "move")
+	c.Inc("docs", "copy")
+	c.Add(-5, "docs", "copy") // ignored: counters never go down
+	reg.NewGaugeFunc("test_gauge", "A test gauge.", func() float64 { return 1.5 })
+
+	want := `# HELP test_total A test counter.
+# TYPE test_total counter
+test_total{category="docs",action="copy"} 1
+test_total{category="images",action="move"} 3
+# HELP test_gauge A test gauge.
+# TYPE test_gauge gauge
+test_gauge 1.5
+`
+	assert.Equal(t, want, render(t, reg))
+	assert.Equal(t, float64(3), c.Value("images", "move"))
+	assert.Equal(t, float64(0), c.Value("never", "seen"))
+}
+
+func TestRegistry_EscapesLabelValues(t *testing.T) {
+	t.Parallel()
+	reg := NewRegistry()
+	reg.NewCounterVec("esc_total", "Escapes.", "category").Inc("a\"b\\c\nd")
+	assert.Contains(t, render(t, reg), `esc_total{category="a\"b\\c\nd"} 1`)
+}
+
+func TestRegistry_SameNameSharesFamily(t *testing.T) {
+	t.Parallel()
+	reg := NewRegistry()
+	reg.NewCounterVec("shared_total", "Shared.").Inc()
+	reg.NewCounterVec("shared_total", "Shared.").Inc()
+	assert.Contains(t, render(t, reg), "shared_total 2\n")
+}
+
+func TestCollector_NilIsNoop(t *testing.T) {
+	t.Parallel()
+	var c *Collector
+	assert.NotPanics(t, func() {
+		c.FileMoved("a", "move", 10)
+		c.FileSkipped("a", "move")
+		c.FileFailed("a", "move")
+		c.ConflictDecision("rename", DecisionResolved)
+		c.HookFailed("a", "before")
+		c.WatchRetry()
+		c.WatchRetriesExhausted()
+		c.RegisterQueue(func() int { return 0 }, func() (time.Time, bool) { return time.Time{}, false })
+	})
+	assert.Nil(t, c.Registry())
+}
+
+func TestCollector_RecordsAndQueueGauges(t *testing.T) {
+	t.Parallel()
+	c := NewCollector()
+	c.FileMoved("images", "move", 1024)
+	c.FileMoved("images", "move", 1024)
+	c.FileFailed("images", "move")
+	c.ConflictDecision("skip", DecisionSkipped)
+	c.WatchRetriesExhausted()
+	c.RegisterQueue(func() int { return 4 }, func() (time.Time, bool) { return time.Time{}, false })
+
+	out := render(t, c.Registry())
+	assert.Contains(t, out, `movelooper_files_moved_total{category="images",action="move"} 2`)
+	assert.Contains(t, out, `movelooper_bytes_moved_total{category="images",action="move"} 2048`)
+	assert.Contains(t, out, `movelooper_files_failed_total{category="images",action="move"} 1`)
+	assert.Contains(t, out, `movelooper_conflict_decisions_total{strategy="skip",decision="skipped"} 1`)
+	assert.Contains(t, out, "movelooper_watch_retries_exhausted_total 1\n")
+	assert.Contains(t, out, "movelooper_watch_queue_length 4\n")
+	assert.Contains(t, out, "movelooper_watch_oldest_pending_seconds 0\n")
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+	reg := NewRegistry()
+	reg.NewCounterVec("h_total", "Handler.").Inc()
+
+	rec := httptest.NewRecorder()
+	Handler(reg).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, contentType, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "h_total 1")
+
+	rec = httptest.NewRecorder()
+	Handler(reg).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestServe(t *testing.T) {
+	t.Parallel()
+	reg := NewRegistry()
+	reg.NewCounterVec("serve_total", "Serve.").Inc()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done, err := Serve(ctx, "127.0.0.1:0", reg)
+	require.NoError(t, err)
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("server did not shut down after cancel")
+	}
+}
+
+func TestServe_BadAddress(t *testing.T) {
+	t.Parallel()
+	_, err := Serve(context.Background(), "not-an-address", NewRegistry())
+	require.Error(t, err)
+}
+
+// TestHandler_OverHTTP scrapes the handler through a real HTTP round trip.
+func TestHandler_OverHTTP(t *testing.T) {
+	t.Parallel()
+	reg := NewRegistry()
+	reg.NewCounterVec("live_total", "Live.").Inc()
+
+	srv := httptest.NewServer(Handler(reg))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "live_total 1")
+}
@@ -39,6 +39,7 @@ type Category struct {
 	Source      CategorySource      `yaml:"source" mapstructure:"source"`
 	Destination CategoryDestination `yaml:"destination" mapstructure:"destination"`
 	Hooks       *CategoryHooks      `yaml:"hooks,omitempty" mapstructure:"hooks"`
+	Watch       *CategoryWatch      `yaml:"watch,omitempty" mapstructure:"watch"`
 }
 
 // IsEnabled reports whether the category is active.
@@ -47,6 +48,13 @@ func (c *Category) IsEnabled() bool {
 	return c.Enabled != nil && *c.Enabled
 }
 
+// CategoryWatch overrides the global watch-mode stability settings for one
+// category. Zero values inherit configuration.watch.
+type CategoryWatch struct {
+	Delay     time.Duration `yaml:"delay,omitempty"     mapstructure:"delay"`
+	Stability StabilityMode `yaml:"stability,omitempty" mapstructure:"stability"`
+}
+
 // CategorySource holds the source path, extensions, and filters for a category
 type CategorySource struct {
 	Path         string         `yaml:"path"                    mapstructure:"path"`
@@ -55,6 +63,15 @@ type CategorySource struct {
 	Recursive    bool           `yaml:"recursive,omitempty"     mapstructure:"recursive"`
 	MaxDepth     int            `yaml:"max-depth,omitempty"     mapstructure:"max-depth"`
 	ExcludePaths []string       `yaml:"exclude-paths,omitempty" mapstructure:"exclude-paths"`
+	// ExcludePatterns are globs matched against each directory's path
+	// relative to Path; a recursive walk skips a matching directory and
+	// everything below it. ** matches any number of directories.
+	ExcludePatterns []string `yaml:"exclude-patterns,omitempty" mapstructure:"exclude-patterns"`
+	// NameDatePatterns are regexes with year, month and day groups that
+	// {name-date} and age.from: name try before the built-in file name date
+	// patterns. Config validation compiles them into CompiledNameDatePatterns.
+	NameDatePatterns         []string         `yaml:"name-date-patterns,omitempty" mapstructure:"name-date-patterns"`
+	CompiledNameDatePatterns []*regexp.Regexp `yaml:"-"                            mapstructure:"-"`
 }
 
 // CategoryDestination holds the destination path and placement rules for a category
@@ -65,6 +82,10 @@ type CategoryDestination struct {
 	Action           Action           `yaml:"action,omitempty"            mapstructure:"action"`
 	Rename           string           `yaml:"rename,omitempty"            mapstructure:"rename"`
 	Archive          *ArchiveConfig   `yaml:"archive,omitempty"           mapstructure:"archive"`
+	// TagDefaults overrides the value an audio tag token takes when the file
+	// does not record that tag, keyed by token name without braces, e.g.
+	// "artist" or "genre".
+	TagDefaults map[string]string `yaml:"tag-defaults,omitempty" mapstructure:"tag-defaults"`
 }
 
 // ArchiveConfig configures action: archive — how a category's files are packed
@@ -90,9 +111,14 @@ func (a *ArchiveConfig) KeepsSource() bool {
 // Use any/all/not for explicit boolean composition.
 type CategoryFilter struct {
 	Match *MatchFilter     `yaml:"match,omitempty" mapstructure:"match"`
+	Path  *PathFilter      `yaml:"path,omitempty"  mapstructure:"path"`
 	Age   *AgeFilter       `yaml:"age,omitempty"   mapstructure:"age"`
+	Date  *DateFilter      `yaml:"date,omitempty"  mapstructure:"date"`
 	Size  *SizeFilter      `yaml:"size,omitempty"  mapstructure:"size"`
 	Mime  string           `yaml:"mime,omitempty"  mapstructure:"mime"`
+	Exif  *ExifFilter      `yaml:"exif,omitempty"  mapstructure:"exif"`
+	Tags  *TagsFilter      `yaml:"tags,omitempty"  mapstructure:"tags"`
+	Video *VideoFilter     `yaml:"video,omitempty" mapstructure:"video"`
 	Any   []CategoryFilter `yaml:"any,omitempty"   mapstructure:"any"`
 	All   []CategoryFilter `yaml:"all,omitempty"   mapstructure:"all"`
 	Not   []CategoryFilter `yaml:"not,omitempty"   mapstructure:"not"`
@@ -100,7 +126,7 @@ type CategoryFilter struct {
 
 // IsZero lets yaml.v3 omit an empty CategoryFilter when the parent field has omitempty.
 func (f CategoryFilter) IsZero() bool {
-	return f.Match == nil && f.Age == nil && f.Size == nil && f.Mime == "" &&
+	return f.Match == nil && f.Path == nil && f.Age == nil && f.Date == nil && f.Size == nil && f.Mime == "" && f.Exif == nil && f.Tags == nil && f.Video == nil &&
 		len(f.Any) == 0 && len(f.All) == 0 && len(f.Not) == 0
 }
 
@@ -113,10 +139,62 @@ type MatchFilter struct {
 	CompiledRegex *regexp.Regexp `yaml:"-"                        mapstructure:"-"`
 }
 
-// AgeFilter constrains by modification time.
+// PathFilter constrains by the file's path relative to the category's
+// source.path, written with forward slashes (e.g. raw/2024/IMG_0001.jpg): one
+// of regex or glob. In a glob, * and ? stay within one directory and a **
+// segment matches any number of directories. Root is the source path, set by
+// config validation.
+type PathFilter struct {
+	Regex         string         `yaml:"regex,omitempty"          mapstructure:"regex"`
+	Glob          string         `yaml:"glob,omitempty"           mapstructure:"glob"`
+	CaseSensitive bool           `yaml:"case-sensitive,omitempty" mapstructure:"case-sensitive"`
+	CompiledRegex *regexp.Regexp `yaml:"-"                        mapstructure:"-"`
+	Root          string         `yaml:"-"                        mapstructure:"-"`
+}
+
+// DateSource selects which date of a file a filter measures.
+type DateSource string
+
+const (
+	// DateSourceMtime is the modification time, the default.
+	DateSourceMtime DateSource = "mtime"
+	// DateSourceName is the date written in the file name, e.g.
+	// IMG_20240315_101500.jpg.
+	DateSourceName DateSource = "name"
+	// DateSourceBirth is the creation time where the file system records it.
+	DateSourceBirth DateSource = "birth"
+	// DateSourceExif is when a photo was taken, from its EXIF block.
+	DateSourceExif DateSource = "exif"
+	// DateSourceAtime is the last access time.
+	DateSourceAtime DateSource = "atime"
+	// DateSourceCtime is the last metadata (inode) change time.
+	DateSourceCtime DateSource = "ctime"
+)
+
+// AgeFilter constrains by the age of one of the file's dates, the
+// modification time unless Field (a file system time) or From (a file system
+// time, the file name or EXIF) says otherwise; at most one of them is set. A
+// file without the chosen date is measured by its modification time.
+// NamePatterns holds the category's compiled source.name-date-patterns, set
+// by config validation.
 type AgeFilter struct {
-	Min time.Duration `yaml:"min,omitempty" mapstructure:"min"`
-	Max time.Duration `yaml:"max,omitempty" mapstructure:"max"`
+	Min          time.Duration    `yaml:"min,omitempty"   mapstructure:"min"`
+	Max          time.Duration    `yaml:"max,omitempty"   mapstructure:"max"`
+	Field        DateSource       `yaml:"field,omitempty" mapstructure:"field"`
+	From         DateSource       `yaml:"from,omitempty"  mapstructure:"from"`
+	NamePatterns []*regexp.Regexp `yaml:"-"               mapstructure:"-"`
+}
+
+// DateFilter constrains one of the file's dates, chosen like AgeFilter's, to
+// the range [After, Before). Each bound is a date (YYYY-MM-DD), an RFC 3339
+// time or a calendar expression such as start-of-month, which is evaluated
+// when the file is matched.
+type DateFilter struct {
+	After        string           `yaml:"after,omitempty"  mapstructure:"after"`
+	Before       string           `yaml:"before,omitempty" mapstructure:"before"`
+	Field        DateSource       `yaml:"field,omitempty"  mapstructure:"field"`
+	From         DateSource       `yaml:"from,omitempty"   mapstructure:"from"`
+	NamePatterns []*regexp.Regexp `yaml:"-"                mapstructure:"-"`
 }
 
 // SizeFilter constrains by file size.
@@ -127,6 +205,34 @@ type SizeFilter struct {
 	MaxBytes int64  `yaml:"-"             mapstructure:"-"`
 }
 
+// ExifFilter constrains by photo metadata read from the file's EXIF block.
+// TakenAfter and TakenBefore are parsed into After and Before by config
+// validation.
+type ExifFilter struct {
+	Camera      string    `yaml:"camera,omitempty"       mapstructure:"camera"`
+	TakenAfter  string    `yaml:"taken-after,omitempty"  mapstructure:"taken-after"`
+	TakenBefore string    `yaml:"taken-before,omitempty" mapstructure:"taken-before"`
+	HasGPS      *bool     `yaml:"has-gps,omitempty"      mapstructure:"has-gps"`
+	After       time.Time `yaml:"-"                      mapstructure:"-"`
+	Before      time.Time `yaml:"-"                      mapstructure:"-"`
+}
+
+// TagsFilter constrains by the audio tags read from the file (ID3, FLAC
+// Vorbis comments, MP4 atoms). Each field is a case-insensitive glob.
+type TagsFilter struct {
+	Artist string `yaml:"artist,omitempty" mapstructure:"artist"`
+	Album  string `yaml:"album,omitempty"  mapstructure:"album"`
+	Genre  string `yaml:"genre,omitempty"  mapstructure:"genre"`
+}
+
+// VideoFilter constrains by the duration and frame size recorded in a video
+// container (MP4, MOV, MKV, WebM).
+type VideoFilter struct {
+	MinDuration time.Duration `yaml:"min-duration,omitempty" mapstructure:"min-duration"`
+	MaxDuration time.Duration `yaml:"max-duration,omitempty" mapstructure:"max-duration"`
+	MinHeight   int           `yaml:"min-height,omitempty"   mapstructure:"min-height"`
+}
+
 // CategoryHooks holds optional before/after hooks for a category.
 type CategoryHooks struct {
 	Before *CategoryHook `yaml:"before,omitempty" mapstructure:"before"`
@@ -163,6 +269,26 @@ func (Category) Metadata() map[string]*metadata.Node {
 		"hooks": {FieldMeta: editor.FieldMeta{
 			Description: "Optional shell commands to run before and after each file is moved.",
 		}},
+		"watch": {FieldMeta: editor.FieldMeta{
+			Description: "Optional watch-mode overrides for this category, e.g. a shorter delay for screenshots.",
+		}},
+	}
+}
+
+func (CategoryWatch) Metadata() map[string]*metadata.Node {
+	return map[string]*metadata.Node{
+		"delay": {FieldMeta: editor.FieldMeta{
+			Description: "Stability delay for this category's files, overriding configuration.watch.delay.",
+			Min:         "1s",
+			Max:         "168h",
+			Formats:     []editor.Format{editor.FormatDuration},
+			Example:     "delay: 2s",
+		}},
+		"stability": {FieldMeta: editor.FieldMeta{
+			Description: "Stability mode for this category's files, overriding configuration.watch.stability.",
+			OneOf:       []string{"events", "size", "checksum"},
+			Example:     "stability: size",
+		}},
 	}
 }
 
@@ -197,6 +323,14 @@ func (CategorySource) Metadata() map[string]*metadata.Node {
 			Description: "Absolute paths to skip during recursive walk. The destination path is always auto-excluded.",
 			Example:     "exclude-paths:\n  - /home/user/Downloads/archives\n  - /home/user/Downloads/.Trash",
 		}},
+		"exclude-patterns": {FieldMeta: editor.FieldMeta{
+			Description: "Globs matched against each directory's path relative to the source path; a recursive walk skips matching directories and everything below them. ** matches any number of directories.",
+			Example:     "exclude-patterns:\n  - \"**/node_modules\"\n  - \"**/.git\"\n  - \"backups/*\"",
+		}},
+		"name-date-patterns": {FieldMeta: editor.FieldMeta{
+			Description: "Extra RE2 regexes for dates in file names, tried before the built-in patterns by the {name-date} tokens and age.from: name. Each must have (?P<year>), (?P<month>) and (?P<day>) groups; hour, minute, second and ampm groups are optional.",
+			Example:     "name-date-patterns:\n  - \"(?P<day>\\d{2})\\.(?P<month>\\d{2})\\.(?P<year>\\d{4})\"",
+		}},
 		"filter": {
 			FieldMeta: editor.FieldMeta{
 				Description: "Optional filtering rules applied to each matched file. All populated sub-fields must match (AND logic) unless any/all are used.",
@@ -238,6 +372,10 @@ func (CategoryDestination) Metadata() map[string]*metadata.Node {
 			Formats:     []editor.Format{FormatRenamePattern},
 			Example:     "rename: \"{year}-{month}-{day}_{name}.{ext}\"\n\n# Full filename — include {ext} to keep the extension.\n# {name}  filename without extension\n# {year}, {month}, {day}, {hour}, {minute}, {second}\n# {seq}   auto-incrementing counter\n# {sha256:N}  first N hex chars of SHA-256",
 		}},
+		"tag-defaults": {FieldMeta: editor.FieldMeta{
+			Description: "Values the audio tag tokens take when a file lacks the tag, keyed by token name: artist, album-artist, album, title, genre, tag-year, track, disc. Built-in defaults are \"Unknown Artist\", \"Unknown Album\", \"Unknown Genre\", \"Unknown Year\", track 0 and disc 1; a missing title uses the file name.",
+			Example:     "tag-defaults:\n  artist: Various Artists\n  genre: Unsorted",
+		}},
 	}
 }
 
@@ -291,8 +429,14 @@ func (CategoryFilter) Metadata() map[string]*metadata.Node {
 		"match": {FieldMeta: editor.FieldMeta{
 			Description: "Name-based filter: glob, regex, or literal match (pick one).",
 		}},
+		"path": {FieldMeta: editor.FieldMeta{
+			Description: "Path-based filter: glob or regex (pick one) matched against the file's path relative to the source path, e.g. raw/2024/IMG_0001.jpg. Useful with recursive scans.",
+		}},
 		"age": {FieldMeta: editor.FieldMeta{
-			Description: "Modification-time constraints.",
+			Description: "Age constraints, measured from the modification time or the date chosen with field or from.",
+		}},
+		"date": {FieldMeta: editor.FieldMeta{
+			Description: "Absolute date range: after and before take a date, an RFC 3339 time or a calendar expression such as start-of-month, so the range does not move with the run time.",
 		}},
 		"size": {FieldMeta: editor.FieldMeta{
 			Description: "File-size constraints.",
@@ -301,6 +445,15 @@ func (CategoryFilter) Metadata() map[string]*metadata.Node {
 			Description: "Match by the file's real MIME type (magic bytes), as a glob against the detected type. Examples: \"image/*\", \"application/pdf\". Reads the file content; combine with extensions: [all] to match by real type.",
 			Example:     "mime: \"image/*\"",
 		}},
+		"exif": {FieldMeta: editor.FieldMeta{
+			Description: "Photo metadata constraints read from the file's EXIF block (JPEG, TIFF and camera raw, PNG, HEIC). A file without EXIF fails camera and taken-* rules and counts as having no GPS.",
+		}},
+		"tags": {FieldMeta: editor.FieldMeta{
+			Description: "Audio tag constraints read from ID3v1/v2, FLAC Vorbis comments or MP4 atoms. A file without the tag a rule names fails that rule.",
+		}},
+		"video": {FieldMeta: editor.FieldMeta{
+			Description: "Video constraints read from the MP4/MOV or Matroska container: duration and frame height. A file that is not a video fails every rule.",
+		}},
 		"any": anyNode,
 		"all": allNode,
 		"not": notNode,
@@ -318,7 +471,7 @@ func (MatchFilter) Metadata() map[string]*metadata.Node {
 			Example:     "literal: \"Anna's Archive.pdf\"",
 		}},
 		"regex": {FieldMeta: editor.FieldMeta{
-			Description: "RE2 regular expression matched against the filename. Mutually exclusive with glob and literal.",
+			Description: "RE2 regular expression matched against the filename. Mutually exclusive with glob and literal. Its capture groups are available to rename and organize-by as {match:N} and {match:NAME}.",
 			Formats:     []editor.Format{FormatRegex},
 			Example:     "regex: \"^\\d{4}-\\d{2}-\\d{2}_.*\\.pdf$\"",
 		}},
@@ -335,6 +488,26 @@ func (MatchFilter) Metadata() map[string]*metadata.Node {
 	}
 }
 
+func (PathFilter) Metadata() map[string]*metadata.Node {
+	return map[string]*metadata.Node{
+		"regex": {FieldMeta: editor.FieldMeta{
+			Description: "RE2 regular expression matched against the path relative to the source path, with / as the separator. Mutually exclusive with glob.",
+			Formats:     []editor.Format{FormatRegex},
+			Example:     "regex: \"(^|/)raw/\"",
+		}},
+		"glob": {FieldMeta: editor.FieldMeta{
+			Description: "Glob pattern matched against the whole path relative to the source path. * and ? stay within one directory; a ** segment matches any number of directories. Mutually exclusive with regex.",
+			Formats:     []editor.Format{FormatGlob},
+			Example:     "glob: \"raw/**\"",
+		}},
+		"case-sensitive": {FieldMeta: editor.FieldMeta{
+			Description: "Whether matching is case-sensitive. Applies to regex and glob.",
+			Default:     "false",
+			Example:     "case-sensitive: false",
+		}},
+	}
+}
+
 func (AgeFilter) Metadata() map[string]*metadata.Node {
 	return map[string]*metadata.Node{
 		"min": {FieldMeta: editor.FieldMeta{
@@ -351,6 +524,43 @@ func (AgeFilter) Metadata() map[string]*metadata.Node {
 			Formats:     []editor.Format{editor.FormatDuration},
 			Example:     "max: 720h",
 		}},
+		"field": {FieldMeta: editor.FieldMeta{
+			Description: "Which file system time the age is measured from: modification, last access, metadata change or creation. Set field or from, not both.",
+			OneOf:       []string{"mtime", "atime", "ctime", "birth"},
+			Default:     "mtime",
+			Example:     "field: atime",
+		}},
+		"from": {FieldMeta: editor.FieldMeta{
+			Description: "Which date the age is measured from: the modification time, the date in the file name, the creation time, or when the photo was taken (EXIF). A file without that date falls back to its modification time. Set field or from, not both.",
+			OneOf:       []string{"mtime", "name", "birth", "exif"},
+			Default:     "mtime",
+			Example:     "from: name",
+		}},
+	}
+}
+
+func (DateFilter) Metadata() map[string]*metadata.Node {
+	return map[string]*metadata.Node{
+		"after": {FieldMeta: editor.FieldMeta{
+			Description: "Only match files dated at or after this point: YYYY-MM-DD, an RFC 3339 time, or a calendar expression (today, yesterday, start-of-week, start-of-last-month, start-of-quarter, start-of-year, ...).",
+			Example:     "after: start-of-last-quarter",
+		}},
+		"before": {FieldMeta: editor.FieldMeta{
+			Description: "Only match files dated before this point (exclusive), in the same forms as after.",
+			Example:     "before: start-of-quarter",
+		}},
+		"field": {FieldMeta: editor.FieldMeta{
+			Description: "Which file system time is compared: modification, last access, metadata change or creation. Set field or from, not both.",
+			OneOf:       []string{"mtime", "atime", "ctime", "birth"},
+			Default:     "mtime",
+			Example:     "field: birth",
+		}},
+		"from": {FieldMeta: editor.FieldMeta{
+			Description: "Which date is compared: the modification time, the date in the file name, the creation time, or when the photo was taken (EXIF). A file without that date falls back to its modification time. Set field or from, not both.",
+			OneOf:       []string{"mtime", "name", "birth", "exif"},
+			Default:     "mtime",
+			Example:     "from: name",
+		}},
 	}
 }
 
@@ -371,6 +581,70 @@ func (SizeFilter) Metadata() map[string]*metadata.Node {
 	}
 }
 
+func (ExifFilter) Metadata() map[string]*metadata.Node {
+	return map[string]*metadata.Node{
+		"camera": {FieldMeta: editor.FieldMeta{
+			Description: "Case-insensitive glob matched against the camera model, and against make and model joined by a space.",
+			Formats:     []editor.Format{FormatGlob},
+			Example:     "camera: \"*EOS*\"",
+		}},
+		"taken-after": {FieldMeta: editor.FieldMeta{
+			Description: "Only match photos taken at or after this date (YYYY-MM-DD, local time) or RFC 3339 time.",
+			Example:     "taken-after: 2024-06-01",
+		}},
+		"taken-before": {FieldMeta: editor.FieldMeta{
+			Description: "Only match photos taken before this date (YYYY-MM-DD, local time) or RFC 3339 time.",
+			Example:     "taken-before: 2024-09-01",
+		}},
+		"has-gps": {FieldMeta: editor.FieldMeta{
+			Description: "true matches only photos with GPS coordinates; false only photos without.",
+			Example:     "has-gps: true",
+		}},
+	}
+}
+
+func (TagsFilter) Metadata() map[string]*metadata.Node {
+	return map[string]*metadata.Node{
+		"artist": {FieldMeta: editor.FieldMeta{
+			Description: "Case-insensitive glob matched against the artist, and against the album artist.",
+			Formats:     []editor.Format{FormatGlob},
+			Example:     "artist: \"Miles Davis\"",
+		}},
+		"album": {FieldMeta: editor.FieldMeta{
+			Description: "Case-insensitive glob matched against the album.",
+			Formats:     []editor.Format{FormatGlob},
+			Example:     "album: \"Kind of*\"",
+		}},
+		"genre": {FieldMeta: editor.FieldMeta{
+			Description: "Case-insensitive glob matched against the genre. Numeric ID3v1 genres are resolved to their names.",
+			Formats:     []editor.Format{FormatGlob},
+			Example:     "genre: \"*jazz*\"",
+		}},
+	}
+}
+
+func (VideoFilter) Metadata() map[string]*metadata.Node {
+	return map[string]*metadata.Node{
+		"min-duration": {FieldMeta: editor.FieldMeta{
+			Description: "Only match videos at least this long.",
+			Min:         "0s",
+			Formats:     []editor.Format{editor.FormatDuration},
+			Example:     "min-duration: 30s",
+		}},
+		"max-duration": {FieldMeta: editor.FieldMeta{
+			Description: "Only match videos no longer than this.",
+			Min:         "0s",
+			Formats:     []editor.Format{editor.FormatDuration},
+			Example:     "max-duration: 10m",
+		}},
+		"min-height": {FieldMeta: editor.FieldMeta{
+			Description: "Only match videos whose display height is at least this many pixels (1080 for Full HD landscape). Rotation is applied, so portrait phone videos count their long side.",
+			Min:         "0",
+			Example:     "min-height: 720",
+		}},
+	}
+}
+
 func (CategoryHooks) Metadata() map[string]*metadata.Node {
 	return map[string]*metadata.Node{
 		"before": {FieldMeta: editor.FieldMeta{
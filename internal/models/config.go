@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/lucasassuncao/movelooper/internal/tokens"
 	"github.com/lucasassuncao/yedit/editor"
 	"github.com/lucasassuncao/yedit/metadata"
 )
@@ -20,6 +21,12 @@ type Configuration struct {
 	Watch    Watch     `yaml:"watch" mapstructure:"watch"`
 	History  History   `yaml:"history" mapstructure:"history"`
 	Defaults *Defaults `yaml:"defaults,omitempty" mapstructure:"defaults"`
+	// Timezone is the IANA zone (e.g. Europe/Paris, UTC) every date token is
+	// rendered in; empty keeps the machine's local zone.
+	Timezone string `yaml:"timezone,omitempty" mapstructure:"timezone"`
+	// Locale sets the language of month and weekday names in formatted date
+	// tokens such as {mod:%B}; empty is English.
+	Locale string `yaml:"locale,omitempty" mapstructure:"locale"`
 }
 
 // Logging holds the log output settings.
@@ -37,13 +44,53 @@ type Logging struct {
 type Watch struct {
 	Delay        time.Duration `yaml:"delay" mapstructure:"delay"`
 	PollInterval time.Duration `yaml:"poll-interval,omitempty" mapstructure:"poll-interval"`
+	Stability    StabilityMode `yaml:"stability,omitempty" mapstructure:"stability"`
+	StablePolls  int           `yaml:"stable-polls,omitempty" mapstructure:"stable-polls"`
+	Batching     BatchingMode  `yaml:"batching,omitempty" mapstructure:"batching"`
+	BatchIdle    time.Duration `yaml:"batch-idle,omitempty" mapstructure:"batch-idle"`
 }
 
+// BatchingMode selects how watch mode groups its moves into history batches.
+type BatchingMode string
+
+const (
+	// BatchingFile records every move as its own batch.
+	BatchingFile BatchingMode = "file"
+	// BatchingDaemon records every move of one watch run in a single batch.
+	BatchingDaemon BatchingMode = "daemon"
+	// BatchingHourly starts a new batch at the top of every hour.
+	BatchingHourly BatchingMode = "hourly"
+	// BatchingIdle groups bursts of moves, starting a new batch once
+	// watch.batch-idle passes without one.
+	BatchingIdle BatchingMode = "idle"
+)
+
+// StabilityMode selects how watch mode decides that a pending file is done
+// being written.
+type StabilityMode string
+
+const (
+	// StabilityEvents waits for watch.delay without a create/write event.
+	StabilityEvents StabilityMode = "events"
+	// StabilitySize additionally requires watch.stable-polls consecutive polls
+	// with an unchanged size and modification time.
+	StabilitySize StabilityMode = "size"
+	// StabilityChecksum additionally requires watch.stable-polls consecutive
+	// polls with an unchanged size and hash of the file's tail.
+	StabilityChecksum StabilityMode = "checksum"
+)
+
 // History holds the undo-history settings.
 type History struct {
 	Limit   int    `yaml:"limit" mapstructure:"limit"`
 	File    string `yaml:"file" mapstructure:"file"`
 	Enabled bool   `yaml:"enabled,omitempty" mapstructure:"enabled"`
+	// KeepReplaced keeps destinations displaced by a replace-style conflict
+	// strategy in ~/.movelooper/backups/<batch> so undo can put them back.
+	KeepReplaced bool `yaml:"keep-replaced,omitempty" mapstructure:"keep-replaced"`
+	// Hash records a SHA-256 of every destination next to its size and mtime,
+	// so undo and "history verify" can tell a touched file from an edited one.
+	Hash bool `yaml:"hash,omitempty" mapstructure:"hash"`
 }
 
 // Defaults holds fallback values applied to any category that omits them.
@@ -81,6 +128,17 @@ func (Configuration) Metadata() map[string]*metadata.Node {
 		"defaults": {FieldMeta: editor.FieldMeta{
 			Description: "Fallback destination settings applied to any category that omits them. Per-category values always win.",
 		}},
+		"timezone": {FieldMeta: editor.FieldMeta{
+			Description: "IANA timezone every date token is rendered in, so organize-by gives the same folders on machines in different zones. Empty uses the machine's local zone.",
+			Formats:     []editor.Format{FormatTimezone},
+			Example:     "timezone: Europe/Paris",
+		}},
+		"locale": {FieldMeta: editor.FieldMeta{
+			Description: "Language of the month and weekday names in formatted date tokens such as {mod:%B}.",
+			OneOf:       tokens.Locales,
+			Default:     "en",
+			Example:     "locale: fr",
+		}},
 	}
 }
 
@@ -151,6 +209,33 @@ func (Watch) Metadata() map[string]*metadata.Node {
 			Formats:     []editor.Format{editor.FormatDuration},
 			Example:     "poll-interval: 5s",
 		}},
+		"stability": {FieldMeta: editor.FieldMeta{
+			Description: "How a pending file is judged complete. 'events' waits for delay without a new event; 'size' also requires stable-polls consecutive polls with unchanged size and mtime; 'checksum' compares a hash of the file's tail instead of mtime.",
+			OneOf:       []string{"events", "size", "checksum"},
+			Default:     "events",
+			Example:     "stability: size",
+		}},
+		"stable-polls": {FieldMeta: editor.FieldMeta{
+			Description: "Consecutive unchanged polls required by the size and checksum stability modes.",
+			Default:     "3",
+			Min:         "1",
+			Max:         "100",
+			Example:     "stable-polls: 3",
+		}},
+		"batching": {FieldMeta: editor.FieldMeta{
+			Description: "How watch-mode moves are grouped into undo batches. 'file' records each move on its own; 'daemon' uses one batch per watch run; 'hourly' starts a batch every hour; 'idle' closes a batch once batch-idle passes without a move.",
+			OneOf:       []string{"file", "daemon", "hourly", "idle"},
+			Default:     "file",
+			Example:     "batching: idle",
+		}},
+		"batch-idle": {FieldMeta: editor.FieldMeta{
+			Description: "How long watch mode must go without moving a file before the 'idle' batching mode closes the current batch.",
+			Default:     "1m",
+			Min:         "1s",
+			Max:         "24h",
+			Formats:     []editor.Format{editor.FormatDuration},
+			Example:     "batch-idle: 1m",
+		}},
 	}
 }
 
@@ -174,6 +259,16 @@ func (History) Metadata() map[string]*metadata.Node {
 			Formats:     []editor.Format{editor.FormatDirectoryPath},
 			Example:     "file: ~/.movelooper/history/movelooper.json",
 		}},
+		"keep-replaced": {FieldMeta: editor.FieldMeta{
+			Description: "Keep files replaced by the overwrite/newest/oldest/larger/smaller conflict strategies in ~/.movelooper/backups/<batch> instead of deleting them, so undo can restore them. Backups are deleted when their batch is pruned from history.",
+			Default:     "false",
+			Example:     "keep-replaced: true",
+		}},
+		"hash": {FieldMeta: editor.FieldMeta{
+			Description: "Record a SHA-256 of each file at its destination, next to the size and modification time that are always recorded. Undo and 'history verify' use it to tell a file whose timestamp changed from one whose content changed. Costs one extra read of every file.",
+			Default:     "false",
+			Example:     "hash: true",
+		}},
 	}
 }
 
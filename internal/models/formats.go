@@ -3,6 +3,7 @@ package models
 import (
 	"path/filepath"
 	"regexp"
+	"time"
 
 	"github.com/lucasassuncao/movelooper/internal/tokens"
 	"github.com/lucasassuncao/yedit/editor"
@@ -21,6 +22,12 @@ var (
 		return err == nil
 	})
 
+	// FormatTimezone validates that the value is an IANA timezone name.
+	FormatTimezone = editor.FormatCustom("timezone", func(v string) bool {
+		_, err := time.LoadLocation(v)
+		return err == nil
+	})
+
 	// FormatOrganizeByPattern validates organize-by token strings using the
 	// same token set as the runtime resolver.
 	FormatOrganizeByPattern = editor.FormatCustom("organize-by pattern", func(v string) bool {
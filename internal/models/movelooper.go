@@ -5,6 +5,7 @@ import (
 
 	"github.com/lucasassuncao/movelooper/internal/history"
 	"github.com/lucasassuncao/movelooper/internal/logger"
+	"github.com/lucasassuncao/movelooper/internal/metrics"
 )
 
 // Movelooper holds the app dependencies and runtime state.
@@ -16,4 +17,7 @@ type Movelooper struct {
 	Categories []*Category
 	History    *history.History
 	LogCloser  io.Closer // non-nil when logging to a file; closed on exit
+	// Metrics is non-nil only when a metrics endpoint is enabled (watch
+	// --metrics-addr). Its methods are nil-safe, so callers record unconditionally.
+	Metrics *metrics.Collector
 }
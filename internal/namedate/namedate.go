@@ -0,0 +1,103 @@
+// Package namedate finds the date a file name carries, as cameras, phones,
+// screenshot tools and scanners write it (IMG_20240315_101500.jpg,
+// Screenshot 2024-03-15 at 10.15.00.png). A small set of built-in patterns
+// covers the common layouts; callers may try their own patterns first.
+package namedate
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// builtins are tried in order, most specific first, after any custom
+// patterns. Every pattern names its groups year, month and day, and
+// optionally hour, minute, second and ampm.
+var builtins = []*regexp.Regexp{
+	// IMG_20240315_101500.jpg, PXL_20240315_101500123.jpg, 20240315-101500.mp4
+	regexp.MustCompile(`(?:^|\D)(?P<year>(?:19|20)\d{2})(?P<month>\d{2})(?P<day>\d{2})[_-](?P<hour>\d{2})(?P<minute>\d{2})(?P<second>\d{2})`),
+	// Screenshot 2024-03-15 at 10.15.00.png, Screen Shot 2024-03-15 at 9.05.12 AM.png, 2024-03-15T10:15:00
+	regexp.MustCompile(`(?:^|\D)(?P<year>(?:19|20)\d{2})-(?P<month>\d{2})-(?P<day>\d{2})(?:[ _T]|[ _]at[ _])(?P<hour>\d{1,2})[.:-](?P<minute>\d{2})[.:-](?P<second>\d{2})(?:[ _\x{202f}]?(?P<ampm>[AaPp][Mm]))?`),
+	// 2024-03-15 scan.pdf, notes_2024_03_15.txt, 2024.03.15.jpg
+	regexp.MustCompile(`(?:^|\D)(?P<year>(?:19|20)\d{2})[-_.](?P<month>\d{2})[-_.](?P<day>\d{2})(?:\D|$)`),
+	// IMG-20240315-WA0001.jpg, scan 20240315.pdf
+	regexp.MustCompile(`(?:^|\D)(?P<year>(?:19|20)\d{2})(?P<month>\d{2})(?P<day>\d{2})(?:\D|$)`),
+}
+
+// requiredGroups are the named groups every pattern must have.
+var requiredGroups = []string{"year", "month", "day"}
+
+// Compile compiles a user-defined pattern, which must name its year, month
+// and day groups, e.g. `(?P<day>\d{2})\.(?P<month>\d{2})\.(?P<year>\d{4})`.
+// hour, minute, second and ampm groups are optional, and a two-digit year is
+// taken to be in the 2000s.
+func Compile(expr string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range requiredGroups {
+		if re.SubexpIndex(g) < 0 {
+			return nil, fmt.Errorf("pattern must have a (?P<%s>...) group", g)
+		}
+	}
+	return re, nil
+}
+
+// Parse returns the date found in name, trying custom then the built-in
+// patterns, in the local time zone. It reports false when no pattern matches a
+// valid date. A match that names an impossible date (month 13, February 30)
+// is skipped in favour of the next pattern.
+func Parse(name string, custom []*regexp.Regexp) (time.Time, bool) {
+	for _, patterns := range [][]*regexp.Regexp{custom, builtins} {
+		for _, re := range patterns {
+			if t, ok := match(re, name); ok {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// match applies one pattern and builds the date its groups describe.
+func match(re *regexp.Regexp, name string) (time.Time, bool) {
+	sub := re.FindStringSubmatch(name)
+	if sub == nil {
+		return time.Time{}, false
+	}
+	group := func(g string, def int) (int, bool) {
+		i := re.SubexpIndex(g)
+		if i < 0 || sub[i] == "" {
+			return def, true
+		}
+		n, err := strconv.Atoi(sub[i])
+		return n, err == nil
+	}
+	var v [6]int
+	for i, g := range []string{"year", "month", "day", "hour", "minute", "second"} {
+		n, ok := group(g, 0)
+		if !ok {
+			return time.Time{}, false
+		}
+		v[i] = n
+	}
+	if v[0] < 100 {
+		v[0] += 2000
+	}
+	if i := re.SubexpIndex("ampm"); i >= 0 && sub[i] != "" {
+		if v[3] < 1 || v[3] > 12 {
+			return time.Time{}, false
+		}
+		v[3] %= 12
+		if strings.EqualFold(sub[i], "pm") {
+			v[3] += 12
+		}
+	}
+	t := time.Date(v[0], time.Month(v[1]), v[2], v[3], v[4], v[5], 0, time.Local)
+	if t.Year() != v[0] || int(t.Month()) != v[1] || t.Day() != v[2] || t.Hour() != v[3] || t.Minute() != v[4] || t.Second() != v[5] {
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -0,0 +1,72 @@
+package namedate
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Builtins(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name string
+		want time.Time
+	}{
+		{"IMG_20240315_101500.jpg", time.Date(2024, 3, 15, 10, 15, 0, 0, time.Local)},
+		{"PXL_20240315_101500123.jpg", time.Date(2024, 3, 15, 10, 15, 0, 0, time.Local)},
+		{"VID_20231231-235959.mp4", time.Date(2023, 12, 31, 23, 59, 59, 0, time.Local)},
+		{"Screenshot 2024-03-15 at 10.15.00.png", time.Date(2024, 3, 15, 10, 15, 0, 0, time.Local)},
+		{"Screen Shot 2024-03-15 at 9.05.12 PM.png", time.Date(2024, 3, 15, 21, 5, 12, 0, time.Local)},
+		{"Screen Shot 2024-03-15 at 12.05.12 AM.png", time.Date(2024, 3, 15, 0, 5, 12, 0, time.Local)},
+		{"export_2024-03-15T10:15:00.csv", time.Date(2024, 3, 15, 10, 15, 0, 0, time.Local)},
+		{"2024-03-15 scan.pdf", time.Date(2024, 3, 15, 0, 0, 0, 0, time.Local)},
+		{"notes_2024_03_15.txt", time.Date(2024, 3, 15, 0, 0, 0, 0, time.Local)},
+		{"2024.03.15.jpg", time.Date(2024, 3, 15, 0, 0, 0, 0, time.Local)},
+		{"IMG-20240315-WA0001.jpg", time.Date(2024, 3, 15, 0, 0, 0, 0, time.Local)},
+		{"scan 20240315.pdf", time.Date(2024, 3, 15, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range cases {
+		got, ok := Parse(tt.name, nil)
+		require.True(t, ok, tt.name)
+		assert.True(t, tt.want.Equal(got), "%s: got %s", tt.name, got)
+	}
+}
+
+func TestParse_NoDate(t *testing.T) {
+	t.Parallel()
+	for _, name := range []string{
+		"report.pdf",
+		"invoice-123456789.pdf",
+		"IMG_20241315_101500.jpg",
+		"2024-02-30 notes.txt",
+		"build_120240315.log",
+	} {
+		_, ok := Parse(name, nil)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestParse_CustomFirst(t *testing.T) {
+	t.Parallel()
+	re, err := Compile(`(?P<day>\d{2})\.(?P<month>\d{2})\.(?P<year>\d{2})`)
+	require.NoError(t, err)
+
+	got, ok := Parse("Rechnung 15.03.24.pdf", []*regexp.Regexp{re})
+	require.True(t, ok)
+	assert.True(t, time.Date(2024, 3, 15, 0, 0, 0, 0, time.Local).Equal(got))
+
+	got, ok = Parse("IMG_20240315_101500.jpg", []*regexp.Regexp{re})
+	require.True(t, ok, "built-in patterns still apply when no custom one matches")
+	assert.Equal(t, 10, got.Hour())
+}
+
+func TestCompile(t *testing.T) {
+	t.Parallel()
+	_, err := Compile(`(?P<year>\d{4})(?P<month>\d{2})`)
+	assert.ErrorContains(t, err, "(?P<day>...)")
+	_, err = Compile(`(?P<year>\d{4}`)
+	assert.Error(t, err)
+}
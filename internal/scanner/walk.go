@@ -1,15 +1,18 @@
 // Package scanner walks a category's source directory and returns the regular
-// files eligible for moving, honoring recursion, depth limits, and path
-// exclusions.
+// files eligible for moving, honoring recursion, depth limits, path and
+// pattern exclusions, and .mlignore files.
 package scanner
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/lucasassuncao/movelooper/internal/filters"
+	"github.com/lucasassuncao/movelooper/internal/ignore"
 	"github.com/lucasassuncao/movelooper/internal/models"
 )
 
@@ -20,10 +23,10 @@ type FileEntry struct {
 }
 
 // WalkSource returns all regular files under source.Path that pass the
-// exclusion and depth rules. autoExclude lists destination paths that are
-// automatically excluded to prevent infinite loops when the destination is
-// inside the source tree. When source.Recursive is false only the top-level
-// directory is read.
+// exclusion and depth rules and no .mlignore file ignores. autoExclude lists
+// destination paths that are automatically excluded to prevent infinite loops
+// when the destination is inside the source tree. When source.Recursive is
+// false only the top-level directory is read.
 func WalkSource(ctx context.Context, source models.CategorySource, autoExclude []string) ([]FileEntry, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
@@ -31,16 +34,58 @@ func WalkSource(ctx context.Context, source models.CategorySource, autoExclude [
 	if source.Recursive && source.MaxDepth < 0 {
 		return nil, fmt.Errorf("max-depth must be >= 0 (0 = unlimited), got %d", source.MaxDepth)
 	}
+	ign := ignore.NewMatcher(source.Path)
 	if !source.Recursive {
-		return walkFlat(ctx, source.Path)
+		return walkFlat(ctx, source.Path, ign)
 	}
 	var results []FileEntry
-	err := walkRecursive(ctx, source.Path, 0, source, autoExclude, &results)
+	err := walkRecursive(ctx, source.Path, 0, source, autoExclude, ign, &results)
 	return results, err
 }
 
-// walkFlat reads a single directory and returns FileEntry for every regular file.
-func walkFlat(ctx context.Context, dir string) ([]FileEntry, error) {
+// ErrOutsideSource is returned by Explain for a path that is not under the
+// source path.
+var ErrOutsideSource = errors.New("not under source.path")
+
+// Explain reports why WalkSource would leave out the regular file at path, a
+// file under source.Path: a recursion or depth limit, an exclusion, or the
+// .mlignore rule that ignores it. The empty string means WalkSource returns
+// the file.
+func Explain(source models.CategorySource, autoExclude []string, path string) (string, error) {
+	rel, err := filepath.Rel(source.Path, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrOutsideSource
+	}
+	depth := strings.Count(rel, string(filepath.Separator))
+	if depth > 0 && !source.Recursive {
+		return "in a subfolder and source.recursive is off", nil
+	}
+	if source.MaxDepth > 0 && depth > source.MaxDepth {
+		return fmt.Sprintf("deeper than source.max-depth (%d)", source.MaxDepth), nil
+	}
+	for dir := filepath.Dir(path); depth > 0; dir, depth = filepath.Dir(dir), depth-1 {
+		switch {
+		case isExcluded(dir, autoExclude):
+			return "inside the destination", nil
+		case isExcluded(dir, source.ExcludePaths):
+			return "under a source.exclude-paths entry", nil
+		case matchesExcludePattern(source, dir):
+			return fmt.Sprintf("folder %s matches source.exclude-patterns", filters.RelPath(source.Path, dir)), nil
+		}
+	}
+	rule, err := ignore.Check(source.Path, path, false)
+	if err != nil {
+		return "", err
+	}
+	if rule != nil {
+		return "ignored by " + rule.String(), nil
+	}
+	return "", nil
+}
+
+// walkFlat reads a single directory and returns FileEntry for every regular
+// file its ignore file does not ignore.
+func walkFlat(ctx context.Context, dir string, ign *ignore.Matcher) ([]FileEntry, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
@@ -48,9 +93,12 @@ func walkFlat(ctx context.Context, dir string) ([]FileEntry, error) {
 	if err != nil {
 		return nil, err
 	}
+	if ign, err = ign.Enter(dir); err != nil {
+		return nil, err
+	}
 	var result []FileEntry
 	for _, e := range entries {
-		if e.Type().IsRegular() {
+		if e.Type().IsRegular() && ign.Match(filepath.Join(dir, e.Name()), false) == nil {
 			result = append(result, FileEntry{Dir: dir, Entry: e})
 		}
 	}
@@ -58,13 +106,15 @@ func walkFlat(ctx context.Context, dir string) ([]FileEntry, error) {
 }
 
 // walkRecursive descends into dir, collecting regular files while honouring
-// exclusion rules and max-depth.
+// exclusion rules, max-depth and the ignore files of dir and its parents,
+// whose rules ign holds.
 func walkRecursive(
 	ctx context.Context,
 	dir string,
 	depth int,
 	source models.CategorySource,
 	autoExclude []string,
+	ign *ignore.Matcher,
 	results *[]FileEntry,
 ) error {
 	if err := ctx.Err(); err != nil {
@@ -78,10 +128,15 @@ func walkRecursive(
 	if err != nil {
 		return err
 	}
+	if ign, err = ign.Enter(dir); err != nil {
+		return err
+	}
 
 	for _, e := range entries {
 		if e.Type().IsRegular() {
-			*results = append(*results, FileEntry{Dir: dir, Entry: e})
+			if ign.Match(filepath.Join(dir, e.Name()), false) == nil {
+				*results = append(*results, FileEntry{Dir: dir, Entry: e})
+			}
 			continue
 		}
 		if !e.IsDir() {
@@ -92,16 +147,32 @@ func walkRecursive(
 			continue // depth limit reached, do not descend
 		}
 		childDir := filepath.Join(dir, e.Name())
-		if isExcluded(childDir, autoExclude) || isExcluded(childDir, source.ExcludePaths) {
+		if isExcluded(childDir, autoExclude) || isExcluded(childDir, source.ExcludePaths) ||
+			matchesExcludePattern(source, childDir) || ign.Match(childDir, true) != nil {
 			continue // skip before incurring the ReadDir syscall inside the recursive call
 		}
-		if err := walkRecursive(ctx, childDir, childDepth, source, autoExclude, results); err != nil {
+		if err := walkRecursive(ctx, childDir, childDepth, source, autoExclude, ign, results); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// matchesExcludePattern reports whether dir's path relative to the source
+// root matches any of source.ExcludePatterns.
+func matchesExcludePattern(source models.CategorySource, dir string) bool {
+	if len(source.ExcludePatterns) == 0 {
+		return false
+	}
+	rel := filters.RelPath(source.Path, dir)
+	for _, pattern := range source.ExcludePatterns {
+		if filters.MatchesPathGlob(rel, pattern, true) {
+			return true
+		}
+	}
+	return false
+}
+
 // isExcluded reports whether dir is equal to or a subdirectory of any path in list.
 func isExcluded(dir string, list []string) bool {
 	cleanDir := filepath.Clean(dir)
@@ -26,7 +26,7 @@ type testWalkSource struct {
 }
 
 // testWalkSourceTestCases defines a set of test cases for the WalkSource function,
-// covering non-recursive, recursive, max depth, auto-exclude, user-defined excludes,
+// covering non-recursive, recursive, max depth, auto-exclude, user-defined excludes, exclude patterns, .mlignore files,
 // symlink skipping, empty directory, invalid path, and absolute Dir field scenarios.
 var testWalkSourceTestCases = []testWalkSource{
 	{
@@ -110,6 +110,52 @@ var testWalkSourceTestCases = []testWalkSource{
 			assert.NotContains(t, names, "old.pdf")
 		},
 	},
+	{
+		name: "exclude patterns prune matching directories at any depth",
+		setup: func(t *testing.T, root string) {
+			touch(t, filepath.Join(root, "a.pdf"))
+			touch(t, filepath.Join(mkdirAll(t, root, "node_modules"), "top.pdf"))
+			touch(t, filepath.Join(mkdirAll(t, root, "app/node_modules/pkg"), "deep.pdf"))
+			touch(t, filepath.Join(mkdirAll(t, root, "backups/2024"), "old.pdf"))
+			touch(t, filepath.Join(mkdirAll(t, root, "backups"), "keep.pdf"))
+		},
+		srcOpts: func(root string) []func(*models.CategorySource) {
+			return []func(*models.CategorySource){withRecursive, withExcludePatterns("**/node_modules", "backups/*")}
+		},
+		check: func(t *testing.T, entries []scanner.FileEntry, root string) {
+			assert.ElementsMatch(t, []string{"a.pdf", "keep.pdf"}, entryNames(entries))
+		},
+	},
+	{
+		name: "mlignore files are honoured at every level",
+		setup: func(t *testing.T, root string) {
+			write(t, filepath.Join(root, ".mlignore"), "*.part\ncache/\n")
+			touch(t, filepath.Join(root, "a.pdf"))
+			touch(t, filepath.Join(root, "b.part"))
+			touch(t, filepath.Join(mkdirAll(t, root, "x/cache"), "c.pdf"))
+			sub := mkdirAll(t, root, "keep")
+			write(t, filepath.Join(sub, ".mlignore"), "!*.part\n/d.pdf\n")
+			touch(t, filepath.Join(sub, "d.pdf"))
+			touch(t, filepath.Join(sub, "e.part"))
+		},
+		srcOpts: func(root string) []func(*models.CategorySource) {
+			return []func(*models.CategorySource){withRecursive}
+		},
+		check: func(t *testing.T, entries []scanner.FileEntry, root string) {
+			assert.ElementsMatch(t, []string{"a.pdf", "e.part"}, entryNames(entries))
+		},
+	},
+	{
+		name: "mlignore applies to a non-recursive scan",
+		setup: func(t *testing.T, root string) {
+			write(t, filepath.Join(root, ".mlignore"), "*.part\n")
+			touch(t, filepath.Join(root, "a.pdf"))
+			touch(t, filepath.Join(root, "b.part"))
+		},
+		check: func(t *testing.T, entries []scanner.FileEntry, root string) {
+			assert.Equal(t, []string{"a.pdf"}, entryNames(entries))
+		},
+	},
 	{
 		name: "recursive skips symlinks",
 		setup: func(t *testing.T, root string) {
@@ -209,6 +255,13 @@ func withExclude(paths ...string) func(*models.CategorySource) {
 	}
 }
 
+// withExcludePatterns returns a function that sets the ExcludePatterns field of a CategorySource to the specified globs.
+func withExcludePatterns(patterns ...string) func(*models.CategorySource) {
+	return func(s *models.CategorySource) {
+		s.ExcludePatterns = patterns
+	}
+}
+
 // withMaxDepth returns a function that sets the MaxDepth field of a CategorySource to the specified value.
 func withMaxDepth(n int) func(*models.CategorySource) {
 	return func(s *models.CategorySource) {
@@ -252,3 +305,44 @@ func touch(t *testing.T, path string) {
 	t.Helper()
 	require.NoError(t, os.WriteFile(path, []byte{}, 0o644))
 }
+
+// write creates a file at path with the given content.
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestExplain(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	write(t, filepath.Join(root, ".mlignore"), "# partial downloads\n*.part\n")
+	dest := mkdirAll(t, root, "Sorted")
+	excluded := mkdirAll(t, root, "Archive")
+	mkdirAll(t, root, "a/node_modules/b/c")
+	source := models.CategorySource{
+		Path: root, Recursive: true, MaxDepth: 3,
+		ExcludePaths: []string{excluded}, ExcludePatterns: []string{"**/node_modules"},
+	}
+	cases := map[string]string{
+		"x.pdf":                        "",
+		"a/y.pdf":                      "",
+		"z.part":                       "ignored by " + filepath.Join(root, ".mlignore") + ":2: *.part",
+		"Sorted/x.pdf":                 "inside the destination",
+		"Archive/x.pdf":                "under a source.exclude-paths entry",
+		"a/node_modules/b/x.pdf":       "folder a/node_modules matches source.exclude-patterns",
+		"a/node_modules/b/c/d/e/x.pdf": "deeper than source.max-depth (3)",
+	}
+	for rel, want := range cases {
+		got, err := scanner.Explain(source, []string{dest}, filepath.Join(root, filepath.FromSlash(rel)))
+		require.NoError(t, err, rel)
+		assert.Equal(t, want, got, rel)
+	}
+
+	source.Recursive = false
+	got, err := scanner.Explain(source, nil, filepath.Join(root, "a", "y.pdf"))
+	require.NoError(t, err)
+	assert.Equal(t, "in a subfolder and source.recursive is off", got)
+
+	_, err = scanner.Explain(source, nil, filepath.Join(filepath.Dir(root), "elsewhere.pdf"))
+	assert.ErrorIs(t, err, scanner.ErrOutsideSource)
+}
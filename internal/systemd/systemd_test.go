@@ -0,0 +1,112 @@
+package systemd
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func listenNotify(t *testing.T) (string, *net.UnixConn) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return path, conn
+}
+
+func readDatagram(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	return string(buf[:n])
+}
+
+func TestNotifier_NilIsNoop(t *testing.T) {
+	n := newNotifier("", "", "", 1)
+	assert.Nil(t, n)
+	assert.NoError(t, n.Ready("x"))
+	assert.NoError(t, n.Watchdog())
+	assert.Zero(t, n.WatchdogInterval())
+}
+
+func TestNotifier_SendsDatagrams(t *testing.T) {
+	path, conn := listenNotify(t)
+	n := newNotifier(path, "", "", os.Getpid())
+	require.NotNil(t, n)
+
+	require.NoError(t, n.Ready("watching; 0 file(s) pending"))
+	assert.Equal(t, "READY=1\nSTATUS=watching; 0 file(s) pending", readDatagram(t, conn))
+
+	require.NoError(t, n.Status("busy"))
+	assert.Equal(t, "STATUS=busy", readDatagram(t, conn))
+
+	require.NoError(t, n.Watchdog())
+	assert.Equal(t, "WATCHDOG=1", readDatagram(t, conn))
+
+	require.NoError(t, n.Stopping())
+	assert.Equal(t, "STOPPING=1", readDatagram(t, conn))
+}
+
+func TestNotifier_WatchdogInterval(t *testing.T) {
+	n := newNotifier("/run/x", "60000000", "", 42)
+	assert.Equal(t, 30*time.Second, n.WatchdogInterval())
+
+	n = newNotifier("/run/x", "60000000", "42", 42)
+	assert.Equal(t, 30*time.Second, n.WatchdogInterval(), "matching WATCHDOG_PID")
+
+	n = newNotifier("/run/x", "60000000", "7", 42)
+	assert.Zero(t, n.WatchdogInterval(), "watchdog meant for another process")
+
+	n = newNotifier("/run/x", "garbage", "", 42)
+	assert.Zero(t, n.WatchdogInterval())
+}
+
+func TestRenderUnit(t *testing.T) {
+	unit := RenderUnit(UnitOptions{
+		Executable: "/usr/local/bin/movelooper",
+		ConfigPath: `/home/me/my "cfg" 100%$HOME.yaml`,
+		ExtraArgs:  []string{"--category", "images"},
+	})
+	assert.Contains(t, unit, "Type=notify\n")
+	assert.Contains(t, unit, "WatchdogSec=60\n")
+	assert.Contains(t, unit, "WantedBy=default.target\n")
+	assert.NotContains(t, unit, "After=")
+	assert.Contains(t, unit,
+		`ExecStart="/usr/local/bin/movelooper" "watch" "--config" "/home/me/my \"cfg\" 100%%$$HOME.yaml" "--category" "images"`+"\n")
+}
+
+func TestUnitPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	p, err := UnitPath("movelooper")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "systemd", "user", "movelooper.service"), p)
+
+	p, err = UnitPath("ml.service")
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(p, "ml.service"))
+}
+
+func TestWriteAndRemoveUnit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "systemd", "user", "movelooper.service")
+	require.NoError(t, WriteUnit(path, "[Unit]\n"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "[Unit]\n", string(data))
+
+	require.NoError(t, RemoveUnit(path))
+	require.NoError(t, RemoveUnit(path), "removing a missing unit is not an error")
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,129 @@
+package systemd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultUnitName is the unit name used when the caller does not pick one.
+const DefaultUnitName = "movelooper"
+
+// UnitOptions describes the user unit rendered by RenderUnit.
+type UnitOptions struct {
+	// Executable is the absolute path of the movelooper binary.
+	Executable string
+	// ConfigPath is the resolved, absolute configuration file path.
+	ConfigPath string
+	// ExtraArgs are appended to "watch --config <path>" verbatim.
+	ExtraArgs []string
+}
+
+// RenderUnit returns the contents of a Type=notify user unit that runs
+// `movelooper watch` against opts.ConfigPath. The watchdog is enabled so
+// systemd restarts the daemon if it stops pinging.
+func RenderUnit(opts UnitOptions) string {
+	args := append([]string{opts.Executable, "watch", "--config", opts.ConfigPath}, opts.ExtraArgs...)
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = quoteArg(a)
+	}
+
+	var b strings.Builder
+	b.WriteString("# Generated by `movelooper service install`.\n")
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=movelooper watch\n\n")
+	b.WriteString("[Service]\n")
+	b.WriteString("Type=notify\n")
+	b.WriteString("NotifyAccess=main\n")
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(quoted, " "))
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=10\n")
+	b.WriteString("WatchdogSec=60\n\n")
+	b.WriteString("[Install]\n")
+	b.WriteString("WantedBy=default.target\n")
+	return b.String()
+}
+
+// quoteArg quotes a single ExecStart argument: the value is wrapped in double
+// quotes with backslashes and quotes escaped, '%' is doubled so systemd does
+// not treat it as a specifier, and '$' is doubled so it does not expand an
+// environment variable.
+func quoteArg(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "%", "%%")
+	s = strings.ReplaceAll(s, "$", "$$")
+	return `"` + s + `"`
+}
+
+// UserUnitDir returns the directory systemd searches for user units:
+// $XDG_CONFIG_HOME/systemd/user, or ~/.config/systemd/user.
+func UserUnitDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "systemd", "user"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolving home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "systemd", "user"), nil
+}
+
+// UnitPath returns the file path of the user unit called name.
+func UnitPath(name string) (string, error) {
+	dir, err := UserUnitDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, unitFileName(name)), nil
+}
+
+func unitFileName(name string) string {
+	if strings.HasSuffix(name, ".service") {
+		return name
+	}
+	return name + ".service"
+}
+
+// WriteUnit writes content to path atomically, creating the unit directory
+// when needed.
+func WriteUnit(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("creating unit directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0o600); err != nil { //#nosec G306 -- unit files only need to be readable by their owner
+		return fmt.Errorf("writing unit file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("installing unit file: %w", err)
+	}
+	return nil
+}
+
+// RemoveUnit deletes the unit file at path. A missing file is not an error.
+func RemoveUnit(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing unit file: %w", err)
+	}
+	return nil
+}
+
+// Systemctl runs `systemctl --user <args...>` and includes its output in the
+// returned error on failure.
+func Systemctl(args ...string) error {
+	full := append([]string{"--user"}, args...)
+	out, err := exec.Command("systemctl", full...).CombinedOutput() //#nosec G204 -- fixed binary, arguments built by movelooper
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			return fmt.Errorf("systemctl %s: %w", strings.Join(full, " "), err)
+		}
+		return fmt.Errorf("systemctl %s: %w: %s", strings.Join(full, " "), err, msg)
+	}
+	return nil
+}
@@ -8,11 +8,58 @@ import (
 	"time"
 )
 
-// getBirthTime returns the file creation (birth) time.
+// BirthTime returns the file creation (birth) time.
 // On Windows it reads CreationTime from Win32FileAttributeData.
 // On macOS it reads Birthtimespec from Stat_t.
 // On other platforms it falls back to modification time.
-func getBirthTime(info os.FileInfo) time.Time {
+func BirthTime(info os.FileInfo) time.Time {
+	switch runtime.GOOS {
+	case "windows":
+		return sysTime(info, "CreationTime")
+	case "darwin":
+		return sysTime(info, "Birthtimespec")
+	default:
+		return info.ModTime()
+	}
+}
+
+// AccessTime returns the time the file was last read.
+// On Windows it reads LastAccessTime from Win32FileAttributeData; on Linux
+// Atim and on macOS and the BSDs Atimespec from Stat_t. Elsewhere it falls
+// back to modification time. File systems mounted noatime or relatime update
+// it rarely or never.
+func AccessTime(info os.FileInfo) time.Time {
+	switch runtime.GOOS {
+	case "windows":
+		return sysTime(info, "LastAccessTime")
+	case "linux", "android":
+		return sysTime(info, "Atim")
+	default:
+		return sysTime(info, "Atimespec")
+	}
+}
+
+// ChangeTime returns the time the file's metadata (inode) last changed, which
+// a rename, chmod or write updates.
+// On Linux it reads Ctim and on macOS and the BSDs Ctimespec from Stat_t.
+// Windows has no such time, so there and elsewhere it falls back to
+// modification time.
+func ChangeTime(info os.FileInfo) time.Time {
+	switch runtime.GOOS {
+	case "windows":
+		return info.ModTime()
+	case "linux", "android":
+		return sysTime(info, "Ctim")
+	default:
+		return sysTime(info, "Ctimespec")
+	}
+}
+
+// sysTime reads the named time field of info.Sys(): a syscall.Timespec
+// {Sec, Nsec} in Stat_t or a syscall.Filetime {LowDateTime, HighDateTime}
+// in Win32FileAttributeData. A missing or unusable field gives the
+// modification time.
+func sysTime(info os.FileInfo, field string) time.Time {
 	sys := info.Sys()
 	if sys == nil {
 		return info.ModTime()
@@ -22,37 +69,31 @@ func getBirthTime(info os.FileInfo) time.Time {
 	if v.Kind() == reflect.Ptr {
 		v = v.Elem()
 	}
+	if v.Kind() != reflect.Struct {
+		return info.ModTime()
+	}
+	f := v.FieldByName(field)
+	if !f.IsValid() || f.Kind() != reflect.Struct {
+		return info.ModTime()
+	}
 
-	switch runtime.GOOS {
-	case "windows":
-		// syscall.Win32FileAttributeData.CreationTime is a syscall.Filetime{LowDateTime, HighDateTime uint32}
-		ct := v.FieldByName("CreationTime")
-		if !ct.IsValid() {
-			return info.ModTime()
-		}
-		lo := ct.FieldByName("LowDateTime").Uint()
-		hi := ct.FieldByName("HighDateTime").Uint()
-		ft := hi<<32 | lo
-		// Windows FILETIME: 100-ns intervals since 1601-01-01; Unix epoch offset in same unit.
-		const windowsEpochOffset uint64 = 116444736000000000
-		if ft < windowsEpochOffset {
-			return info.ModTime()
-		}
-		ns := ft - windowsEpochOffset
-		if ns > math.MaxInt64/100 {
-			return info.ModTime()
-		}
-		return time.Unix(0, int64(ns)*100)
-
-	case "darwin":
-		// syscall.Stat_t.Birthtimespec is a syscall.Timespec{Sec, Nsec int64}
-		bts := v.FieldByName("Birthtimespec")
-		if !bts.IsValid() {
-			return info.ModTime()
-		}
-		return time.Unix(bts.FieldByName("Sec").Int(), bts.FieldByName("Nsec").Int())
-
-	default:
+	if sec := f.FieldByName("Sec"); sec.IsValid() {
+		return time.Unix(sec.Int(), f.FieldByName("Nsec").Int())
+	}
+	lo := f.FieldByName("LowDateTime")
+	hi := f.FieldByName("HighDateTime")
+	if !lo.IsValid() || !hi.IsValid() {
+		return info.ModTime()
+	}
+	ft := hi.Uint()<<32 | lo.Uint()
+	// Windows FILETIME: 100-ns intervals since 1601-01-01; Unix epoch offset in same unit.
+	const windowsEpochOffset uint64 = 116444736000000000
+	if ft < windowsEpochOffset {
+		return info.ModTime()
+	}
+	ns := ft - windowsEpochOffset
+	if ns > math.MaxInt64/100 {
 		return info.ModTime()
 	}
+	return time.Unix(0, int64(ns)*100)
 }
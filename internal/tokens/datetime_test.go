@@ -3,6 +3,7 @@ package tokens
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 
@@ -10,8 +11,8 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-// TestGetBirthTime tests that getBirthTime returns a recent, non-zero time for a newly created file.
-func TestGetBirthTime(t *testing.T) {
+// TestBirthTime tests that BirthTime returns a recent, non-zero time for a newly created file.
+func TestBirthTime(t *testing.T) {
 	t.Parallel()
 	tmp := t.TempDir()
 	path := filepath.Join(tmp, "file.txt")
@@ -20,14 +21,14 @@ func TestGetBirthTime(t *testing.T) {
 	info, err := os.Stat(path)
 	require.NoError(t, err)
 
-	got := getBirthTime(info)
+	got := BirthTime(info)
 
 	assert.False(t, got.IsZero(), "birth time should not be zero")
 	assert.WithinDuration(t, time.Now(), got, 10*time.Second, "birth time should be recent")
 }
 
-// TestGetBirthTime_ModifiedMtime tests that getBirthTime returns a non-zero time even when the file's mtime has been modified.
-func TestGetBirthTime_ModifiedMtime(t *testing.T) {
+// TestBirthTime_ModifiedMtime tests that BirthTime returns a non-zero time even when the file's mtime has been modified.
+func TestBirthTime_ModifiedMtime(t *testing.T) {
 	t.Parallel()
 	tmp := t.TempDir()
 	path := filepath.Join(tmp, "file.txt")
@@ -39,7 +40,44 @@ func TestGetBirthTime_ModifiedMtime(t *testing.T) {
 	info, err := os.Stat(path)
 	require.NoError(t, err)
 
-	got := getBirthTime(info)
+	got := BirthTime(info)
 
 	assert.False(t, got.IsZero(), "birth time should not be zero even with modified mtime")
 }
+
+// TestAccessTime tests that AccessTime returns the access time os.Chtimes sets,
+// where the platform exposes one.
+func TestAccessTime(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("access time is read on Linux and macOS")
+	}
+	path := filepath.Join(t.TempDir(), "file.txt")
+	require.NoError(t, os.WriteFile(path, []byte("x"), 0o644))
+	atime := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	mtime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, os.Chtimes(path, atime, mtime))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	assert.True(t, atime.Equal(AccessTime(info)), "got %s", AccessTime(info))
+}
+
+// TestChangeTime tests that ChangeTime reflects the last metadata change, not
+// the modification time os.Chtimes backdated.
+func TestChangeTime(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("change time is read on Linux and macOS")
+	}
+	path := filepath.Join(t.TempDir(), "file.txt")
+	require.NoError(t, os.WriteFile(path, []byte("x"), 0o644))
+	old := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, os.Chtimes(path, old, old))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	assert.WithinDuration(t, time.Now(), ChangeTime(info), 10*time.Second)
+}
@@ -0,0 +1,91 @@
+package tokens
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/lucasassuncao/movelooper/internal/media"
+)
+
+// unknownMetadata stands in for a metadata token the file does not record.
+const unknownMetadata = "unknown"
+
+// exifTokens are the tokens preProcessExif resolves.
+var exifTokens = []string{"{exif-year}", "{exif-month}", "{exif-date}", "{camera-make}", "{camera-model}", "{lens}", "{iso}"}
+
+func hasExifToken(template string) bool {
+	for _, tok := range exifTokens {
+		if strings.Contains(template, tok) {
+			return true
+		}
+	}
+	return false
+}
+
+// photoMetadata returns the EXIF metadata of ctx.SourcePath, reading the file
+// on first use only. A file without metadata gives the zero Metadata.
+func (ctx *TokenContext) photoMetadata() media.Metadata {
+	if ctx.photo == nil {
+		md, _ := media.Read(ctx.SourcePath)
+		ctx.photo = &md
+	}
+	return *ctx.photo
+}
+
+// preProcessExif resolves the photo metadata tokens. The date tokens use the
+// time the photo was taken and fall back to the modification time when the
+// file records none; the camera tokens fall back to "unknown".
+func preProcessExif(template string, ctx *TokenContext) string {
+	if !hasExifToken(template) {
+		return template
+	}
+	md := ctx.photoMetadata()
+	taken := md.Taken
+	if taken.IsZero() {
+		taken = inZone(ctx.Info.ModTime())
+	}
+	iso := unknownMetadata
+	if md.ISO > 0 {
+		iso = strconv.Itoa(md.ISO)
+	}
+	return strings.NewReplacer(
+		"{exif-year}", taken.Format("2006"),
+		"{exif-month}", taken.Format("01"),
+		"{exif-date}", taken.Format("2006-01-02"),
+		"{camera-make}", metadataSegment(md.Make),
+		"{camera-model}", metadataSegment(md.Model),
+		"{lens}", metadataSegment(md.Lens),
+		"{iso}", iso,
+	).Replace(template)
+}
+
+// metadataSegment makes a metadata string safe to use as one path segment,
+// with "unknown" for an empty value.
+func metadataSegment(s string) string {
+	return pathSegment(s, unknownMetadata)
+}
+
+// segmentReplacer maps path separators to "-" and the other characters that
+// are invalid in Windows file names to "_".
+var segmentReplacer = strings.NewReplacer(
+	"/", "-", `\`, "-",
+	":", "_", "*", "_", "?", "_", `"`, "_", "<", "_", ">", "_", "|", "_",
+)
+
+// pathSegment makes a string read from file metadata safe to use as one path
+// segment: separators and reserved characters are replaced, control
+// characters dropped, and trailing dots and spaces trimmed. A value that ends
+// up empty, "." or ".." becomes fallback.
+func pathSegment(s, fallback string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7F {
+			return -1
+		}
+		return r
+	}, segmentReplacer.Replace(s))
+	s = strings.TrimRight(strings.TrimSpace(s), ". ")
+	if s == "" {
+		return fallback
+	}
+	return s
+}
@@ -0,0 +1,58 @@
+package tokens
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lucasassuncao/movelooper/internal/media"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveGroupBy_Exif(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "IMG_0001.jpg")
+	require.NoError(t, os.WriteFile(path, []byte("not a photo"), 0o644))
+	mtime := time.Date(2025, 2, 3, 10, 0, 0, 0, time.Local)
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	ctx := &TokenContext{Info: info, Now: time.Now(), SourcePath: path}
+	assert.Equal(t, filepath.FromSlash("2025/2025-02-03/unknown_unknown"), ResolveGroupBy("{exif-year}/{exif-date}/{camera-model}_{iso}", ctx),
+		"without EXIF the dates fall back to mtime")
+
+	ctx = &TokenContext{Info: info, Now: time.Now(), SourcePath: path}
+	ctx.photo = &media.Metadata{
+		Make:  "Canon",
+		Model: "Canon EOS R6",
+		Lens:  "EF 70-200mm f/2.8L",
+		ISO:   800,
+		Taken: time.Date(2023, 7, 14, 18, 30, 5, 0, time.Local),
+	}
+	assert.Equal(t, filepath.FromSlash("Canon/Canon EOS R6/2023-07"), ResolveGroupBy("{camera-make}/{camera-model}/{exif-year}-{exif-month}", ctx))
+	assert.Equal(t, "EF 70-200mm f-2.8L_800.jpg", ResolveRename("{lens}_{iso}.{ext}", ctx), "separators in values are replaced")
+}
+
+// TestPhotoMetadata_Cached verifies that a TokenContext reads the file once.
+func TestPhotoMetadata_Cached(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "a.jpg")
+	require.NoError(t, os.WriteFile(path, []byte("x"), 0o644))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	ctx := &TokenContext{Info: info, SourcePath: path}
+	_ = ResolveGroupBy("{camera-model}", ctx)
+	require.NotNil(t, ctx.photo)
+	ctx.photo.Model = "cached"
+	assert.Equal(t, "cached", ResolveGroupBy("{camera-model}", ctx))
+}
+
+func TestValidateTemplate_Exif(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, ValidateTemplate("{exif-year}/{exif-month}/{exif-date}/{camera-make}/{camera-model}/{lens}/{iso}"))
+	assert.Error(t, ValidateTemplate("{exif-day}"))
+}
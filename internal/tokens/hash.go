@@ -47,13 +47,27 @@ func preProcessHash(template, sourcePath string) string {
 }
 
 func computeFileHash(path string, h hash.Hash) string {
-	f, err := os.Open(filepath.Clean(path)) //#nosec G304 -- path comes from validated file walk
+	sum, err := hashFile(path, h)
 	if err != nil {
 		return "unknown"
 	}
+	return sum
+}
+
+// FileSHA256 returns the hex SHA-256 of the file at path: the full hash that
+// {sha256:N} truncates.
+func FileSHA256(path string) (string, error) {
+	return hashFile(path, sha256.New())
+}
+
+func hashFile(path string, h hash.Hash) (string, error) {
+	f, err := os.Open(filepath.Clean(path)) //#nosec G304 -- path comes from validated file walk or from history
+	if err != nil {
+		return "", err
+	}
 	defer f.Close()
 	if _, err := io.Copy(h, f); err != nil {
-		return "unknown"
+		return "", err
 	}
-	return fmt.Sprintf("%x", h.Sum(nil))
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
 }
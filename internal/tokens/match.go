@@ -0,0 +1,48 @@
+package tokens
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// matchToken matches {match:GROUP}, where GROUP is a capture group number or
+// name of the category's filter.match.regex.
+var matchToken = regexp.MustCompile(`\{match:(\w+)\}`)
+
+// preProcessMatch resolves {match:GROUP} to the group's text in the file name.
+// A group that did not take part in the match resolves to "".
+func preProcessMatch(template string, ctx *TokenContext) string {
+	if !strings.Contains(template, "{match:") {
+		return template
+	}
+	return matchToken.ReplaceAllStringFunc(template, func(tok string) string {
+		return ctx.Captures[matchToken.FindStringSubmatch(tok)[1]]
+	})
+}
+
+// validateMatchToken checks the syntax of a {match:GROUP} token. Whether the
+// group exists depends on the category's regex, which callers check with
+// MatchGroups.
+func validateMatchToken(tok string) error {
+	if !matchToken.MatchString(tok) || matchToken.FindString(tok) != tok {
+		return fmt.Errorf("token %q: the group must be a capture group number or name", tok)
+	}
+	return nil
+}
+
+// MatchGroups returns the capture groups, numbers or names, that the
+// {match:GROUP} tokens of template reference, in order of appearance.
+func MatchGroups(template string) []string {
+	tmpl, err := parseTemplate(template)
+	if err != nil {
+		return nil
+	}
+	var groups []string
+	for _, n := range tmpl.nodes {
+		if m := matchToken.FindStringSubmatch(n.token); m != nil {
+			groups = append(groups, m[1])
+		}
+	}
+	return groups
+}
@@ -0,0 +1,40 @@
+package tokens
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveGroupBy_Match(t *testing.T) {
+	t.Parallel()
+	ctx := pipelineContext(t, "INV-acme-2024-07.pdf")
+	ctx.Captures = map[string]string{"0": "INV-acme-2024", "1": "acme", "2": "2024", "client": "acme", "year": "2024"}
+
+	assert.Equal(t, filepath.FromSlash("acme/2024"), ResolveGroupBy("{match:client}/{match:2}", ctx))
+	assert.Equal(t, "ACME_2024-07.pdf", ResolveRename("{match:client|upper}_{match:year}-07.{ext}", ctx))
+	assert.Equal(t, "INV-acme-2024", ResolveGroupBy("{match:0}", ctx))
+	assert.Equal(t, "x--y", ResolveGroupBy("x-{match:missing}-y", ctx), "a group without a value resolves to empty")
+}
+
+func TestResolveGroupBy_MatchValuesAreNotReexpanded(t *testing.T) {
+	t.Parallel()
+	ctx := pipelineContext(t, "a{ext}.pdf")
+	ctx.Captures = map[string]string{"1": "{ext}"}
+	assert.Equal(t, "{ext}-pdf", ResolveGroupBy("{match:1}-{ext}", ctx))
+}
+
+func TestMatchGroups(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, []string{"client", "1", "year"}, MatchGroups("{match:client}/{match:1|upper}_{name}_{match:year}"))
+	assert.Empty(t, MatchGroups("{name}/{mod-year}"))
+	assert.Empty(t, MatchGroups("{name|nope}"), "an invalid template references nothing")
+}
+
+func TestValidateTemplate_Match(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, ValidateTemplate("{match:client}/{match:1}/{match:year|slug}"))
+	assert.ErrorContains(t, ValidateTemplate("{match:first-name}"), "capture group number or name")
+	assert.ErrorContains(t, ValidateTemplate("{match:}"), "capture group number or name")
+}
@@ -2,20 +2,37 @@ package tokens
 
 import (
 	"os"
+	"regexp"
 	"strings"
 	"time"
+
+	"github.com/lucasassuncao/movelooper/internal/content"
+	"github.com/lucasassuncao/movelooper/internal/media"
 )
 
 // TokenContext carries all inputs needed to resolve any token in a template.
 // ResolveGroupBy uses Info, CategoryName, and Now.
 // ResolveRename additionally uses DestDir and SourcePath.
 type TokenContext struct {
-	Info         os.FileInfo
-	CategoryName string
-	Now          time.Time
-	DestDir      string        // required for seq, seq-alpha, seq-roman
-	SourcePath   string        // required for {md5}, {sha256:N}
-	DryRun       bool          // when true, seq/hash tokens are left as literal placeholders
-	SeqAlloc     *SeqAllocator // optional per-batch sequence counter; nil falls back to a directory scan per file
-	replacer     *strings.Replacer
+	Info             os.FileInfo
+	CategoryName     string
+	Now              time.Time
+	DestDir          string            // required for seq, seq-alpha, seq-roman
+	SourcePath       string            // required for {md5}, {sha256:N}, {mime*} and the EXIF, audio tag and video tokens
+	SourceRoot       string            // the category's source.path, for {relpath} and {depth}
+	DryRun           bool              // when true, seq/hash tokens are left as literal placeholders
+	SeqAlloc         *SeqAllocator     // optional per-batch sequence counter; nil falls back to a directory scan per file
+	TagDefaults      map[string]string // optional values for missing audio tags, keyed by token name; see TagDefaultKeys
+	Captures         map[string]string // filter.match.regex capture groups of the file name, by number and name, for {match:...}
+	NameDatePatterns []*regexp.Regexp  // the category's source.name-date-patterns, tried before the built-in ones by the {name-date} tokens
+	replacer         *strings.Replacer
+	// mime, photo, tags and video cache what was read from SourcePath, so a
+	// file is sniffed once however many templates reference it. Each is filled
+	// only when a template uses one of its tokens, and, being read-only, also
+	// in dry-run. A nil pointer means not read yet; a read error is cached as
+	// the zero value.
+	mime  *content.Info
+	photo *media.Metadata
+	tags  *media.Tags
+	video *media.Video
 }
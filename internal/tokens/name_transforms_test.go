@@ -1,9 +1,12 @@
 package tokens
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // testNameTransform defines the structure for test cases of the name transform functions,
@@ -62,17 +65,17 @@ func TestNameTransforms(t *testing.T) {
 	}
 }
 
-// testPreProcessNameTrunc defines the structure for test cases of the preProcessNameTrunc function,
-// containing the template string, the file name, and the expected output.
-type testPreProcessNameTrunc struct {
+// testNameTrunc defines the structure for test cases of the {name-trunc:N}
+// alias, containing the template string, the file name, and the expected output.
+type testNameTrunc struct {
 	template string
 	name     string
 	want     string
 }
 
-// testPreProcessNameTruncTestCases defines a set of test cases for the preProcessNameTrunc function,
+// testNameTruncTestCases defines a set of test cases for {name-trunc:N},
 // including truncation by rune count (not bytes) and passthrough when no token is present.
-var testPreProcessNameTruncTestCases = []testPreProcessNameTrunc{
+var testNameTruncTestCases = []testNameTrunc{
 	{"{name-trunc:4}", "very-long-name", "very"},
 	{"{name-trunc:8}", "very-long-name", "very-lon"},
 	{"{name-trunc:20}", "short", "short"},
@@ -84,13 +87,17 @@ var testPreProcessNameTruncTestCases = []testPreProcessNameTrunc{
 	{"{name-trunc:2}", "日本語", "日本"},
 }
 
-// TestPreProcessNameTrunc tests the preProcessNameTrunc function to ensure it correctly truncates names by rune count.
-func TestPreProcessNameTrunc(t *testing.T) {
+// TestNameTrunc tests that {name-trunc:N}, an alias of {name|trunc:N}, truncates names by rune count.
+func TestNameTrunc(t *testing.T) {
 	t.Parallel()
-	for _, tt := range testPreProcessNameTruncTestCases {
+	for _, tt := range testNameTruncTestCases {
 		t.Run(tt.template+"/"+tt.name, func(t *testing.T) {
 			t.Parallel()
-			assert.Equal(t, tt.want, preProcessNameTrunc(tt.template, tt.name))
+			path := filepath.Join(t.TempDir(), tt.name+".txt")
+			require.NoError(t, os.WriteFile(path, nil, 0o644))
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ResolveGroupBy(tt.template, &TokenContext{Info: info, SourcePath: path}))
 		})
 	}
 }
@@ -0,0 +1,39 @@
+package tokens
+
+import (
+	"strings"
+
+	"github.com/lucasassuncao/movelooper/internal/namedate"
+)
+
+// nameDateTokens are the tokens preProcessNameDate resolves.
+var nameDateTokens = []string{"{name-year}", "{name-month}", "{name-day}", "{name-date}"}
+
+func hasNameDateToken(template string) bool {
+	for _, tok := range nameDateTokens {
+		if strings.Contains(template, tok) {
+			return true
+		}
+	}
+	return false
+}
+
+// preProcessNameDate resolves the tokens of the date written in the file
+// name, found by ctx.NameDatePatterns and then the built-in patterns. The date
+// is used as written, with no timezone conversion; a name without a date
+// falls back to the modification time.
+func preProcessNameDate(template string, ctx *TokenContext) string {
+	if !hasNameDateToken(template) {
+		return template
+	}
+	date, ok := namedate.Parse(ctx.Info.Name(), ctx.NameDatePatterns)
+	if !ok {
+		date = inZone(ctx.Info.ModTime())
+	}
+	return strings.NewReplacer(
+		"{name-year}", date.Format("2006"),
+		"{name-month}", date.Format("01"),
+		"{name-day}", date.Format("02"),
+		"{name-date}", date.Format("2006-01-02"),
+	).Replace(template)
+}
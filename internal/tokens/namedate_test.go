@@ -0,0 +1,44 @@
+package tokens
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveGroupBy_NameDate(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	mtime := time.Date(2025, 2, 3, 10, 0, 0, 0, time.Local)
+	stat := func(name string) os.FileInfo {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte("x"), 0o644))
+		require.NoError(t, os.Chtimes(path, mtime, mtime))
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		return info
+	}
+
+	ctx := &TokenContext{Info: stat("Screenshot 2024-03-15 at 10.15.00.png"), Now: time.Now()}
+	assert.Equal(t, filepath.FromSlash("2024/03/15"), ResolveGroupBy("{name-year}/{name-month}/{name-day}", ctx))
+	assert.Equal(t, "2024-03-15_Screenshot 2024-03-15 at 10.15.00.png", ResolveRename("{name-date}_{name}.{ext}", ctx))
+
+	ctx = &TokenContext{Info: stat("notes.txt"), Now: time.Now()}
+	assert.Equal(t, "2025-02-03", ResolveGroupBy("{name-date}", ctx), "a name without a date falls back to mtime")
+
+	ctx = &TokenContext{Info: stat("Rechnung 15.03.24.pdf"), Now: time.Now(),
+		NameDatePatterns: []*regexp.Regexp{regexp.MustCompile(`(?P<day>\d{2})\.(?P<month>\d{2})\.(?P<year>\d{2})`)}}
+	assert.Equal(t, "2024-03", ResolveGroupBy("{name-year}-{name-month}", ctx))
+	assert.Equal(t, "20240315", ResolveGroupBy("{name-date|replace:-:}", ctx))
+}
+
+func TestValidateTemplate_NameDate(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, ValidateTemplate("{name-year}/{name-month}/{name-day}/{name-date}"))
+	assert.Error(t, ValidateTemplate("{name-hour}"))
+}
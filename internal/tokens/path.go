@@ -0,0 +1,67 @@
+package tokens
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// parentToken matches {parent:N}, the Nth ancestor directory of the file.
+var parentToken = regexp.MustCompile(`\{parent:(\d+)\}`)
+
+// relDir returns the directory of ctx.SourcePath relative to ctx.SourceRoot,
+// slash-separated, or "" when the file sits in the root itself or outside it.
+func (ctx *TokenContext) relDir() string {
+	if ctx.SourcePath == "" || ctx.SourceRoot == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(filepath.Clean(ctx.SourceRoot), filepath.Dir(ctx.SourcePath))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// depth returns how many directories below ctx.SourceRoot the file sits.
+func (ctx *TokenContext) depth() int {
+	rel := ctx.relDir()
+	if rel == "" {
+		return 0
+	}
+	return strings.Count(rel, "/") + 1
+}
+
+// ancestor returns the name of the file's nth ancestor directory, 1 being
+// the directory that contains it. Ancestors are not limited to the source
+// tree; past the filesystem root the result is "".
+func (ctx *TokenContext) ancestor(n int) string {
+	if ctx.SourcePath == "" {
+		return ""
+	}
+	dir := filepath.Dir(ctx.SourcePath)
+	for range n - 1 {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+	name := filepath.Base(dir)
+	if name == string(filepath.Separator) || name == "." {
+		return ""
+	}
+	return name
+}
+
+// preProcessParent resolves {parent:N}. {parent}, {relpath} and {depth} are
+// static tokens.
+func preProcessParent(template string, ctx *TokenContext) string {
+	if !strings.Contains(template, "{parent:") {
+		return template
+	}
+	return parentToken.ReplaceAllStringFunc(template, func(tok string) string {
+		n, _ := strconv.Atoi(parentToken.FindStringSubmatch(tok)[1])
+		return ctx.ancestor(n)
+	})
+}
@@ -0,0 +1,67 @@
+package tokens
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// treeContext returns a context for a file at rel (slash-separated) below a
+// temporary source root.
+func treeContext(t *testing.T, rel string) *TokenContext {
+	t.Helper()
+	root := filepath.Join(t.TempDir(), "Inbox")
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte("x"), 0o644))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	return &TokenContext{Info: info, Now: time.Now(), SourcePath: path, SourceRoot: root}
+}
+
+func TestResolveGroupBy_PathTokens(t *testing.T) {
+	t.Parallel()
+	ctx := treeContext(t, "clients/acme/2024/invoice.pdf")
+	assert.Equal(t, filepath.FromSlash("clients/acme/2024"), ResolveGroupBy("{relpath}", ctx))
+	assert.Equal(t, "2024", ResolveGroupBy("{parent}", ctx))
+	assert.Equal(t, "2024", ResolveGroupBy("{parent:1}", ctx))
+	assert.Equal(t, filepath.FromSlash("acme/2024"), ResolveGroupBy("{parent:2}/{parent}", ctx))
+	assert.Equal(t, "clients", ResolveGroupBy("{parent:3}", ctx))
+	assert.Equal(t, "Inbox", ResolveGroupBy("{parent:4}", ctx), "ancestors continue above the source root")
+	assert.Equal(t, "3", ResolveGroupBy("{depth}", ctx))
+	assert.Equal(t, "ACME", ResolveGroupBy("{parent:2|upper}", ctx))
+	assert.Equal(t, "clients_acme_2024_invoice.pdf", ResolveRename("{relpath}/{name}.{ext}", ctx),
+		"separators are flattened in rename")
+}
+
+func TestResolveGroupBy_PathTokensAtRoot(t *testing.T) {
+	t.Parallel()
+	ctx := treeContext(t, "invoice.pdf")
+	assert.Empty(t, ResolveGroupBy("{relpath}", ctx))
+	assert.Equal(t, "Inbox", ResolveGroupBy("{parent}", ctx))
+	assert.Equal(t, "0", ResolveGroupBy("{depth}", ctx))
+	assert.Equal(t, filepath.FromSlash("/dst/pdf"), filepath.Join(filepath.FromSlash("/dst"), ResolveGroupBy("{relpath}/{ext}", ctx)),
+		"an empty relpath adds no directory")
+
+	ctx.SourceRoot = ""
+	assert.Empty(t, ResolveGroupBy("{relpath}", ctx), "no root, no relative path")
+}
+
+func TestAncestor_PastRoot(t *testing.T) {
+	t.Parallel()
+	ctx := &TokenContext{SourcePath: filepath.FromSlash("/a/b.txt")}
+	assert.Equal(t, "a", ctx.ancestor(1))
+	assert.Empty(t, ctx.ancestor(2))
+	assert.Empty(t, ctx.ancestor(10))
+}
+
+func TestValidateTemplate_PathTokens(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, ValidateTemplate("{relpath}/{parent}/{parent:3}/{depth}"))
+	assert.ErrorContains(t, ValidateTemplate("{parent:0}"), "between 1 and 32")
+	assert.ErrorContains(t, ValidateTemplate("{parent:x}"), "positive integer")
+}
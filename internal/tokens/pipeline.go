@@ -0,0 +1,260 @@
+package tokens
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// node is one piece of a parsed template: literal text, or a token and the
+// modifiers piped after it.
+type node struct {
+	literal string
+	raw     string     // the token as written, e.g. "{name|slug|trunc:40}"
+	token   string     // the base token, e.g. "{name}"
+	mods    []modifier // applied left to right
+}
+
+// modifier is one "|name:arg:arg" step of a token pipeline.
+type modifier struct {
+	name string
+	args []string
+}
+
+// parsedTemplate is the AST of a template.
+type parsedTemplate struct {
+	nodes []node
+	// piped reports whether any token carries modifiers (written or through
+	// an alias), so templates without any skip the pipeline entirely.
+	piped bool
+}
+
+// modifierSpec describes one modifier: how many arguments it takes, an
+// optional check of those arguments, and the transform itself.
+type modifierSpec struct {
+	args  int
+	check func(args []string) error
+	apply func(s string, args []string) string
+}
+
+// modifiers are the transforms a token pipeline may apply.
+var modifiers = map[string]modifierSpec{
+	"upper":    {apply: func(s string, _ []string) string { return strings.ToUpper(s) }},
+	"lower":    {apply: func(s string, _ []string) string { return strings.ToLower(s) }},
+	"title":    {apply: func(s string, _ []string) string { return titleCase(s) }},
+	"slug":     {apply: func(s string, _ []string) string { return nameSlug(s) }},
+	"snake":    {apply: func(s string, _ []string) string { return nameSnake(s) }},
+	"alpha":    {apply: func(s string, _ []string) string { return nameAlpha(s) }},
+	"ascii":    {apply: func(s string, _ []string) string { return nameASCII(s) }},
+	"initials": {apply: func(s string, _ []string) string { return nameInitials(s) }},
+	"reverse":  {apply: func(s string, _ []string) string { return nameReverse(s) }},
+	"trim":     {apply: func(s string, _ []string) string { return strings.TrimSpace(s) }},
+	"trunc": {args: 1, check: intArg(255), apply: func(s string, args []string) string {
+		n, _ := strconv.Atoi(args[0])
+		if rr := []rune(s); n < len(rr) {
+			return string(rr[:n])
+		}
+		return s
+	}},
+	"pad": {args: 1, check: intArg(20), apply: func(s string, args []string) string {
+		n, _ := strconv.Atoi(args[0])
+		if pad := n - len([]rune(s)); pad > 0 {
+			return strings.Repeat("0", pad) + s
+		}
+		return s
+	}},
+	"replace": {args: 2, check: func(args []string) error {
+		if args[0] == "" {
+			return fmt.Errorf("the text to replace must not be empty")
+		}
+		return nil
+	}, apply: func(s string, args []string) string { return strings.ReplaceAll(s, args[0], args[1]) }},
+}
+
+// intArg checks that a modifier's only argument is an integer in [1, max].
+func intArg(max int) func(args []string) error {
+	return func(args []string) error {
+		if !paramPattern.MatchString(args[0]) {
+			return fmt.Errorf("N must be a positive integer")
+		}
+		if n, _ := strconv.Atoi(args[0]); n < 1 || n > max {
+			return fmt.Errorf("N must be between 1 and %d", max)
+		}
+		return nil
+	}
+}
+
+// tokenAliases are the fixed transform tokens that predate pipelines. Each
+// is parsed as the pipeline it stands for.
+var tokenAliases = map[string]string{
+	"{ext-upper}":     "{ext|upper}",
+	"{ext-lower}":     "{ext|lower}",
+	"{ext-reverse}":   "{ext|reverse}",
+	"{name-slug}":     "{name|slug}",
+	"{name-snake}":    "{name|snake}",
+	"{name-upper}":    "{name|upper}",
+	"{name-lower}":    "{name|lower}",
+	"{name-alpha}":    "{name|alpha}",
+	"{name-ascii}":    "{name|ascii}",
+	"{name-initials}": "{name|initials}",
+	"{name-reverse}":  "{name|reverse}",
+}
+
+// nameTruncAlias matches {name-trunc:N}, an alias of {name|trunc:N}.
+var nameTruncAlias = regexp.MustCompile(`^\{name-trunc:([^|}]*)`)
+
+// parsedTemplates caches the AST of every template parsed so far, so a
+// template validated at load time is not parsed again for each file.
+var parsedTemplates sync.Map
+
+type parseResult struct {
+	tmpl *parsedTemplate
+	err  error
+}
+
+// parseTemplate returns the AST of template, from the cache when it was
+// parsed before. The error reports malformed pipelines and unknown or
+// misused modifiers; base tokens are checked by ValidateTemplate.
+func parseTemplate(template string) (*parsedTemplate, error) {
+	if v, ok := parsedTemplates.Load(template); ok {
+		r := v.(parseResult)
+		return r.tmpl, r.err
+	}
+	tmpl, err := parse(template)
+	parsedTemplates.Store(template, parseResult{tmpl, err})
+	return tmpl, err
+}
+
+func parse(template string) (*parsedTemplate, error) {
+	tmpl := &parsedTemplate{}
+	last := 0
+	for _, loc := range tokenPattern.FindAllStringIndex(template, -1) {
+		if loc[0] > last {
+			tmpl.nodes = append(tmpl.nodes, node{literal: template[last:loc[0]]})
+		}
+		last = loc[1]
+		n, err := parseToken(template[loc[0]:loc[1]])
+		if err != nil {
+			return nil, err
+		}
+		tmpl.piped = tmpl.piped || len(n.mods) > 0
+		tmpl.nodes = append(tmpl.nodes, n)
+	}
+	if last < len(template) {
+		tmpl.nodes = append(tmpl.nodes, node{literal: template[last:]})
+	}
+	return tmpl, nil
+}
+
+// parseToken parses one "{base|mod|mod:arg}" token, expanding aliases.
+func parseToken(raw string) (node, error) {
+	expanded := raw
+	base, pipes, _ := strings.Cut(raw[1:len(raw)-1], "|")
+	if alias, ok := tokenAliases["{"+base+"}"]; ok {
+		expanded = strings.TrimSuffix(alias, "}") + strings.TrimPrefix(raw, "{"+base)
+	} else if m := nameTruncAlias.FindStringSubmatch(raw); m != nil {
+		expanded = "{name|trunc:" + m[1] + strings.TrimPrefix(raw, m[0])
+	}
+	if expanded != raw {
+		base, pipes, _ = strings.Cut(expanded[1:len(expanded)-1], "|")
+	}
+	if strings.TrimSpace(base) == "" {
+		return node{}, fmt.Errorf("token %q: missing token before |", raw)
+	}
+	n := node{raw: raw, token: "{" + base + "}"}
+	if !strings.Contains(expanded, "|") {
+		return n, nil
+	}
+	for step := range strings.SplitSeq(pipes, "|") {
+		name, rest, hasArgs := strings.Cut(step, ":")
+		spec, ok := modifiers[name]
+		if !ok {
+			if name == "" {
+				return node{}, fmt.Errorf("token %q: empty modifier", raw)
+			}
+			return node{}, fmt.Errorf("token %q: unknown modifier %q", raw, name)
+		}
+		var args []string
+		if hasArgs {
+			args = strings.SplitN(rest, ":", max(spec.args, 1))
+		}
+		if len(args) != spec.args {
+			return node{}, fmt.Errorf("token %q: modifier %q takes %d argument(s), got %d", raw, name, spec.args, len(args))
+		}
+		if spec.check != nil {
+			if err := spec.check(args); err != nil {
+				return node{}, fmt.Errorf("token %q: modifier %q: %w", raw, name, err)
+			}
+		}
+		n.mods = append(n.mods, modifier{name: name, args: args})
+	}
+	return n, nil
+}
+
+// apply runs the node's modifiers over value.
+func (n node) apply(value string) string {
+	for _, m := range n.mods {
+		value = modifiers[m.name].apply(value, m.args)
+	}
+	return value
+}
+
+// pipeMark delimits the placeholder a piped token is replaced with while the
+// rest of the template resolves; NUL cannot occur in a template or a path.
+const pipeMark = "\x00"
+
+// resolvePipes resolves every token of template that carries modifiers:
+// resolve gives the value of its base token, then the modifiers run. The
+// returned template has each such token swapped for a placeholder, so the
+// usual resolution of the remaining tokens cannot touch the values, and fill
+// puts the values back once that is done. A base token resolve leaves as is
+// (seq and hash in dry-run) keeps the whole token literal. Templates without
+// modifiers are returned unchanged.
+func resolvePipes(template string, resolve func(token string) string) (string, func(string) string) {
+	tmpl, err := parseTemplate(template)
+	if err != nil || !tmpl.piped {
+		return template, func(s string) string { return s }
+	}
+	var b strings.Builder
+	var pairs []string
+	for _, n := range tmpl.nodes {
+		switch {
+		case n.token == "":
+			b.WriteString(n.literal)
+		case len(n.mods) == 0:
+			b.WriteString(n.raw)
+		default:
+			value := resolve(n.token)
+			if value == n.token {
+				value = n.raw
+			} else {
+				value = n.apply(value)
+			}
+			mark := pipeMark + strconv.Itoa(len(pairs)/2) + pipeMark
+			pairs = append(pairs, mark, value)
+			b.WriteString(mark)
+		}
+	}
+	fill := strings.NewReplacer(pairs...)
+	return b.String(), fill.Replace
+}
+
+// titleCase upper-cases the first letter of every word and lower-cases the
+// rest.
+func titleCase(s string) string {
+	start := true
+	return strings.Map(func(r rune) rune {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			start = true
+			return r
+		}
+		if start {
+			start = false
+			return unicode.ToUpper(r)
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
@@ -0,0 +1,133 @@
+package tokens
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pipelineContext(t *testing.T, name string) *TokenContext {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte("%PDF-1.5\n"), 0o644))
+	mtime := time.Date(2025, 3, 9, 8, 0, 0, 0, time.Local)
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	return &TokenContext{Info: info, CategoryName: "Invoices", Now: mtime, SourcePath: path}
+}
+
+func TestResolveGroupBy_Pipelines(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		template string
+		want     string
+	}{
+		{"{name|slug|trunc:9}", "quarterly"},
+		{"{name|slug|trunc:12}", "quarterly-re"},
+		{"{name|trunc:12|slug}", "quarterly-re"},
+		{"{category|upper}", "INVOICES"},
+		{"{category|lower|reverse}", "seciovni"},
+		{"{mod-date|replace:-:_}", "2025_03_09"},
+		{"{mod-date|replace:-:}", "20250309"},
+		{"{mime-type|upper}", "APPLICATION"},
+		{"{name|initials|upper}", "QR–É2"},
+		{"{ext|pad:5}", "00pdf"},
+		{"{name|title}", "Quarterly Report – Été 2025"},
+		{"{name-slug|upper}", "QUARTERLY-REPORT-ETE-2025"},
+		{"{mod-year}/{name|snake}.{ext}", filepath.FromSlash("2025/quarterly_report_ete_2025.pdf")},
+	}
+	for _, tt := range cases {
+		ctx := pipelineContext(t, "Quarterly report – été 2025.pdf")
+		assert.Equal(t, tt.want, ResolveGroupBy(tt.template, ctx), tt.template)
+	}
+}
+
+// TestAliasesMatchPipelines verifies that each fixed transform token resolves
+// exactly like the pipeline it is an alias of.
+func TestAliasesMatchPipelines(t *testing.T) {
+	t.Parallel()
+	ctx := pipelineContext(t, "Mÿ Fïle_name-2024.PDF")
+	for alias, pipeline := range tokenAliases {
+		assert.Equal(t, ResolveGroupBy(pipeline, ctx), ResolveGroupBy(alias, ctx), alias)
+	}
+	assert.Equal(t, ResolveGroupBy("{name|trunc:4}", ctx), ResolveGroupBy("{name-trunc:4}", ctx))
+	assert.Equal(t, "PDF", ResolveGroupBy("{ext-upper}", ctx))
+}
+
+// TestResolvePipes_ValuesAreNotReexpanded verifies that a value produced by a
+// pipeline is not read as a template again.
+func TestResolvePipes_ValuesAreNotReexpanded(t *testing.T) {
+	t.Parallel()
+	ctx := pipelineContext(t, "a{ext}b.pdf")
+	assert.Equal(t, "a{ext}b-pdf", ResolveGroupBy("{name|trim}-{ext}", ctx))
+}
+
+func TestResolveRename_Pipelines(t *testing.T) {
+	t.Parallel()
+	ctx := pipelineContext(t, "Report.pdf")
+	ctx.DestDir = t.TempDir()
+	assert.Equal(t, "REPORT_xx1.pdf", ResolveRename("{name|upper}_{seq:3|replace:0:x}.{ext}", ctx))
+	assert.Equal(t, "2025-03", ResolveRename("{mod-date|trunc:7}", ctx))
+
+	ctx = pipelineContext(t, "Report.pdf")
+	ctx.DryRun = true
+	assert.Equal(t, "{seq:3|upper}_report", ResolveRename("{seq:3|upper}_{name|lower}", ctx),
+		"rename-only tokens stay literal in dry-run, modifiers included")
+}
+
+func TestValidateTemplate_Pipelines(t *testing.T) {
+	t.Parallel()
+	for _, ok := range []string{
+		"{name|slug|trunc:40}",
+		"{category|upper}",
+		"{mod-date|replace:-:_}",
+		"{seq:3|pad:5}",
+		"{name-slug|upper}",
+		"{name-trunc:10|upper}",
+		"{artist|ascii|snake}",
+	} {
+		assert.NoError(t, ValidateTemplate(ok), ok)
+	}
+	for tmpl, msg := range map[string]string{
+		"{name|shout}":       `unknown modifier "shout"`,
+		"{name||upper}":      "empty modifier",
+		"{|upper}":           "missing token",
+		"{nope|upper}":       `unknown token "{nope}"`,
+		"{name|trunc}":       "takes 1 argument(s), got 0",
+		"{name|trunc:0}":     "between 1 and 255",
+		"{name|trunc:x}":     "positive integer",
+		"{name|upper:x}":     "takes 0 argument(s), got 1",
+		"{name|replace:-}":   "takes 2 argument(s), got 1",
+		"{name|replace::_}":  "must not be empty",
+		"{name-trunc:0}":     "between 1 and 255",
+		"{name-trunc:x|pad}": "positive integer",
+	} {
+		assert.ErrorContains(t, ValidateTemplate(tmpl), msg, tmpl)
+	}
+}
+
+func TestParseTemplate_Cached(t *testing.T) {
+	t.Parallel()
+	first, err := parseTemplate("{name|upper}/{ext}")
+	require.NoError(t, err)
+	second, err := parseTemplate("{name|upper}/{ext}")
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+	assert.True(t, first.piped)
+	require.Len(t, first.nodes, 3)
+	assert.Equal(t, "{name}", first.nodes[0].token)
+	assert.Equal(t, []modifier{{name: "upper"}}, first.nodes[0].mods)
+	assert.Equal(t, "/", first.nodes[1].literal)
+}
+
+func TestRenameOnlyToken_Pipelines(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "{seq:3|pad:5}", RenameOnlyToken("{ext}/{seq:3|pad:5}"))
+	assert.Equal(t, "{sha256:8|upper}", RenameOnlyToken("{sha256:8|upper}"))
+	assert.Empty(t, RenameOnlyToken("{name|upper}"))
+}
@@ -3,7 +3,6 @@ package tokens
 import (
 	"os"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -11,12 +10,15 @@ import (
 	"github.com/lucasassuncao/movelooper/internal/content"
 )
 
-// buildStaticPairs returns key-value pairs for strings.NewReplacer covering all static tokens.
+// buildStaticPairs returns key-value pairs for strings.NewReplacer covering all
+// static tokens. The transform aliases ({name-slug}, {ext-upper}, ...) are not
+// among them: they resolve as the pipelines they stand for.
 func buildStaticPairs(ctx *TokenContext) []string {
 	initSystemContext()
 
-	modTime := ctx.Info.ModTime()
-	createdTime := getBirthTime(ctx.Info)
+	modTime := inZone(ctx.Info.ModTime())
+	createdTime := inZone(BirthTime(ctx.Info))
+	now := inZone(ctx.Now)
 	rawExt := strings.TrimPrefix(filepath.Ext(ctx.Info.Name()), ".")
 	name := strings.TrimSuffix(ctx.Info.Name(), filepath.Ext(ctx.Info.Name()))
 
@@ -24,18 +26,6 @@ func buildStaticPairs(ctx *TokenContext) []string {
 		// identification
 		"{name}", name,
 		"{ext}", strings.ToLower(rawExt),
-		"{ext-upper}", strings.ToUpper(rawExt),
-		"{ext-lower}", strings.ToLower(rawExt),
-		"{ext-reverse}", reverseString(strings.ToLower(rawExt)),
-		// name transforms
-		"{name-slug}", nameSlug(name),
-		"{name-snake}", nameSnake(name),
-		"{name-upper}", strings.ToUpper(name),
-		"{name-lower}", strings.ToLower(name),
-		"{name-alpha}", nameAlpha(name),
-		"{name-ascii}", nameASCII(name),
-		"{name-initials}", nameInitials(name),
-		"{name-reverse}", nameReverse(name),
 		// modification date
 		"{mod-year}", modTime.Format("2006"),
 		"{mod-month}", modTime.Format("01"),
@@ -48,16 +38,20 @@ func buildStaticPairs(ctx *TokenContext) []string {
 		"{created-day}", createdTime.Format("02"),
 		"{created-date}", createdTime.Format("2006-01-02"),
 		// run date
-		"{year}", ctx.Now.Format("2006"),
-		"{month}", ctx.Now.Format("01"),
-		"{day}", ctx.Now.Format("02"),
-		"{date}", ctx.Now.Format("2006-01-02"),
-		"{weekday}", ctx.Now.Weekday().String(),
+		"{year}", now.Format("2006"),
+		"{month}", now.Format("01"),
+		"{day}", now.Format("02"),
+		"{date}", now.Format("2006-01-02"),
+		"{weekday}", now.Weekday().String(),
 		// run time
-		"{hour}", ctx.Now.Format("15"),
-		"{minute}", ctx.Now.Format("04"),
-		"{second}", ctx.Now.Format("05"),
-		"{timestamp}", ctx.Now.Format("20060102-150405"),
+		"{hour}", now.Format("15"),
+		"{minute}", now.Format("04"),
+		"{second}", now.Format("05"),
+		"{timestamp}", now.Format("20060102-150405"),
+		// source tree
+		"{relpath}", ctx.relDir(),
+		"{parent}", ctx.ancestor(1),
+		"{depth}", strconv.Itoa(ctx.depth()),
 		// size
 		"{size-range}", fileSizeRange(ctx.Info.Size()),
 		// category
@@ -69,20 +63,6 @@ func buildStaticPairs(ctx *TokenContext) []string {
 	}
 }
 
-var nameTruncToken = regexp.MustCompile(`\{name-trunc:(\d+)\}`)
-
-func preProcessNameTrunc(template, name string) string {
-	return nameTruncToken.ReplaceAllStringFunc(template, func(tok string) string {
-		m := nameTruncToken.FindStringSubmatch(tok)
-		n, _ := strconv.Atoi(m[1])
-		rr := []rune(name)
-		if n >= len(rr) {
-			return name
-		}
-		return string(rr[:n])
-	})
-}
-
 // staticReplacer lazily builds and caches the strings.Replacer for static tokens.
 func (ctx *TokenContext) staticReplacer() *strings.Replacer {
 	if ctx.replacer == nil {
@@ -92,15 +72,29 @@ func (ctx *TokenContext) staticReplacer() *strings.Replacer {
 }
 
 // ResolveGroupBy resolves a group-by template string into a relative subdirectory
-// path that should be appended to the category destination.
+// path that should be appended to the category destination. Tokens may carry
+// modifiers, as in {name|slug|trunc:40}.
 func ResolveGroupBy(template string, ctx *TokenContext) string {
 	if template == "" {
 		return ""
 	}
-	name := strings.TrimSuffix(ctx.Info.Name(), filepath.Ext(ctx.Info.Name()))
-	template = preProcessNameTrunc(template, name)
-	template = preProcessMime(template, ctx.SourcePath)
-	return filepath.FromSlash(ctx.staticReplacer().Replace(template))
+	template, fill := resolvePipes(template, ctx.resolveTokens)
+	return filepath.FromSlash(fill(ctx.resolveTokens(template)))
+}
+
+// resolveTokens resolves the tokens ResolveGroupBy supports, without
+// modifiers and without converting separators.
+func (ctx *TokenContext) resolveTokens(template string) string {
+	template = preProcessDateFormats(template, ctx)
+	template = preProcessParent(template, ctx)
+	template = preProcessMime(template, ctx)
+	template = preProcessExif(template, ctx)
+	template = preProcessTags(template, ctx)
+	template = preProcessVideo(template, ctx)
+	template = preProcessNameDate(template, ctx)
+	// Captures are substituted last: they are file name text, and any
+	// "{token}" inside them must stay as written.
+	return preProcessMatch(ctx.staticReplacer().Replace(template), ctx)
 }
 
 // preProcessMime resolves the {mime}, {mime-type}, and {mime-ext} tokens by
@@ -108,13 +102,17 @@ func ResolveGroupBy(template string, ctx *TokenContext) string {
 // mime token, so files are only read when MIME is actually used. Detection
 // errors fall back to application/octet-stream. Unlike seq/hash, MIME resolves
 // in dry-run too: it is read-only and the preview value is showing the real
-// destination.
-func preProcessMime(template, sourcePath string) string {
+// destination. The detected type is cached in ctx.
+func preProcessMime(template string, ctx *TokenContext) string {
 	if !strings.Contains(template, "{mime") {
 		return template
 	}
+	if ctx.mime == nil {
+		info, _ := content.Detect(ctx.SourcePath)
+		ctx.mime = &info
+	}
 	full, top, ext := "application/octet-stream", "application", "bin"
-	if info, err := content.Detect(sourcePath); err == nil {
+	if info := *ctx.mime; info.Full != "" {
 		full = info.Full
 		if info.Type != "" {
 			top = info.Type
@@ -132,15 +130,26 @@ func preProcessMime(template, sourcePath string) string {
 }
 
 // ResolveRename applies a rename template to produce a destination filename.
-// It supports the same tokens as ResolveGroupBy, plus {seq}, {seq:N}, {seq-alpha},
-// {seq-roman}, {md5}, {md5:N}, and {sha256:N}.
+// It supports the same tokens and modifiers as ResolveGroupBy, plus {seq},
+// {seq:N}, {seq-alpha}, {seq-roman}, {md5}, {md5:N}, and {sha256:N}.
 // When template is empty, the original filename is returned unchanged.
 // Path separators are stripped from the result so the output is always a plain filename.
 func ResolveRename(template string, ctx *TokenContext) string {
 	if template == "" {
 		return ctx.Info.Name()
 	}
+	template, fill := resolvePipes(template, func(tok string) string {
+		return ctx.resolveTokens(ctx.resolveRenameOnly(tok))
+	})
+	resolved := fill(ResolveGroupBy(ctx.resolveRenameOnly(template), ctx))
+	resolved = strings.ReplaceAll(resolved, string(os.PathSeparator), "_")
+	resolved = strings.ReplaceAll(resolved, "/", "_")
+	return resolved
+}
 
+// resolveRenameOnly resolves the sequence and hash tokens only ResolveRename
+// supports.
+func (ctx *TokenContext) resolveRenameOnly(template string) string {
 	// In dry-run the seq and hash tokens are left literal as placeholders: they
 	// must not read the source file (hashing) or scan the destination directory
 	// (which does not exist yet), keeping the preview strictly non-mutating.
@@ -158,11 +167,7 @@ func ResolveRename(template string, ctx *TokenContext) string {
 			template = preProcessSeq(template, ctx.DestDir, ctx.SeqAlloc)
 		}
 	}
-
-	resolved := ResolveGroupBy(template, ctx)
-	resolved = strings.ReplaceAll(resolved, string(os.PathSeparator), "_")
-	resolved = strings.ReplaceAll(resolved, "/", "_")
-	return resolved
+	return template
 }
 
 // ResolveArchiveName resolves an archive filename template using only tokens
@@ -176,7 +181,8 @@ func ResolveArchiveName(template, category string, now time.Time) string {
 		return category
 	}
 	initSystemContext()
-	resolved := strings.NewReplacer(archiveNamePairs(category, now)...).Replace(template)
+	now = inZone(now)
+	resolved := strings.NewReplacer(archiveNamePairs(category, now)...).Replace(preProcessNowFormats(template, now))
 	resolved = strings.ReplaceAll(resolved, string(os.PathSeparator), "_")
 	resolved = strings.ReplaceAll(resolved, "/", "_")
 	return resolved
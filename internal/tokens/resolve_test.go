@@ -166,7 +166,7 @@ func TestResolveGroupBy(t *testing.T) {
 	plain := newFile("my-report.PDF", 1, modTime)
 	tiny := newFile("tiny.bin", 500, modTime)
 	mid := newFile("mid.bin", 10*1024*1024, modTime)
-	createdTime := getBirthTime(plain)
+	createdTime := BirthTime(plain)
 	initSystemContext()
 
 	for _, tt := range testResolveGroupByTestCases(plain, tiny, mid, createdTime, now, modTime) {
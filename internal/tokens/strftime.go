@@ -0,0 +1,215 @@
+package tokens
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locales are the accepted configuration.locale values, which set the
+// language of the month and weekday names in {mod:FORMAT}, {created:FORMAT}
+// and {now:FORMAT}.
+var Locales = []string{"en", "de", "es", "fr", "it", "nl", "pt"}
+
+// localeNames holds the month and weekday names of one locale. Weekdays start
+// on Sunday, like time.Weekday.
+type localeNames struct {
+	months, monthsAbbr [12]string
+	days, daysAbbr     [7]string
+}
+
+var localeTable = map[string]localeNames{
+	"en": {
+		months:     [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		monthsAbbr: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		days:       [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		daysAbbr:   [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	},
+	"de": {
+		months:     [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		monthsAbbr: [12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+		days:       [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		daysAbbr:   [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+	},
+	"es": {
+		months:     [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		monthsAbbr: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sep", "oct", "nov", "dic"},
+		days:       [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		daysAbbr:   [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+	},
+	"fr": {
+		months:     [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		monthsAbbr: [12]string{"janv", "févr", "mars", "avr", "mai", "juin", "juil", "août", "sept", "oct", "nov", "déc"},
+		days:       [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		daysAbbr:   [7]string{"dim", "lun", "mar", "mer", "jeu", "ven", "sam"},
+	},
+	"it": {
+		months:     [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		monthsAbbr: [12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		days:       [7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		daysAbbr:   [7]string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+	},
+	"nl": {
+		months:     [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		monthsAbbr: [12]string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		days:       [7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		daysAbbr:   [7]string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+	},
+	"pt": {
+		months:     [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		monthsAbbr: [12]string{"jan", "fev", "mar", "abr", "mai", "jun", "jul", "ago", "set", "out", "nov", "dez"},
+		days:       [7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+		daysAbbr:   [7]string{"dom", "seg", "ter", "qua", "qui", "sex", "sáb"},
+	},
+}
+
+// timezone and locale are the process-wide date settings from
+// configuration.timezone and configuration.locale. They are set once while
+// the configuration loads, before any file is processed.
+var (
+	timezone *time.Location // nil keeps each time in the zone it came in, the local zone for file times
+	locale   = "en"
+)
+
+// SetTimezone makes every date token render in loc, so organize-by gives the
+// same folders on machines in different zones. nil restores the local zone.
+func SetTimezone(loc *time.Location) {
+	timezone = loc
+}
+
+// SetLocale sets the language of the month and weekday names of the
+// {mod:FORMAT}, {created:FORMAT} and {now:FORMAT} tokens. A name not in
+// Locales selects English.
+func SetLocale(name string) {
+	if _, ok := localeTable[name]; !ok {
+		name = "en"
+	}
+	locale = name
+}
+
+// inZone converts t to the configured timezone, if any.
+func inZone(t time.Time) time.Time {
+	if timezone == nil {
+		return t
+	}
+	return t.In(timezone)
+}
+
+// dateFormatToken matches {mod:FORMAT}, {created:FORMAT} and {now:FORMAT}.
+var dateFormatToken = regexp.MustCompile(`\{(mod|created|now):([^}|]*)\}`)
+
+// strftimeVerbs renders each supported %-directive.
+var strftimeVerbs = map[byte]func(t time.Time, names localeNames) string{
+	'Y': func(t time.Time, _ localeNames) string { return strconv.Itoa(t.Year()) },
+	'y': func(t time.Time, _ localeNames) string { return t.Format("06") },
+	'm': func(t time.Time, _ localeNames) string { return t.Format("01") },
+	'd': func(t time.Time, _ localeNames) string { return t.Format("02") },
+	'j': func(t time.Time, _ localeNames) string { return fmt.Sprintf("%03d", t.YearDay()) },
+	'H': func(t time.Time, _ localeNames) string { return t.Format("15") },
+	'I': func(t time.Time, _ localeNames) string { return t.Format("03") },
+	'p': func(t time.Time, _ localeNames) string { return t.Format("PM") },
+	'M': func(t time.Time, _ localeNames) string { return t.Format("04") },
+	'S': func(t time.Time, _ localeNames) string { return t.Format("05") },
+	'B': func(t time.Time, n localeNames) string { return n.months[t.Month()-1] },
+	'b': func(t time.Time, n localeNames) string { return n.monthsAbbr[t.Month()-1] },
+	'A': func(t time.Time, n localeNames) string { return n.days[t.Weekday()] },
+	'a': func(t time.Time, n localeNames) string { return n.daysAbbr[t.Weekday()] },
+	'u': func(t time.Time, _ localeNames) string { return strconv.Itoa((int(t.Weekday())+6)%7 + 1) },
+	'w': func(t time.Time, _ localeNames) string { return strconv.Itoa(int(t.Weekday())) },
+	'V': func(t time.Time, _ localeNames) string { _, w := t.ISOWeek(); return fmt.Sprintf("%02d", w) },
+	'G': func(t time.Time, _ localeNames) string { y, _ := t.ISOWeek(); return strconv.Itoa(y) },
+	'q': func(t time.Time, _ localeNames) string { return strconv.Itoa((int(t.Month())-1)/3 + 1) },
+	'F': func(t time.Time, _ localeNames) string { return t.Format("2006-01-02") },
+	's': func(t time.Time, _ localeNames) string { return strconv.FormatInt(t.Unix(), 10) },
+	'z': func(t time.Time, _ localeNames) string { return t.Format("-0700") },
+	'Z': func(t time.Time, _ localeNames) string { return t.Format("MST") },
+	'%': func(time.Time, localeNames) string { return "%" },
+}
+
+// strftime formats t after format, a strftime-style layout such as
+// "%Y/%m - %B". Directives checkStrftime rejects are written as is.
+func strftime(t time.Time, format string, names localeNames) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			b.WriteByte(format[i])
+			continue
+		}
+		verb, ok := strftimeVerbs[format[i+1]]
+		if !ok {
+			b.WriteString(format[i : i+2])
+		} else {
+			b.WriteString(verb(t, names))
+		}
+		i++
+	}
+	return b.String()
+}
+
+// checkStrftime reports a format that is empty, ends in a lone % or uses a
+// directive strftime does not support.
+func checkStrftime(format string) error {
+	if format == "" {
+		return fmt.Errorf("missing format")
+	}
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		if i+1 == len(format) {
+			return fmt.Errorf("format ends with a lone %%")
+		}
+		if _, ok := strftimeVerbs[format[i+1]]; !ok {
+			return fmt.Errorf("unsupported directive %%%c", format[i+1])
+		}
+		i++
+	}
+	return nil
+}
+
+// validateDateFormatToken checks a {mod:FORMAT}, {created:FORMAT} or
+// {now:FORMAT} token.
+func validateDateFormatToken(tok string) error {
+	m := dateFormatToken.FindStringSubmatch(tok)
+	if m == nil || m[0] != tok {
+		return fmt.Errorf("unknown token %q in template", tok)
+	}
+	if err := checkStrftime(m[2]); err != nil {
+		return fmt.Errorf("token %q: %w", tok, err)
+	}
+	return nil
+}
+
+// preProcessDateFormats resolves the {mod:FORMAT}, {created:FORMAT} and
+// {now:FORMAT} tokens in the configured timezone and locale.
+func preProcessDateFormats(template string, ctx *TokenContext) string {
+	if !dateFormatToken.MatchString(template) {
+		return template
+	}
+	return dateFormatToken.ReplaceAllStringFunc(template, func(tok string) string {
+		m := dateFormatToken.FindStringSubmatch(tok)
+		var t time.Time
+		switch m[1] {
+		case "mod":
+			t = ctx.Info.ModTime()
+		case "created":
+			t = BirthTime(ctx.Info)
+		default:
+			t = ctx.Now
+		}
+		return strftime(inZone(t), m[2], localeTable[locale])
+	})
+}
+
+// preProcessNowFormats resolves {now:FORMAT} alone, for archive names.
+func preProcessNowFormats(template string, now time.Time) string {
+	return dateFormatToken.ReplaceAllStringFunc(template, func(tok string) string {
+		m := dateFormatToken.FindStringSubmatch(tok)
+		if m[1] != "now" {
+			return tok
+		}
+		return strftime(inZone(now), m[2], localeTable[locale])
+	})
+}
@@ -0,0 +1,121 @@
+package tokens
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrftime(t *testing.T) {
+	t.Parallel()
+	// A Monday in ISO week 1 of 2025.
+	ts := time.Date(2024, 12, 30, 15, 4, 5, 0, time.UTC)
+	cases := []struct {
+		format, locale, want string
+	}{
+		{"%Y/%m - %B", "en", "2024/12 - December"},
+		{"%Y/%m - %B", "fr", "2024/12 - décembre"},
+		{"%d %b %y", "nl", "30 dec 24"},
+		{"%A, %a", "de", "Montag, Mo"},
+		{"%G-W%V-%u", "en", "2025-W01-1"},
+		{"%Y-Q%q", "en", "2024-Q4"},
+		{"%j %w", "en", "365 1"},
+		{"%I.%M.%S %p", "en", "03.04.05 PM"},
+		{"%H%M%S", "en", "150405"},
+		{"%F", "en", "2024-12-30"},
+		{"%s", "en", "1735571045"},
+		{"%z %Z", "en", "+0000 UTC"},
+		{"100%%", "en", "100%"},
+		{"%A", "pt", "segunda-feira"},
+	}
+	for _, tt := range cases {
+		assert.Equal(t, tt.want, strftime(ts, tt.format, localeTable[tt.locale]), "%s (%s)", tt.format, tt.locale)
+	}
+	assert.Equal(t, "2024-Q1", strftime(time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), "%Y-Q%q", localeTable["en"]))
+	assert.Equal(t, "2020-W53", strftime(time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC), "%G-W%V", localeTable["en"]))
+}
+
+func TestLocaleTable(t *testing.T) {
+	t.Parallel()
+	assert.Len(t, localeTable, len(Locales))
+	for _, name := range Locales {
+		names, ok := localeTable[name]
+		if assert.True(t, ok, name) {
+			for i := range 12 {
+				assert.NotEmpty(t, names.months[i], "%s month %d", name, i+1)
+				assert.NotEmpty(t, names.monthsAbbr[i], "%s month %d", name, i+1)
+			}
+			for i := range 7 {
+				assert.NotEmpty(t, names.days[i], "%s day %d", name, i)
+				assert.NotEmpty(t, names.daysAbbr[i], "%s day %d", name, i)
+			}
+		}
+	}
+}
+
+func TestResolveGroupBy_DateFormats(t *testing.T) {
+	t.Parallel()
+	ctx := pipelineContext(t, "report.pdf")
+	ctx.Now = time.Date(2025, 7, 1, 9, 30, 0, 0, time.Local)
+
+	assert.Equal(t, filepath.FromSlash("2025/03 - March"), ResolveGroupBy("{mod:%Y/%m - %B}", ctx))
+	assert.Equal(t, filepath.FromSlash("2025-W27/report"), ResolveGroupBy("{now:%G-W%V}/{name}", ctx))
+	assert.Equal(t, "MARCH", ResolveGroupBy("{mod:%B|upper}", ctx))
+	assert.Equal(t, "2025_Q3_report.pdf", ResolveRename("{now:%Y/Q%q}_{name}.{ext}", ctx), "separators in a rename are flattened")
+	assert.NotEmpty(t, ResolveGroupBy("{created:%Y}", ctx))
+}
+
+func TestResolveArchiveName_NowFormat(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2025, 11, 2, 8, 0, 0, 0, time.UTC)
+	assert.Equal(t, "docs-2025-Q4-{mod:%Y}", ResolveArchiveName("{category}-{now:%Y-Q%q}-{mod:%Y}", "docs", now),
+		"only {now:FORMAT} applies to archive names")
+}
+
+func TestValidateTemplate_DateFormats(t *testing.T) {
+	t.Parallel()
+	for _, ok := range []string{"{mod:%Y/%m - %B}", "{created:%F}", "{now:%G-W%V}", "{mod:%Y-Q%q|lower}", "{now:fixed}"} {
+		assert.NoError(t, ValidateTemplate(ok), ok)
+	}
+	for tmpl, msg := range map[string]string{
+		"{mod:}":        "missing format",
+		"{mod:%Y%}":     "lone %",
+		"{now:%T}":      "unsupported directive %T",
+		"{modified:%Y}": "unknown token",
+	} {
+		assert.ErrorContains(t, ValidateTemplate(tmpl), msg, tmpl)
+	}
+}
+
+// TestDateSettings changes the process-wide timezone and locale, so it does
+// not run in parallel with the other tests.
+func TestDateSettings(t *testing.T) {
+	t.Cleanup(func() {
+		SetTimezone(nil)
+		SetLocale("")
+	})
+	ctx := pipelineContext(t, "report.pdf")
+	ctx.Now = time.Date(2025, 3, 9, 20, 0, 0, 0, time.UTC)
+
+	SetTimezone(time.FixedZone("UTC+14", 14*60*60))
+	SetLocale("es")
+	assert.Equal(t, filepath.FromSlash("2025-03-10/10 lunes"), ResolveGroupBy("{date}/{now:%H %A}", ctx))
+	assert.Equal(t, "2025-03-10", ResolveArchiveName("{date}", "c", ctx.Now))
+	mtime := ctx.Now
+	require.NoError(t, os.Chtimes(ctx.SourcePath, mtime, mtime))
+	info, err := os.Stat(ctx.SourcePath)
+	require.NoError(t, err)
+	ctx.Info = info
+	assert.Equal(t, "2025-03-10", ResolveGroupBy("{exif-date}", ctx), "the mtime fallback of files without EXIF")
+
+	SetTimezone(nil)
+	SetLocale("xx")
+	ctx = pipelineContext(t, "report.pdf")
+	ctx.Now = time.Date(2025, 3, 9, 20, 0, 0, 0, time.UTC)
+	assert.Equal(t, filepath.FromSlash("2025-03-09/20 Sunday"), ResolveGroupBy("{date}/{now:%H %A}", ctx),
+		"an unknown locale falls back to English")
+}
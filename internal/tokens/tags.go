@@ -0,0 +1,112 @@
+package tokens
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lucasassuncao/movelooper/internal/media"
+)
+
+// defaultTagValues are the values the tag tokens take when the file lacks the
+// tag and TokenContext.TagDefaults does not override it. A missing title uses
+// the file name instead.
+var defaultTagValues = map[string]string{
+	"artist":   "Unknown Artist",
+	"album":    "Unknown Album",
+	"genre":    "Unknown Genre",
+	"tag-year": "Unknown Year",
+	"track":    "0",
+	"disc":     "1",
+}
+
+// TagDefaultKeys are the keys accepted in destination.tag-defaults.
+var TagDefaultKeys = []string{"artist", "album-artist", "album", "title", "genre", "tag-year", "track", "disc"}
+
+// tagTokens are the fixed tokens preProcessTags resolves; {track:N} is
+// matched by trackPadToken.
+var tagTokens = []string{"{artist}", "{album-artist}", "{album}", "{title}", "{genre}", "{tag-year}", "{track}", "{disc}"}
+
+var trackPadToken = regexp.MustCompile(`\{track:(\d+)\}`)
+
+func hasTagToken(template string) bool {
+	for _, tok := range tagTokens {
+		if strings.Contains(template, tok) {
+			return true
+		}
+	}
+	return trackPadToken.MatchString(template)
+}
+
+// audioTags returns the audio tags of ctx.SourcePath, reading the file on
+// first use only. A file without tags gives the zero Tags.
+func (ctx *TokenContext) audioTags() media.Tags {
+	if ctx.tags == nil {
+		t, _ := media.ReadTags(ctx.SourcePath)
+		ctx.tags = &t
+	}
+	return *ctx.tags
+}
+
+// tagDefault returns the value for a missing tag: the configured default,
+// else the built-in one.
+func (ctx *TokenContext) tagDefault(key string) string {
+	if v, ok := ctx.TagDefaults[key]; ok && v != "" {
+		return v
+	}
+	return defaultTagValues[key]
+}
+
+// preProcessTags resolves the audio tag tokens. Values are made safe for use
+// as a path segment, since tags often hold "/" or ":"; missing tags use the
+// defaults. {album-artist} falls back to the artist before its own default,
+// and {track:N} zero-pads to N digits.
+func preProcessTags(template string, ctx *TokenContext) string {
+	if !hasTagToken(template) {
+		return template
+	}
+	t := ctx.audioTags()
+	value := func(v, key string) string {
+		return pathSegment(v, pathSegment(ctx.tagDefault(key), "_"))
+	}
+	artist := value(t.Artist, "artist")
+	albumArtist := t.AlbumArtist
+	if albumArtist == "" {
+		albumArtist = t.Artist
+	}
+	albumArtistValue := artist
+	if albumArtist != "" || ctx.TagDefaults["album-artist"] != "" {
+		albumArtistValue = value(albumArtist, "album-artist")
+	}
+	title := t.Title
+	if title == "" && ctx.TagDefaults["title"] == "" {
+		title = strings.TrimSuffix(ctx.Info.Name(), filepath.Ext(ctx.Info.Name()))
+	}
+	number := func(n int, key string) string {
+		if n > 0 {
+			return strconv.Itoa(n)
+		}
+		return value("", key)
+	}
+	track := number(t.Track, "track")
+
+	template = trackPadToken.ReplaceAllStringFunc(template, func(tok string) string {
+		width, _ := strconv.Atoi(trackPadToken.FindStringSubmatch(tok)[1])
+		if n, err := strconv.Atoi(track); err == nil {
+			return fmt.Sprintf("%0*d", width, n)
+		}
+		return track
+	})
+	return strings.NewReplacer(
+		"{artist}", artist,
+		"{album-artist}", albumArtistValue,
+		"{album}", value(t.Album, "album"),
+		"{title}", value(title, "title"),
+		"{genre}", value(t.Genre, "genre"),
+		"{tag-year}", value(t.Year, "tag-year"),
+		"{track}", track,
+		"{disc}", number(t.Disc, "disc"),
+	).Replace(template)
+}
@@ -0,0 +1,89 @@
+package tokens
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lucasassuncao/movelooper/internal/media"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tagContext(t *testing.T, name string, tags *media.Tags) *TokenContext {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte("not audio"), 0o644))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	return &TokenContext{Info: info, SourcePath: path, tags: tags}
+}
+
+func TestResolveGroupBy_Tags(t *testing.T) {
+	t.Parallel()
+	ctx := tagContext(t, "01.mp3", &media.Tags{
+		Artist: "AC/DC",
+		Album:  "Live: 1992",
+		Title:  "Thunderstruck?",
+		Genre:  "Hard Rock",
+		Year:   "1992",
+		Track:  3,
+		Disc:   2,
+	})
+	assert.Equal(t, filepath.FromSlash("AC-DC/Live_ 1992 (1992)"), ResolveGroupBy("{artist}/{album} ({tag-year})", ctx),
+		"values are made path-safe")
+	assert.Equal(t, "2-03 - Thunderstruck_.mp3", ResolveRename("{disc}-{track:2} - {title}.{ext}", ctx))
+	assert.Equal(t, "AC-DC_Hard Rock_3", ResolveRename("{album-artist}_{genre}_{track}", ctx),
+		"album-artist falls back to the artist")
+}
+
+func TestResolveGroupBy_TagDefaults(t *testing.T) {
+	t.Parallel()
+	ctx := tagContext(t, "track.mp3", nil)
+	assert.Equal(t, filepath.FromSlash("Unknown Artist/Unknown Album/00 - track"), ResolveGroupBy("{artist}/{album}/{track:2} - {title}", ctx),
+		"a file without tags uses the built-in defaults and its name as the title")
+	assert.Equal(t, "Unknown Genre_Unknown Year_1_Unknown Artist", ResolveRename("{genre}_{tag-year}_{disc}_{album-artist}", ctx))
+
+	ctx = tagContext(t, "track.mp3", &media.Tags{Artist: "Nina Simone"})
+	ctx.TagDefaults = map[string]string{"album": "Singles", "album-artist": "Various Artists", "title": "Untitled", "track": "99"}
+	assert.Equal(t, filepath.FromSlash("Nina Simone/Singles/99 - Untitled"), ResolveGroupBy("{album-artist}/{album}/{track:2} - {title}", ctx))
+
+	ctx = tagContext(t, "track.mp3", &media.Tags{})
+	ctx.TagDefaults = map[string]string{"album-artist": "Various Artists", "track": "x"}
+	assert.Equal(t, "Various Artists_x", ResolveRename("{album-artist}_{track:2}", ctx),
+		"a non-numeric track default is not padded")
+}
+
+// TestAudioTags_Cached verifies that a TokenContext reads the file once.
+func TestAudioTags_Cached(t *testing.T) {
+	t.Parallel()
+	ctx := tagContext(t, "a.mp3", nil)
+	_ = ResolveGroupBy("{artist}", ctx)
+	require.NotNil(t, ctx.tags)
+	ctx.tags.Artist = "cached"
+	assert.Equal(t, "cached", ResolveGroupBy("{artist}", ctx))
+}
+
+func TestPathSegment(t *testing.T) {
+	t.Parallel()
+	for in, want := range map[string]string{
+		`AC/DC`:        "AC-DC",
+		`a\b`:          "a-b",
+		`Who? "Me" <3`: `Who_ _Me_ _3`,
+		"tab\there":    "tabhere",
+		"  trailing. ": "trailing",
+		"..":           "fallback",
+		"":             "fallback",
+	} {
+		assert.Equal(t, want, pathSegment(in, "fallback"), in)
+	}
+}
+
+func TestValidateTemplate_Tags(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, ValidateTemplate("{artist}/{album-artist}/{album}/{disc}-{track:2} - {title} ({tag-year}, {genre}).{ext}"))
+	assert.NoError(t, ValidateTemplate("{track}"))
+	assert.Error(t, ValidateTemplate("{track:0}"))
+	assert.Error(t, ValidateTemplate("{track:x}"))
+	assert.Error(t, ValidateTemplate("{composer}"))
+}
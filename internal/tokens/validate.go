@@ -7,23 +7,12 @@ import (
 	"strings"
 )
 
-// knownTokens is the complete set of fixed tokens recognised by both organize-by and rename templates.
+// knownTokens is the complete set of fixed base tokens recognised by both organize-by and rename templates.
 var knownTokens = map[string]bool{
-	// identification
-	"{name}":        true,
-	"{ext}":         true,
-	"{ext-upper}":   true,
-	"{ext-lower}":   true,
-	"{ext-reverse}": true,
-	// name transforms
-	"{name-slug}":     true,
-	"{name-snake}":    true,
-	"{name-upper}":    true,
-	"{name-lower}":    true,
-	"{name-alpha}":    true,
-	"{name-ascii}":    true,
-	"{name-initials}": true,
-	"{name-reverse}":  true,
+	// identification; the transform aliases such as {name-slug} are in
+	// tokenAliases and validate as the pipelines they stand for
+	"{name}": true,
+	"{ext}":  true,
 	// modification date
 	"{mod-year}":    true,
 	"{mod-month}":   true,
@@ -35,6 +24,11 @@ var knownTokens = map[string]bool{
 	"{created-month}": true,
 	"{created-day}":   true,
 	"{created-date}":  true,
+	// date in the file name
+	"{name-year}":  true,
+	"{name-month}": true,
+	"{name-day}":   true,
+	"{name-date}":  true,
 	// run date
 	"{year}":    true,
 	"{month}":   true,
@@ -46,6 +40,10 @@ var knownTokens = map[string]bool{
 	"{minute}":    true,
 	"{second}":    true,
 	"{timestamp}": true,
+	// source tree
+	"{relpath}": true,
+	"{parent}":  true,
+	"{depth}":   true,
 	// size
 	"{size-range}": true,
 	// category
@@ -58,6 +56,29 @@ var knownTokens = map[string]bool{
 	"{mime}":      true,
 	"{mime-type}": true,
 	"{mime-ext}":  true,
+	// photo metadata (EXIF)
+	"{exif-year}":    true,
+	"{exif-month}":   true,
+	"{exif-date}":    true,
+	"{camera-make}":  true,
+	"{camera-model}": true,
+	"{lens}":         true,
+	"{iso}":          true,
+	// video metadata
+	"{video-year}":     true,
+	"{video-month}":    true,
+	"{video-date}":     true,
+	"{duration-range}": true,
+	"{resolution}":     true,
+	// audio tags
+	"{artist}":       true,
+	"{album-artist}": true,
+	"{album}":        true,
+	"{title}":        true,
+	"{genre}":        true,
+	"{tag-year}":     true,
+	"{track}":        true,
+	"{disc}":         true,
 	// advanced sequence (rename only)
 	"{seq-alpha}": true,
 	"{seq-roman}": true,
@@ -70,8 +91,8 @@ var paramPattern = regexp.MustCompile(`^\d+$`)
 
 // renameOnlyPattern matches the sequence and hash tokens that ResolveRename
 // resolves but ResolveGroupBy does not, so they would leak literally into
-// directory names if used in organize-by.
-var renameOnlyPattern = regexp.MustCompile(`\{(?:seq-alpha|seq-roman|seq(?::\d+)?|md5(?::\d+)?|sha256:\d+)\}`)
+// directory names if used in organize-by. Modifiers do not change that.
+var renameOnlyPattern = regexp.MustCompile(`\{(?:seq-alpha|seq-roman|seq(?::\d+)?|md5(?::\d+)?|sha256:\d+)(?:\|[^}]*)?\}`)
 
 // RenameOnlyToken returns the first rename-only token (sequence or hash family)
 // found in template, or "" if there is none. These tokens are resolved only by
@@ -81,33 +102,61 @@ func RenameOnlyToken(template string) string {
 }
 
 // ValidateTemplate returns an error if the template contains any unrecognised
-// or malformed {token}.
+// or malformed {token} or modifier. It parses the template into the AST the
+// resolvers reuse, so each template is parsed once.
 func ValidateTemplate(template string) error {
-	for _, tok := range tokenPattern.FindAllString(template, -1) {
-		switch {
-		case knownTokens[tok]:
-			// known fixed token
-		case tok == "{seq}":
-			// ok
-		case strings.HasPrefix(tok, "{seq:") && strings.HasSuffix(tok, "}"):
-			if err := validateIntParam(tok, "seq", 20); err != nil {
-				return err
-			}
-		case strings.HasPrefix(tok, "{name-trunc:") && strings.HasSuffix(tok, "}"):
-			if err := validateIntParam(tok, "name-trunc", 255); err != nil {
-				return err
-			}
-		case strings.HasPrefix(tok, "{md5:") && strings.HasSuffix(tok, "}"):
-			if err := validateIntParam(tok, "md5", 32); err != nil {
-				return err
-			}
-		case strings.HasPrefix(tok, "{sha256:") && strings.HasSuffix(tok, "}"):
-			if err := validateIntParam(tok, "sha256", 64); err != nil {
-				return err
-			}
-		default:
-			return fmt.Errorf("unknown token %q in template", tok)
+	tmpl, err := parseTemplate(template)
+	if err != nil {
+		return err
+	}
+	for _, n := range tmpl.nodes {
+		if n.token == "" {
+			continue
+		}
+		if err := validateToken(n.token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateToken checks one base token, without modifiers.
+func validateToken(tok string) error {
+	switch {
+	case knownTokens[tok]:
+		// known fixed token
+	case tok == "{seq}":
+		// ok
+	case strings.HasPrefix(tok, "{seq:") && strings.HasSuffix(tok, "}"):
+		if err := validateIntParam(tok, "seq", 20); err != nil {
+			return err
+		}
+	case strings.HasPrefix(tok, "{parent:") && strings.HasSuffix(tok, "}"):
+		if err := validateIntParam(tok, "parent", 32); err != nil {
+			return err
+		}
+	case strings.HasPrefix(tok, "{track:") && strings.HasSuffix(tok, "}"):
+		if err := validateIntParam(tok, "track", 10); err != nil {
+			return err
+		}
+	case strings.HasPrefix(tok, "{mod:") || strings.HasPrefix(tok, "{created:") || strings.HasPrefix(tok, "{now:"):
+		if err := validateDateFormatToken(tok); err != nil {
+			return err
+		}
+	case strings.HasPrefix(tok, "{match:") && strings.HasSuffix(tok, "}"):
+		if err := validateMatchToken(tok); err != nil {
+			return err
+		}
+	case strings.HasPrefix(tok, "{md5:") && strings.HasSuffix(tok, "}"):
+		if err := validateIntParam(tok, "md5", 32); err != nil {
+			return err
+		}
+	case strings.HasPrefix(tok, "{sha256:") && strings.HasSuffix(tok, "}"):
+		if err := validateIntParam(tok, "sha256", 64); err != nil {
+			return err
 		}
+	default:
+		return fmt.Errorf("unknown token %q in template", tok)
 	}
 	return nil
 }
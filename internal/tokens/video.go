@@ -0,0 +1,94 @@
+package tokens
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lucasassuncao/movelooper/internal/media"
+)
+
+const (
+	durationThresholdShort  = time.Minute
+	durationThresholdMedium = 10 * time.Minute
+)
+
+// videoTokens are the tokens preProcessVideo resolves.
+var videoTokens = []string{"{video-year}", "{video-month}", "{video-date}", "{duration-range}", "{resolution}"}
+
+// standardHeights are the resolution labels a video's shorter side is
+// rounded down to.
+var standardHeights = []int{4320, 2160, 1440, 1080, 720, 576, 480, 360, 240}
+
+func hasVideoToken(template string) bool {
+	for _, tok := range videoTokens {
+		if strings.Contains(template, tok) {
+			return true
+		}
+	}
+	return false
+}
+
+// videoMetadata returns the container metadata of ctx.SourcePath, reading
+// the file on first use only. A file that is not a video gives the zero Video.
+func (ctx *TokenContext) videoMetadata() media.Video {
+	if ctx.video == nil {
+		v, _ := media.ReadVideo(ctx.SourcePath)
+		ctx.video = &v
+	}
+	return *ctx.video
+}
+
+// preProcessVideo resolves the video tokens. The date tokens use the recording
+// time the container stores, in local time, and fall back to the modification
+// time; {duration-range} and {resolution} fall back to "unknown".
+func preProcessVideo(template string, ctx *TokenContext) string {
+	if !hasVideoToken(template) {
+		return template
+	}
+	v := ctx.videoMetadata()
+	created := inZone(v.Created.Local())
+	if v.Created.IsZero() {
+		created = inZone(ctx.Info.ModTime())
+	}
+	return strings.NewReplacer(
+		"{video-year}", created.Format("2006"),
+		"{video-month}", created.Format("01"),
+		"{video-date}", created.Format("2006-01-02"),
+		"{duration-range}", durationRange(v.Duration),
+		"{resolution}", resolutionLabel(v.Width, v.Height),
+	).Replace(template)
+}
+
+// durationRange buckets a video length like {size-range} buckets sizes.
+func durationRange(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return unknownMetadata
+	case d < durationThresholdShort:
+		return "short"
+	case d < durationThresholdMedium:
+		return "medium"
+	default:
+		return "long"
+	}
+}
+
+// resolutionLabel names a frame size after its shorter side, rounded down to
+// a standard height with 5% slack (1088 and 1072 are both 1080p), so
+// portrait and landscape videos get the same label.
+func resolutionLabel(width, height int) string {
+	side := height
+	if width > 0 && width < side {
+		side = width
+	}
+	if side <= 0 {
+		return unknownMetadata
+	}
+	for _, h := range standardHeights {
+		if side*100 >= h*95 {
+			return strconv.Itoa(h) + "p"
+		}
+	}
+	return strconv.Itoa(side) + "p"
+}
@@ -0,0 +1,69 @@
+package tokens
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lucasassuncao/movelooper/internal/media"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveGroupBy_Video(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "VID_0001.mp4")
+	require.NoError(t, os.WriteFile(path, []byte("not a video"), 0o644))
+	mtime := time.Date(2025, 2, 3, 10, 0, 0, 0, time.Local)
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	ctx := &TokenContext{Info: info, Now: time.Now(), SourcePath: path}
+	assert.Equal(t, filepath.FromSlash("2025/2025-02-03/unknown_unknown"), ResolveGroupBy("{video-year}/{video-date}/{duration-range}_{resolution}", ctx),
+		"a file that is not a video falls back to mtime and unknown")
+
+	created := time.Date(2024, 8, 3, 12, 0, 0, 0, time.Local)
+	ctx = &TokenContext{Info: info, Now: time.Now(), SourcePath: path}
+	ctx.video = &media.Video{Created: created.UTC(), Duration: 95 * time.Second, Width: 1080, Height: 1920}
+	assert.Equal(t, filepath.FromSlash("2024-08/medium/1080p"), ResolveGroupBy("{video-year}-{video-month}/{duration-range}/{resolution}", ctx))
+	assert.Equal(t, "2024-08-03_VID_0001.mp4", ResolveRename("{video-date}_{name}.{ext}", ctx))
+}
+
+func TestDurationRange(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "unknown", durationRange(0))
+	assert.Equal(t, "short", durationRange(59*time.Second))
+	assert.Equal(t, "medium", durationRange(time.Minute))
+	assert.Equal(t, "medium", durationRange(9*time.Minute))
+	assert.Equal(t, "long", durationRange(10*time.Minute))
+}
+
+func TestResolutionLabel(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		width, height int
+		want          string
+	}{
+		{1920, 1080, "1080p"},
+		{1920, 1088, "1080p"},
+		{1080, 1920, "1080p"},
+		{3840, 2160, "2160p"},
+		{1280, 720, "720p"},
+		{854, 480, "480p"},
+		{720, 576, "576p"},
+		{1024, 768, "720p"},
+		{160, 120, "120p"},
+		{0, 0, "unknown"},
+	}
+	for _, tt := range cases {
+		assert.Equal(t, tt.want, resolutionLabel(tt.width, tt.height), "%dx%d", tt.width, tt.height)
+	}
+}
+
+func TestValidateTemplate_Video(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, ValidateTemplate("{video-year}/{video-month}/{video-date}/{duration-range}/{resolution}"))
+	assert.Error(t, ValidateTemplate("{video-day}"))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	_ "time/tzdata" // configuration.timezone must resolve where the OS has no zoneinfo database (Windows)
 
 	"github.com/lucasassuncao/movelooper/internal/cmd"
 	"github.com/lucasassuncao/movelooper/internal/models"